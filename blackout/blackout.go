@@ -0,0 +1,209 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blackout implements blackout windows, periods of time such as
+// change freezes during which Elon does not terminate employees
+package blackout
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/FakeTwitter/elon/config"
+)
+
+// Window is a period of time during which no employees may be terminated.
+//
+// A window may be scoped to some accounts, regions or apps. An empty list
+// matches everything, so a window with no scope applies to the whole fleet.
+type Window struct {
+	// Start is the beginning of the window, inclusive
+	Start time.Time
+
+	// End is the end of the window, exclusive
+	End time.Time
+
+	Accounts []string
+	Regions  []string
+	Teams    []string
+
+	// Reason is a human-readable explanation for the window, e.g.
+	// "holiday change freeze"
+	Reason string
+}
+
+// Matches returns true if the window is in effect at t for employees of app
+// in account and region.
+//
+// region may be blank when the region isn't known (e.g., a group that spans
+// all regions). In that case, only windows that aren't scoped to regions
+// match.
+func (w Window) Matches(t time.Time, app, account, region string) bool {
+	if t.Before(w.Start) || !t.Before(w.End) {
+		return false
+	}
+
+	return contains(w.Teams, app) && contains(w.Accounts, account) && contains(w.Regions, region)
+}
+
+// String implements fmt.Stringer
+func (w Window) String() string {
+	s := fmt.Sprintf("blackout %s - %s", w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+	if w.Reason != "" {
+		s = fmt.Sprintf("%s (%s)", s, w.Reason)
+	}
+	return s
+}
+
+// contains returns true if values is empty or if it contains s
+func contains(values []string, s string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Blackouts is a list of blackout windows
+type Blackouts []Window
+
+// Active returns the first window that is in effect at t for employees of
+// app in account and region, if any
+func (b Blackouts) Active(t time.Time, app, account, region string) (Window, bool) {
+	for _, w := range b {
+		if w.Matches(t, app, account, region) {
+			return w, true
+		}
+	}
+
+	return Window{}, false
+}
+
+// FromConfig loads the blackout windows from the file specified in cfg.
+// Returns no windows if no file is specified.
+//
+// The file is read on every call, so changes to it take effect the next time
+// a schedule is generated or a termination is attempted.
+func FromConfig(cfg *config.Monkey) (Blackouts, error) {
+	path := cfg.BlackoutsPath()
+	if path == "" {
+		return nil, nil
+	}
+
+	loc, err := cfg.Location()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve location")
+	}
+
+	b, err := Load(path, loc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "blackout file %s", path)
+	}
+
+	return b, nil
+}
+
+// Load loads blackout windows from a YAML file that looks like:
+//
+//  blackouts:
+//    - start: 2016-12-19
+//      end: 2017-01-03
+//      reason: holiday change freeze
+//    - start: 2016-11-08T16:00:00-08:00
+//      end: 2016-11-08T20:00:00-08:00
+//      accounts: [prod]
+//      regions: [us-west-2]
+//      apps: [checkout]
+//
+// Times are in RFC 3339 format. Dates without a time are midnight in loc, so
+// a window that ends on a date does not include that date.
+func Load(path string, loc *time.Location) (Blackouts, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Blackouts []struct {
+			Start    string   `yaml:"start"`
+			End      string   `yaml:"end"`
+			Reason   string   `yaml:"reason"`
+			Accounts []string `yaml:"accounts"`
+			Regions  []string `yaml:"regions"`
+			Teams    []string `yaml:"apps"`
+		} `yaml:"blackouts"`
+	}
+
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(Blackouts, 0, len(doc.Blackouts))
+	for i, entry := range doc.Blackouts {
+		start, err := parseTime(entry.Start, loc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "blackout %d: invalid start", i+1)
+		}
+
+		end, err := parseTime(entry.End, loc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "blackout %d: invalid end", i+1)
+		}
+
+		if !start.Before(end) {
+			return nil, errors.Errorf("blackout %d: start %s is not before end %s", i+1, entry.Start, entry.End)
+		}
+
+		result = append(result, Window{
+			Start:    start,
+			End:      end,
+			Accounts: entry.Accounts,
+			Regions:  entry.Regions,
+			Teams:    entry.Teams,
+			Reason:   entry.Reason,
+		})
+	}
+
+	return result, nil
+}
+
+// parseTime parses s as an RFC 3339 time, or as a date at midnight in loc
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, errors.New("missing time")
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+
+	t, err = time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%q is neither an RFC 3339 time nor a date", s)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blackout_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon/blackout"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+)
+
+const yml = `blackouts:
+  - start: 2016-12-19
+    end: 2017-01-03
+    reason: holiday change freeze
+  - start: 2016-11-08T16:00:00-08:00
+    end: 2016-11-08T20:00:00-08:00
+    accounts: [prod]
+    regions: [us-west-2]
+    apps: [checkout]
+`
+
+// writeFile writes contents to a file named name in a new temporary
+// directory, and returns its path
+func writeFile(t *testing.T, name, contents string) string {
+	dir, err := ioutil.TempDir("", "blackout")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestFromConfig(t *testing.T) {
+	path := writeFile(t, "blackouts.yaml", yml)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	cfg := config.Defaults()
+	cfg.Set(param.TimeZone, "America/Los_Angeles")
+	cfg.Set(param.BlackoutsPath, path)
+
+	b, err := blackout.FromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(b), 2; got != want {
+		t.Fatalf("len(b)=%d, want %d", got, want)
+	}
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := b[0].Start, time.Date(2016, time.December, 19, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("b[0].Start=%s, want %s", got, want)
+	}
+
+	if got, want := b[0].Reason, "holiday change freeze"; got != want {
+		t.Errorf("b[0].Reason=%q, want %q", got, want)
+	}
+
+	tests := []struct {
+		tm                   time.Time
+		app, account, region string
+		want                 bool
+	}{
+		{time.Date(2016, time.December, 18, 23, 59, 0, 0, loc), "foo", "prod", "us-east-1", false},
+		{time.Date(2016, time.December, 19, 0, 0, 0, 0, loc), "foo", "prod", "us-east-1", true},
+		{time.Date(2017, time.January, 2, 23, 59, 0, 0, loc), "foo", "test", "", true},
+		{time.Date(2017, time.January, 3, 0, 0, 0, 0, loc), "foo", "prod", "us-east-1", false},
+		{time.Date(2016, time.November, 8, 17, 0, 0, 0, loc), "checkout", "prod", "us-west-2", true},
+		{time.Date(2016, time.November, 8, 17, 0, 0, 0, loc), "checkout", "test", "us-west-2", false},
+		{time.Date(2016, time.November, 8, 17, 0, 0, 0, loc), "checkout", "prod", "us-east-1", false},
+		{time.Date(2016, time.November, 8, 17, 0, 0, 0, loc), "checkout", "prod", "", false},
+		{time.Date(2016, time.November, 8, 17, 0, 0, 0, loc), "foo", "prod", "us-west-2", false},
+		{time.Date(2016, time.November, 8, 20, 0, 0, 0, loc), "checkout", "prod", "us-west-2", false},
+	}
+
+	for _, tt := range tests {
+		_, got := b.Active(tt.tm, tt.app, tt.account, tt.region)
+		if got != tt.want {
+			t.Errorf("Active(%s, %s, %s, %s)=%t, want %t", tt.tm, tt.app, tt.account, tt.region, got, tt.want)
+		}
+	}
+}
+
+func TestFromConfigNoPath(t *testing.T) {
+	b, err := blackout.FromConfig(config.Defaults())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := b.Active(time.Now(), "foo", "prod", "us-east-1"); ok {
+		t.Error("Expected no blackout when no blackout file is configured")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []string{
+		"blackouts:\n  - start: 2016-12-19\n",
+		"blackouts:\n  - start: 2016-12-19\n    end: tomorrow\n",
+		"blackouts:\n  - start: 2016-12-19\n    end: 2016-12-19\n",
+		"blackouts:\n  - start: 2017-01-03\n    end: 2016-12-19\n",
+	}
+
+	for _, contents := range tests {
+		path := writeFile(t, "blackouts.yaml", contents)
+		_, err := blackout.Load(path, time.UTC)
+		os.RemoveAll(filepath.Dir(path))
+		if err == nil {
+			t.Errorf("Expected error loading %q", contents)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cal
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+)
+
+// Calendar determines which days are work days
+type Calendar interface {
+	// IsWorkday returns true if the date associated with t is a work day
+	// Uses the location associated with t to make this calculation
+	IsWorkday(t time.Time) bool
+}
+
+// Default is the Monday to Friday calendar, without any holidays
+var Default Calendar = NewWorkWeek([]time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, nil)
+
+// date is a year/month/day, without a time zone
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) date {
+	year, month, day := t.Date()
+	return date{year, month, day}
+}
+
+// WorkWeek is a calendar where the work days are a fixed set of days of the
+// week, except for holidays
+type WorkWeek struct {
+	workdays [7]bool
+	holidays map[date]bool
+}
+
+// NewWorkWeek returns a calendar where workdays are the work days of the week
+// and holidays are days off. Only the year, month and day of each holiday
+// are used.
+func NewWorkWeek(workdays []time.Weekday, holidays []time.Time) *WorkWeek {
+	w := &WorkWeek{holidays: make(map[date]bool)}
+	for _, d := range workdays {
+		w.workdays[d] = true
+	}
+	for _, h := range holidays {
+		w.holidays[dateOf(h)] = true
+	}
+	return w
+}
+
+// IsWorkday implements Calendar.IsWorkday
+func (w *WorkWeek) IsWorkday(t time.Time) bool {
+	return w.workdays[t.Weekday()] && !w.holidays[dateOf(t)]
+}
+
+// Regional holds a calendar for each region that has its own calendar,
+// and a default calendar for all other regions
+type Regional struct {
+	Default Calendar
+	Regions map[string]Calendar
+}
+
+// ForRegion returns the calendar for region.
+// If region is empty or has no calendar of its own, returns the default
+// calendar
+func (r Regional) ForRegion(region string) Calendar {
+	if c, ok := r.Regions[region]; ok {
+		return c
+	}
+
+	if r.Default != nil {
+		return r.Default
+	}
+
+	return Default
+}
+
+// IsWorkday returns true if t is a work day in the default calendar
+func (r Regional) IsWorkday(t time.Time) bool {
+	return r.ForRegion("").IsWorkday(t)
+}
+
+// IsWorkdayAnywhere returns true if t is a work day in the default calendar
+// or in any of the regional calendars
+func (r Regional) IsWorkdayAnywhere(t time.Time) bool {
+	if r.IsWorkday(t) {
+		return true
+	}
+
+	for _, c := range r.Regions {
+		if c.IsWorkday(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewFromConfig creates the calendars described by the "calendar" section of
+// cfg, loading the holiday files it refers to
+func NewFromConfig(cfg *config.Monkey) (Regional, error) {
+	workdays, err := cfg.CalendarWorkdays()
+	if err != nil {
+		return Regional{}, err
+	}
+
+	holidays, err := cfg.CalendarHolidays()
+	if err != nil {
+		return Regional{}, err
+	}
+
+	def, err := newWorkWeek(workdays, holidays)
+	if err != nil {
+		return Regional{}, err
+	}
+
+	r := Regional{Default: def, Regions: make(map[string]Calendar)}
+
+	for _, region := range cfg.CalendarRegions() {
+		regionWorkdays, err := cfg.RegionCalendarWorkdays(region)
+		if err != nil {
+			return Regional{}, err
+		}
+
+		regionHolidays, err := cfg.RegionCalendarHolidays(region)
+		if err != nil {
+			return Regional{}, err
+		}
+
+		// Regional holidays are in addition to the ones that apply everywhere
+		c, err := newWorkWeek(regionWorkdays, append(regionHolidays, holidays...))
+		if err != nil {
+			return Regional{}, errors.Wrapf(err, "calendar for region %s", region)
+		}
+
+		r.Regions[region] = c
+	}
+
+	return r, nil
+}
+
+// newWorkWeek returns a calendar from a list of weekday names and a list of
+// holiday files
+func newWorkWeek(names []string, files []string) (*WorkWeek, error) {
+	workdays := make([]time.Weekday, 0, len(names))
+	for _, name := range names {
+		d, err := ParseWeekday(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", param.CalendarWorkdays)
+		}
+		workdays = append(workdays, d)
+	}
+
+	var holidays []time.Time
+	for _, file := range files {
+		h, err := LoadHolidays(file)
+		if err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, h...)
+	}
+
+	return NewWorkWeek(workdays, holidays), nil
+}
+
+// ParseWeekday parses the name of a day of the week, either in full or
+// abbreviated to three letters, e.g., "Sunday" or "sun"
+func ParseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := d.String()
+		if strings.EqualFold(s, name) || strings.EqualFold(s, name[:3]) {
+			return d, nil
+		}
+	}
+
+	return time.Sunday, errors.Errorf("unknown day of the week: %q", s)
+}
+
+// LoadHolidays loads a list of holidays from a file.
+// The format of the file is determined by its extension: ".ics" for
+// iCalendar files and ".yaml" or ".yml" for YAML files.
+func LoadHolidays(path string) ([]time.Time, error) {
+	var holidays []time.Time
+	var err error
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".ics":
+		holidays, err = loadICS(path)
+	case ".yaml", ".yml":
+		holidays, err = loadYAML(path)
+	default:
+		return nil, errors.Errorf("holiday file %s: unsupported file extension %q", path, ext)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "holiday file %s", path)
+	}
+
+	sort.Sort(byTime(holidays))
+	return holidays, nil
+}
+
+type byTime []time.Time
+
+func (t byTime) Len() int           { return len(t) }
+func (t byTime) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t byTime) Less(i, j int) bool { return t[i].Before(t[j]) }
+
+// loadYAML loads holidays from a YAML file that looks like:
+//
+//  holidays:
+//    - 2016-12-26
+//    - 2017-01-02
+func loadYAML(path string) ([]time.Time, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Holidays []string `yaml:"holidays"`
+	}
+
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make([]time.Time, 0, len(doc.Holidays))
+	for _, s := range doc.Holidays {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid holiday %q", s)
+		}
+		holidays = append(holidays, t)
+	}
+
+	return holidays, nil
+}
+
+// loadICS loads holidays from the events of an iCalendar (RFC 5545) file.
+//
+// Every day covered by an event is a holiday, from its DTSTART up to (but not
+// including) its DTEND. A DTEND with a time of day after midnight also covers
+// its date, e.g., an event from 9AM to 5PM on the same day. Recurring events
+// are not supported: each holiday must be its own event, which is how public
+// holiday calendars are usually published.
+func loadICS(path string) ([]time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines, err := unfold(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var holidays []time.Time
+	var inEvent bool
+	var start, end time.Time
+
+	for _, line := range lines {
+		name, value := splitProperty(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			start, end = time.Time{}, time.Time{}
+		case !inEvent:
+			// not part of an event, ignore
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				return nil, errors.New("event without DTSTART")
+			}
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				holidays = append(holidays, d)
+			}
+		case name == "DTSTART":
+			start, _, err = parseICSDate(value)
+			if err != nil {
+				return nil, err
+			}
+		case name == "DTEND":
+			var partial bool
+			end, partial, err = parseICSDate(value)
+			if err != nil {
+				return nil, err
+			}
+			if partial {
+				end = end.AddDate(0, 0, 1)
+			}
+		case name == "RRULE" || name == "RDATE":
+			return nil, errors.Errorf("recurring events are not supported: %s", line)
+		}
+	}
+
+	return holidays, nil
+}
+
+// unfold reads the content lines of an iCalendar file, joining lines that
+// were split ("folded") across multiple physical lines
+func unfold(f *os.File) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// splitProperty splits an iCalendar content line into the property name and
+// value, discarding any property parameters, e.g.,
+// "DTSTART;VALUE=DATE:20161226" returns "DTSTART", "20161226"
+func splitProperty(line string) (name string, value string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return strings.ToUpper(line), ""
+	}
+
+	name = line[:i]
+	if j := strings.Index(name, ";"); j >= 0 {
+		name = name[:j]
+	}
+
+	return strings.ToUpper(name), line[i+1:]
+}
+
+// parseICSDate parses an iCalendar DATE or DATE-TIME value, and returns the
+// date at midnight UTC. partial is true for a DATE-TIME value with a time of
+// day after midnight, which only covers part of its date.
+func parseICSDate(value string) (date time.Time, partial bool, err error) {
+	if len(value) < 8 {
+		return time.Time{}, false, errors.Errorf("invalid date: %q", value)
+	}
+
+	date, err = time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, false, errors.Wrapf(err, "invalid date: %q", value)
+	}
+
+	if len(value) == 8 {
+		return date, false, nil
+	}
+
+	if len(value) < 15 || value[8] != 'T' {
+		return time.Time{}, false, errors.Errorf("invalid date-time: %q", value)
+	}
+
+	_, err = time.Parse("150405", value[9:15])
+	if err != nil {
+		return time.Time{}, false, errors.Wrapf(err, "invalid date-time: %q", value)
+	}
+
+	return date, value[9:15] != "000000", nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon/cal"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+)
+
+const ics = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Example//Holidays//EN
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20151225
+DTEND;VALUE=DATE:20151226
+SUMMARY:Christmas Day
+END:VEVENT
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20151228
+DTEND;VALUE=DATE:20151231
+SUMMARY:Company
+  shutdown
+END:VEVENT
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20160101
+SUMMARY:New Year's Day
+END:VEVENT
+END:VCALENDAR
+`
+
+// timedICS has events with a time of day, whose DTEND date is a holiday
+// unless they end at midnight
+const timedICS = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+DTSTART:20261225T090000
+DTEND:20261225T170000
+SUMMARY:Christmas party
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20261228T120000Z
+DTEND:20261229T120000Z
+SUMMARY:Offsite
+END:VEVENT
+BEGIN:VEVENT
+DTSTART;TZID=America/Los_Angeles:20261230T090000
+DTEND;TZID=America/Los_Angeles:20261231T000000
+SUMMARY:Half day
+END:VEVENT
+END:VCALENDAR
+`
+
+const yml = `holidays:
+  - 2015-12-25
+  - 2016-01-01
+`
+
+// writeFile writes contents to a file named name in a new temporary
+// directory, and returns its path
+func writeFile(t *testing.T, name, contents string) string {
+	dir, err := ioutil.TempDir("", "cal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadHolidays(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []string
+	}{
+		{"holidays.ics", strings.Replace(ics, "\n", "\r\n", -1), []string{"2015-12-25", "2015-12-28", "2015-12-29", "2015-12-30", "2016-01-01"}},
+		{"timed.ics", timedICS, []string{"2026-12-25", "2026-12-28", "2026-12-29", "2026-12-30"}},
+		{"holidays.yaml", yml, []string{"2015-12-25", "2016-01-01"}},
+	}
+
+	for _, tt := range tests {
+		path := writeFile(t, tt.name, tt.contents)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		holidays, err := cal.LoadHolidays(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		var got []string
+		for _, h := range holidays {
+			got = append(got, h.Format("2006-01-02"))
+		}
+
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadHolidaysRejectsRecurringEvents(t *testing.T) {
+	contents := "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20151225\nRRULE:FREQ=YEARLY\nEND:VEVENT\n"
+	path := writeFile(t, "holidays.ics", contents)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	_, err := cal.LoadHolidays(path)
+	if err == nil {
+		t.Fatal("got nil, want error")
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Weekday
+	}{
+		{"sun", time.Sunday},
+		{"Thu", time.Thursday},
+		{"SATURDAY", time.Saturday},
+	}
+
+	for _, tt := range tests {
+		got, err := cal.ParseWeekday(tt.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("got ParseWeekday(%q)=%s, want %s", tt.s, got, tt.want)
+		}
+	}
+
+	_, err := cal.ParseWeekday("Funday")
+	if err == nil {
+		t.Error("got ParseWeekday(\"Funday\")=nil, want error")
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	global := writeFile(t, "holidays.yaml", yml)
+	defer os.RemoveAll(filepath.Dir(global))
+
+	regional := writeFile(t, "holidays.yml", "holidays:\n  - 2015-12-24\n")
+	defer os.RemoveAll(filepath.Dir(regional))
+
+	cfg := config.Defaults()
+	cfg.Set(param.CalendarHolidays, []string{global})
+	cfg.Set(param.CalendarRegions, map[string]interface{}{
+		"me-south-1": map[string]interface{}{
+			"workdays": []string{"Sun", "Mon", "Tue", "Wed", "Thu"},
+			"holidays": []string{regional},
+		},
+	})
+
+	cals, err := cal.NewFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		region string
+		date   string
+		want   bool
+	}{
+		{"us-east-1", "Fri Dec 18 11:33:58 PST 2015", true},
+		{"us-east-1", "Sun Dec 20 11:33:58 PST 2015", false},
+		{"us-east-1", "Thu Dec 24 11:33:58 PST 2015", true},
+		{"us-east-1", "Fri Dec 25 11:33:58 PST 2015", false},
+		{"me-south-1", "Fri Dec 18 11:33:58 PST 2015", false},
+		{"me-south-1", "Sun Dec 20 11:33:58 PST 2015", true},
+		{"me-south-1", "Thu Dec 24 11:33:58 PST 2015", false},
+		{"me-south-1", "Thu Dec 31 11:33:58 PST 2015", true},
+	}
+
+	for _, tt := range tests {
+		if got := cals.ForRegion(tt.region).IsWorkday(parse(tt.date)); got != tt.want {
+			t.Errorf("%s: got IsWorkday(\"%s\")=%t, want %t", tt.region, tt.date, got, tt.want)
+		}
+	}
+}
@@ -12,9 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package mysql
+package cal
 
-// This file contains test for the config.NoFiresSince method
+// This file contains test for the NoFiresSince function
 
 import (
 	"testing"
@@ -125,8 +125,7 @@ func TestZeroDaysBetweenFires(t *testing.T) {
 		{2, "Tue Apr 12 12:35:46 2016 -0700", "Fri Apr  8 15:00:00 2016 -0700"},
 		{2, "Tue Nov  8 12:35:46 2016 -0800", "Fri Nov  4 15:00:00 2016 -0700"},
 
-		// year boundary. Note: this'll break when we support holidays as
-		// non-workdays
+		// year boundary. Note: the Default calendar has no holidays
 		{1, "Fri Jan  1 12:05:00 2016 -0800", "Thu Dec 31 15:00:00 2015 -0800"},
 
 		// try a larger number
@@ -140,12 +139,50 @@ func TestZeroDaysBetweenFires(t *testing.T) {
 
 	endHour := 15 // typically we run elon until 3PM
 	for _, tt := range tests {
-		got, err := noFiresSince(tt.days, parse(tt.now), endHour, tz)
+		got, err := NoFiresSince(Default, tt.days, parse(tt.now), endHour, tz)
 		if err != nil {
 			t.Fatal(err)
 		}
 		if want := parse(tt.since); got != want {
-			t.Errorf("noFiresSince(%d, \"%s\")=\"%s\", want \"%s\"", tt.days, tt.now, format(got.In(tz)), format(want.In(tz)))
+			t.Errorf("NoFiresSince(%d, \"%s\")=\"%s\", want \"%s\"", tt.days, tt.now, format(got.In(tz)), format(want.In(tz)))
+		}
+	}
+}
+
+func TestNoFiresSinceSkipsHolidays(t *testing.T) {
+	tz, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	sunToThu := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday}
+	holidays := []time.Time{
+		time.Date(2015, time.December, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(2015, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		cal   Calendar
+		days  int
+		now   string
+		since string
+	}{
+		{NewWorkWeek(weekdays, holidays), 1, "Fri Jan  1 12:05:00 2016 -0800", "Wed Dec 30 15:00:00 2015 -0800"},
+		{NewWorkWeek(weekdays, holidays), 1, "Mon Dec 28 12:05:00 2015 -0800", "Thu Dec 24 15:00:00 2015 -0800"},
+		{NewWorkWeek(sunToThu, nil), 1, "Sun Dec 20 12:05:00 2015 -0800", "Thu Dec 17 15:00:00 2015 -0800"},
+		{NewWorkWeek(sunToThu, nil), 1, "Mon Dec 21 12:05:00 2015 -0800", "Sun Dec 20 15:00:00 2015 -0800"},
+		{NewWorkWeek(sunToThu, holidays), 2, "Sun Dec 27 12:05:00 2015 -0800", "Wed Dec 23 15:00:00 2015 -0800"},
+	}
+
+	endHour := 15
+	for _, tt := range tests {
+		got, err := NoFiresSince(tt.cal, tt.days, parse(tt.now), endHour, tz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := parse(tt.since); got != want {
+			t.Errorf("NoFiresSince(%d, \"%s\")=\"%s\", want \"%s\"", tt.days, tt.now, format(got.In(tz)), format(want.In(tz)))
 		}
 	}
 }
@@ -0,0 +1,84 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/deploy"
+	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/inventory"
+	"github.com/FakeTwitter/elon/sysbreaker"
+)
+
+// backend provides the deployed apps and their configs, and terminates
+// employees
+type backend interface {
+	deploy.Deployment
+	elon.TeamConfigGetter
+	elon.Terminator
+}
+
+// newKubernetes returns the kubernetes backend. It is only set when elon is
+// built with the kubernetes build tag, so that the default build doesn't
+// depend on client-go.
+var newKubernetes func(cfg *config.Monkey) (backend, error)
+
+func init() {
+	deps.GetOwners = getOwners
+}
+
+// getBackend returns the backend selected by the elon.backend config
+// parameter
+func getBackend(cfg *config.Monkey) (backend, error) {
+	switch name := cfg.Backend(); name {
+	case "sysbreaker":
+		return sysbreaker.NewFromConfig(cfg)
+	case "kubernetes":
+		if newKubernetes == nil {
+			return nil, errors.Errorf("%s=kubernetes is not supported by this build of elon, build it with -tags kubernetes", param.Backend)
+		}
+		return newKubernetes(cfg)
+	case "inventory":
+		return inventory.NewFromConfig(cfg)
+	default:
+		return nil, errors.Errorf("unsupported %s: %s", param.Backend, name)
+	}
+}
+
+// getOwners returns the backend selected by the elon.backend config
+// parameter, to look up the owners of apps
+func getOwners(cfg *config.Monkey) (elon.OwnerGetter, error) {
+	be, err := getBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return ownersOf(be, cfg)
+}
+
+// ownersOf returns be as an elon.OwnerGetter, or an error if it doesn't know
+// the owners of apps
+func ownersOf(be interface{}, cfg *config.Monkey) (elon.OwnerGetter, error) {
+	owners, ok := be.(elon.OwnerGetter)
+	if !ok {
+		return nil, errors.Errorf("the owners of apps are not supported by the %s backend", cfg.Backend())
+	}
+
+	return owners, nil
+}
@@ -0,0 +1,341 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon/cal"
+	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/schedstore"
+	"github.com/FakeTwitter/elon/schedule"
+	"github.com/FakeTwitter/elon/term"
+)
+
+// daemon runs the daily schedule of terminations in-process, instead of
+// handing each termination off to cron
+type daemon struct {
+	d    deps.Deps
+	ss   schedstore.SchedStore
+	cons schedule.Constrainer
+	apps []string
+
+	// after waits for a duration to elapse, it is replaced when testing
+	after func(d time.Duration) <-chan time.Time
+}
+
+// Daemon executes the "daemon" command. It runs as a long-lived process that
+// generates (or fetches) the schedule of terminations each work day and then
+// executes each termination at its scheduled time.
+//
+// The daemon shuts down when it receives SIGTERM or SIGINT. A termination
+// that is in progress is allowed to complete before exiting. Metrics are
+// served at /metrics while it runs, if metrics.address is set.
+func Daemon(d deps.Deps, ss schedstore.SchedStore, cons schedule.Constrainer, apps []string) {
+	log.Println("elon daemon starting")
+	defer log.Println("elon daemon done")
+
+	stop := make(chan struct{})
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+
+	go func() {
+		sig := <-sigs
+		log.Printf("received %s, shutting down", sig)
+		close(stop)
+	}()
+
+	serveMetrics(d.MonkeyCfg)
+
+	dm := daemon{d: d, ss: ss, cons: cons, apps: apps, after: time.After}
+	err := dm.run(stop)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+}
+
+// run executes the daily schedule of terminations until stop is closed
+func (dm daemon) run(stop <-chan struct{}) error {
+	loc, err := dm.d.MonkeyCfg.Location()
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve local timezone")
+	}
+
+	for {
+		now := dm.d.Cl.Now().In(loc)
+
+		sched, ok := dm.retrySchedule(now, stop)
+		if !ok {
+			return nil
+		}
+
+		if sched != nil && !dm.execute(sched, stop) {
+			return nil
+		}
+
+		if !dm.sleepUntil(nextDay(now), stop) {
+			return nil
+		}
+	}
+}
+
+// minRetryBackoff and maxRetryBackoff bound how long the daemon waits
+// between attempts to obtain the schedule of the day
+const (
+	minRetryBackoff = time.Minute
+	maxRetryBackoff = 30 * time.Minute
+)
+
+// retrySchedule obtains the schedule of terminations for the date of now,
+// retrying with exponential backoff until the end of the termination window.
+// Returns a nil schedule if there is nothing to do today or it could not be
+// obtained in time, and false if the daemon was asked to stop.
+func (dm daemon) retrySchedule(now time.Time, stop <-chan struct{}) (*schedule.Schedule, bool) {
+	year, month, day := now.Date()
+	end := time.Date(year, month, day, dm.d.MonkeyCfg.EndHour(), 0, 0, 0, now.Location())
+
+	backoff := minRetryBackoff
+	for {
+		sched, err := dm.schedule(now)
+		if err == nil {
+			return sched, true
+		}
+
+		log.Printf("ERROR: could not obtain schedule for %s: %v", now.Format("2006-01-02"), err)
+		dm.incrementErrorCounter()
+
+		retry := dm.d.Cl.Now().Add(backoff)
+		if !retry.Before(end) {
+			log.Printf("giving up on the schedule for %s, the termination window is over", now.Format("2006-01-02"))
+			return nil, true
+		}
+
+		log.Printf("retrying in %s", backoff)
+		if !dm.sleepUntil(retry, stop) {
+			return nil, false
+		}
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// schedule returns the schedule of terminations for the date of now.
+//
+// If a schedule has already been published for today (e.g., the daemon was
+// restarted), that schedule is used. Otherwise, a new schedule is generated
+// and published. Returns nil if there is nothing to do today.
+func (dm daemon) schedule(now time.Time) (*schedule.Schedule, error) {
+	cals, err := cal.NewFromConfig(dm.d.MonkeyCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load calendars")
+	}
+
+	if !cals.IsWorkdayAnywhere(now) {
+		log.Printf("%s is not a work day, not scheduling", now.Format("Mon 2006-01-02"))
+		return nil, nil
+	}
+
+	sched, err := dm.ss.Retrieve(now)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve schedule")
+	}
+
+	if sched != nil && len(sched.Entries()) > 0 {
+		log.Printf("using previously published schedule with %d entries", len(sched.Entries()))
+		return sched, nil
+	}
+
+	enabled, err := dm.d.MonkeyCfg.ScheduleEnabled()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot determine if schedule is enabled")
+	}
+
+	if !enabled {
+		log.Println("schedule disabled, not scheduling")
+		return nil, nil
+	}
+
+	s := schedule.New()
+	err = s.Populate(dm.d.Dep, dm.d.ConfGetter, dm.d.MonkeyCfg, dm.apps)
+	if failure, ok := err.(schedule.ErrAppsFailed); ok && !failure.AllFailed() {
+		// Schedule the apps that could be retrieved
+		log.Printf("ERROR: scheduling without %d apps: %v", len(failure.Failed), failure)
+		dm.incrementErrorCounter()
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to populate schedule")
+	}
+
+	// Filter out terminations that violate constrains
+	filtered := filter(dm.cons, *s)
+
+	err = dm.ss.Publish(now, &filtered)
+	switch err {
+	case nil:
+		emailDigests(dm.d.MonkeyCfg, dm.d.Dep, &filtered, now)
+		return &filtered, nil
+	case schedstore.ErrAlreadyExists:
+		// Somebody else published a schedule after we checked, use theirs
+		return dm.ss.Retrieve(now)
+	default:
+		return nil, errors.Wrap(err, "could not publish schedule")
+	}
+}
+
+// event is a termination of the schedule, or the warning of one, that the
+// daemon runs at time at
+type event struct {
+	at      time.Time
+	entry   schedule.Entry
+	warning bool
+}
+
+// execute runs each entry of the schedule at its scheduled time, warning of
+// it elon.warning_minutes before. Entries and warnings that are already in
+// the past are skipped, so that a daemon that restarts during the day doesn't
+// warn again of the same terminations.
+// Returns false if the daemon was asked to stop.
+func (dm daemon) execute(sched *schedule.Schedule, stop <-chan struct{}) bool {
+	entries := make([]schedule.Entry, len(sched.Entries()))
+	copy(entries, sched.Entries())
+	sort.Sort(schedule.ByTime(entries))
+
+	lead := dm.d.MonkeyCfg.WarningLead()
+
+	now := dm.d.Cl.Now()
+
+	var events []event
+	for _, entry := range entries {
+		if entry.Time.Before(now) {
+			log.Printf("skipping %s: scheduled time %s has already passed", grp.String(entry.Group), entry.Time)
+			continue
+		}
+
+		if lead > 0 {
+			at := entry.Time.Add(-lead)
+			if at.Before(now) {
+				log.Printf("not warning of %s: warning time %s has already passed", grp.String(entry.Group), at)
+			} else {
+				events = append(events, event{at: at, entry: entry, warning: true})
+			}
+		}
+		events = append(events, event{at: entry.Time, entry: entry})
+	}
+
+	// The warning of a termination may be due before earlier terminations.
+	// Warnings go first when they are due at the same time as a termination.
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].warning && !events[j].warning
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	for _, e := range events {
+		if !dm.sleepUntil(e.at, stop) {
+			return false
+		}
+
+		if e.warning {
+			dm.warn(e.entry.Group, e.entry.Time)
+		} else {
+			dm.terminate(e.entry.Group, sched.EntrySeed(e.entry))
+		}
+	}
+
+	return true
+}
+
+// warn warns that an employee will be fired from group at t, logging any
+// failures. The group is identified like for terminate.
+func (dm daemon) warn(group grp.employeeGroup, t time.Time) {
+	region, _ := group.Region()
+	stack, _ := group.Stack()
+	team, _ := group.Team()
+
+	err := term.Warn(dm.d, group.Team(), group.Account(), region, stack, team, t)
+	if err != nil {
+		log.Printf("ERROR: warning failed for %s: %+v", grp.String(group), err)
+		dm.incrementErrorCounter()
+	}
+}
+
+// terminate fires an employee from group, logging any failures. The
+// employee is selected with the given seed, like the terminate command that
+// cron would run.
+func (dm daemon) terminate(group grp.employeeGroup, seed int64) {
+	region, _ := group.Region()
+	stack, _ := group.Stack()
+	team, _ := group.Team()
+
+	d := dm.d
+	d.Rand = rand.New(rand.NewSource(seed))
+
+	log.Printf("terminating from %s seed=%d", grp.String(group), seed)
+	err := term.Terminate(d, group.Team(), group.Account(), region, stack, team)
+	if err != nil {
+		log.Printf("ERROR: termination failed for %s: %+v", grp.String(group), err)
+		dm.incrementErrorCounter()
+	}
+}
+
+// sleepUntil blocks until the clock reaches t.
+// Returns false if the daemon was asked to stop.
+func (dm daemon) sleepUntil(t time.Time, stop <-chan struct{}) bool {
+	d := t.Sub(dm.d.Cl.Now())
+	if d <= 0 {
+		select {
+		case <-stop:
+			return false
+		default:
+			return true
+		}
+	}
+
+	select {
+	case <-stop:
+		return false
+	case <-dm.after(d):
+		return true
+	}
+}
+
+func (dm daemon) incrementErrorCounter() {
+	if dm.d.ErrCounter == nil {
+		return
+	}
+
+	err := dm.d.ErrCounter.Increment()
+	if err != nil {
+		log.Printf("WARNING could not increment error counter: %v", err)
+	}
+}
+
+// nextDay returns midnight at the start of the day after t, in t's location
+func nextDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
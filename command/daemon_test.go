@@ -0,0 +1,281 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/constrainer"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/mock"
+	"github.com/FakeTwitter/elon/schedstore"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// memSchedStore is an in-memory implementation of schedstore.SchedStore
+type memSchedStore struct {
+	scheds map[string]*schedule.Schedule
+}
+
+func (m *memSchedStore) Retrieve(date time.Time) (*schedule.Schedule, error) {
+	return m.scheds[date.Format("2006-01-02")], nil
+}
+
+func (m *memSchedStore) Publish(date time.Time, sched *schedule.Schedule) error {
+	key := date.Format("2006-01-02")
+	if _, ok := m.scheds[key]; ok {
+		return schedstore.ErrAlreadyExists
+	}
+	m.scheds[key] = sched
+	return nil
+}
+
+// flakySchedStore is a memSchedStore that fails to retrieve schedules a
+// number of times first
+type flakySchedStore struct {
+	memSchedStore
+	failures int
+	calls    int
+}
+
+func (f *flakySchedStore) Retrieve(date time.Time) (*schedule.Schedule, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("connection refused")
+	}
+	return f.memSchedStore.Retrieve(date)
+}
+
+// TestDaemonExecutesSchedule verifies that the daemon fires an employee for
+// each entry in the schedule that hasn't already passed, advancing a fake
+// clock rather than sleeping
+func TestDaemonExecutesSchedule(t *testing.T) {
+	d := mock.Deps()
+	loc, err := d.MonkeyCfg.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Thu Oct 1, 2015 8:00 AM local time
+	cl := &mock.Clock{Time: time.Date(2015, time.October, 1, 8, 0, 0, 0, loc)}
+	d.Cl = cl
+	ttor := new(mock.Terminator)
+	d.T = ttor
+
+	sched := schedule.New()
+	sched.Add(time.Date(2015, time.October, 1, 7, 0, 0, 0, loc), grp.New("foo", "prod", "us-east-1", "", "foo-prod"))
+	sched.Add(time.Date(2015, time.October, 1, 11, 0, 0, 0, loc), grp.New("bar", "prod", "us-east-1", "", "bar-prod"))
+	sched.Add(time.Date(2015, time.October, 1, 10, 0, 0, 0, loc), grp.New("foo", "prod", "us-east-1", "", "foo-prod"))
+	ss := &memSchedStore{scheds: map[string]*schedule.Schedule{"2015-10-01": sched}}
+
+	stop := make(chan struct{})
+	endOfDay := time.Date(2015, time.October, 2, 0, 0, 0, 0, loc)
+
+	dm := daemon{d: d, ss: ss, cons: constrainer.NullConstrainer{}}
+	dm.after = func(dur time.Duration) <-chan time.Time {
+		cl.Time = cl.Time.Add(dur)
+
+		// Stop once the daemon has worked through the day's schedule
+		if !cl.Time.Before(endOfDay) {
+			close(stop)
+			return make(chan time.Time)
+		}
+
+		c := make(chan time.Time, 1)
+		c <- cl.Time
+		return c
+	}
+
+	err = dm.run(stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The 7:00 AM entry had already passed when the daemon started
+	if got, want := ttor.Ncalls, 2; got != want {
+		t.Errorf("got ttor.Ncalls=%d, want %d", got, want)
+	}
+}
+
+// TestDaemonRetriesSchedule verifies that the daemon retries obtaining the
+// schedule of the day when the store fails, instead of waiting for the next day
+func TestDaemonRetriesSchedule(t *testing.T) {
+	d := mock.Deps()
+	loc, err := d.MonkeyCfg.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Thu Oct 1, 2015 8:00 AM local time
+	cl := &mock.Clock{Time: time.Date(2015, time.October, 1, 8, 0, 0, 0, loc)}
+	d.Cl = cl
+	ttor := new(mock.Terminator)
+	d.T = ttor
+
+	sched := schedule.New()
+	sched.Add(time.Date(2015, time.October, 1, 10, 0, 0, 0, loc), grp.New("foo", "prod", "us-east-1", "", "foo-prod"))
+	ss := &flakySchedStore{
+		memSchedStore: memSchedStore{scheds: map[string]*schedule.Schedule{"2015-10-01": sched}},
+		failures:      1,
+	}
+
+	stop := make(chan struct{})
+	endOfDay := time.Date(2015, time.October, 2, 0, 0, 0, 0, loc)
+
+	dm := daemon{d: d, ss: ss, cons: constrainer.NullConstrainer{}}
+	dm.after = func(dur time.Duration) <-chan time.Time {
+		cl.Time = cl.Time.Add(dur)
+		if !cl.Time.Before(endOfDay) {
+			close(stop)
+			return make(chan time.Time)
+		}
+
+		c := make(chan time.Time, 1)
+		c <- cl.Time
+		return c
+	}
+
+	err = dm.run(stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ss.calls, 2; got != want {
+		t.Errorf("got ss.calls=%d, want %d", got, want)
+	}
+
+	if got, want := ttor.Ncalls, 1; got != want {
+		t.Errorf("got ttor.Ncalls=%d, want %d", got, want)
+	}
+}
+
+// orderWarner records when each warning is sent, relative to the clock and
+// to the terminations
+type orderWarner struct {
+	cl   *mock.Clock
+	ttor *mock.Terminator
+	got  []string
+}
+
+func (w *orderWarner) Warn(warning elon.Warning) error {
+	w.got = append(w.got, fmt.Sprintf("%s at %s after %d terminations", warning.App, w.cl.Time.Format("15:04"), w.ttor.Ncalls))
+	return nil
+}
+
+// TestDaemonWarnsBeforeTerminations verifies that the daemon warns of each
+// termination elon.warning_minutes before it, even when the warning is due
+// before an earlier termination, and that it doesn't send warnings that are
+// already past
+func TestDaemonWarnsBeforeTerminations(t *testing.T) {
+	d := mock.Deps()
+	d.MonkeyCfg.Set(param.WarningMinutes, 30)
+	loc, err := d.MonkeyCfg.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Thu Oct 1, 2015 8:00 AM local time
+	cl := &mock.Clock{Time: time.Date(2015, time.October, 1, 8, 0, 0, 0, loc)}
+	d.Cl = cl
+	ttor := new(mock.Terminator)
+	d.T = ttor
+	w := &orderWarner{cl: cl, ttor: ttor}
+	d.Warners = []elon.Warner{w}
+
+	sched := schedule.New()
+	sched.Add(time.Date(2015, time.October, 1, 10, 0, 0, 0, loc), grp.New("foo", "prod", "us-east-1", "", "foo-prod"))
+	sched.Add(time.Date(2015, time.October, 1, 10, 10, 0, 0, loc), grp.New("bar", "prod", "us-east-1", "", "bar-prod"))
+
+	// The warning was due at 7:40 AM, before the daemon started, so it is
+	// not sent
+	sched.Add(time.Date(2015, time.October, 1, 8, 10, 0, 0, loc), grp.New("baz", "prod", "us-east-1", "", "baz-prod"))
+	ss := &memSchedStore{scheds: map[string]*schedule.Schedule{"2015-10-01": sched}}
+
+	stop := make(chan struct{})
+	endOfDay := time.Date(2015, time.October, 2, 0, 0, 0, 0, loc)
+
+	dm := daemon{d: d, ss: ss, cons: constrainer.NullConstrainer{}}
+	dm.after = func(dur time.Duration) <-chan time.Time {
+		cl.Time = cl.Time.Add(dur)
+		if !cl.Time.Before(endOfDay) {
+			close(stop)
+			return make(chan time.Time)
+		}
+
+		c := make(chan time.Time, 1)
+		c <- cl.Time
+		return c
+	}
+
+	err = dm.run(stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"foo at 09:30 after 1 terminations",
+		"bar at 09:40 after 1 terminations",
+	}
+	if got := fmt.Sprint(w.got); got != fmt.Sprint(want) {
+		t.Errorf("got warnings %v, want %v", w.got, want)
+	}
+
+	if got, want := ttor.Ncalls, 3; got != want {
+		t.Errorf("got ttor.Ncalls=%d, want %d", got, want)
+	}
+}
+
+// TestDaemonSkipsWeekends verifies that the daemon does not fire employees on
+// days that aren't work days
+func TestDaemonSkipsWeekends(t *testing.T) {
+	d := mock.Deps()
+	loc, err := d.MonkeyCfg.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sat Oct 3, 2015 8:00 AM local time
+	cl := &mock.Clock{Time: time.Date(2015, time.October, 3, 8, 0, 0, 0, loc)}
+	d.Cl = cl
+	ttor := new(mock.Terminator)
+	d.T = ttor
+
+	sched := schedule.New()
+	sched.Add(time.Date(2015, time.October, 3, 10, 0, 0, 0, loc), grp.New("foo", "prod", "us-east-1", "", "foo-prod"))
+	ss := &memSchedStore{scheds: map[string]*schedule.Schedule{"2015-10-03": sched}}
+
+	stop := make(chan struct{})
+	dm := daemon{d: d, ss: ss, cons: constrainer.NullConstrainer{}}
+	dm.after = func(dur time.Duration) <-chan time.Time {
+		cl.Time = cl.Time.Add(dur)
+		close(stop)
+		return make(chan time.Time)
+	}
+
+	err = dm.run(stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ttor.Ncalls, 0; got != want {
+		t.Errorf("got ttor.Ncalls=%d, want %d", got, want)
+	}
+}
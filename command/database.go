@@ -0,0 +1,65 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/filestore"
+	"github.com/FakeTwitter/elon/mysql"
+	"github.com/FakeTwitter/elon/postgres"
+	"github.com/FakeTwitter/elon/schedstore"
+)
+
+// database stores the schedules and the terminations
+type database interface {
+	elon.Checker
+	schedstore.SchedStore
+
+	// Close releases the resources associated with the database
+	Close() error
+}
+
+// getDatabase returns the database backend selected by the database.driver
+// config parameter
+func getDatabase(cfg *config.Monkey) (database, error) {
+	switch driver := cfg.DatabaseDriver(); driver {
+	case "mysql":
+		return mysql.NewFromConfig(cfg)
+	case "postgres":
+		return postgres.NewFromConfig(cfg)
+	case "file":
+		return filestore.NewFromConfig(cfg)
+	default:
+		return nil, errors.Errorf("unsupported %s: %s", param.DatabaseDriver, driver)
+	}
+}
+
+// migrate upgrades the database to the latest schema version
+func migrate(db database) error {
+	switch db := db.(type) {
+	case mysql.MySQL:
+		return mysql.Migrate(db)
+	case postgres.Postgres:
+		return postgres.Migrate(db)
+	case filestore.FileStore:
+		return filestore.Migrate(db)
+	default:
+		return errors.Errorf("database migration not supported for %T", db)
+	}
+}
@@ -57,5 +57,7 @@ func DumpMonkeyConfig(cfg *config.Monkey) {
 	fmt.Printf("cron path: %s\n", cfg.CronPath())
 	fmt.Printf("term path: %s\n", cfg.TermPath())
 	fmt.Printf("term account: %s\n", cfg.TermAccount())
+	fmt.Printf("warn path: %s\n", cfg.WarnPath())
+	fmt.Printf("warning lead: %s\n", cfg.WarningLead())
 	fmt.Printf("max apps: %d\n", cfg.MaxTeams())
 }
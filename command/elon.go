@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"math/rand"
 	"os"
 	"runtime/debug"
 	"strings"
@@ -31,7 +32,6 @@ import (
 	"github.com/FakeTwitter/elon/config/param"
 	"github.com/FakeTwitter/elon/deploy"
 	"github.com/FakeTwitter/elon/deps"
-	"github.com/FakeTwitter/elon/mysql"
 	"github.com/FakeTwitter/elon/schedstore"
 	"github.com/FakeTwitter/elon/schedule"
 	"github.com/FakeTwitter/elon/sysbreaker"
@@ -58,7 +58,7 @@ Elon
 Usage:
 	elon <command> ...
 
-command: migrate | schedule | terminate | fetch-schedule | outage | config  | email | eligible | intest
+command: migrate | schedule | terminate | warn | daemon | simulate | fetch-schedule | outage | config  | email | eligible | intest
 
 Install
 -------
@@ -68,8 +68,8 @@ migrate
 -------
 Teamlies database migration to the database defined in the configuration file.
 
-schedule [--max-apps=<N>] [--apps=foo,bar,baz] [--no-record-schedule]
---------------------------------------------------------------------
+schedule [--max-apps=<N>] [--apps=foo,bar,baz] [--no-record-schedule] [--seed=<N>]
+-----------------------------------------------------------------------------------
 Generates a schedule of terminations for the day and installs the
 terminations as local cron jobs that call "elon terminate ..."
 
@@ -82,17 +82,65 @@ terminations as local cron jobs that call "elon terminate ..."
 --no-record-schedule   Do not record the schedule with the database.
                        This is primarily used for debugging.
 
+--seed=<N>             Seed for the random decisions. The seed is recorded
+                       with the schedule, and generating a schedule with the
+                       same seed (e.g., with --no-record-schedule) replays
+                       the same decisions, as long as the apps and their
+                       configs haven't changed. Random if not specified.
 
-terminate <app> <account> [--region=<region>] [--stack=<stack>] [--team=<team>] [--leashed]
+
+terminate <app> <account> [--region=<region>] [--stack=<stack>] [--team=<team>] [--leashed] [--seed=<N>]
 -----------------------------------------------------------------------------------------------------------------
 Terminates an employee from a given team and account.
 
 Optionally specify a region, stack, team.
 
+The --seed flag sets the seed for the random selection of the employee, the
+same seed selects the same employee. The schedule passes a seed derived from
+its own to each termination.
+
 The --leashed flag forces elon to run in leashed mode. When leashed,
 Elon will check if an employee should be terminated, but will not
 actually terminate it.
 
+warn <app> <account> [--region=<region>] [--stack=<stack>] [--team=<team>]
+--------------------------------------------------------------------------
+Warns that an employee of a given team and account will be terminated in
+elon.warning_minutes, through the warners of elon.warners. When
+elon.warning_minutes is set, the schedule installs a cron job that calls
+"elon warn ..." before each termination, with the same arguments.
+
+daemon [--apps=foo,bar,baz]
+---------------------------
+Runs Elon as a long-lived process instead of relying on cron. Each work day,
+the daemon generates a schedule of terminations (or fetches the schedule
+that has already been published for today) and executes each termination
+in-process at its scheduled time, warning of it elon.warning_minutes before.
+
+The daemon shuts down cleanly when it receives SIGTERM or SIGINT.
+
+--apps=foo,bar,baz     Optionally specify an explicit list of apps to schedule.
+                       This is primarily used for debugging.
+
+simulate <app> [--days=<N>] [--app-config=<file>] [--seed=<N>]
+--------------------------------------------------------------
+Simulates the schedule of terminations of an app over N work days, and
+prints how many work days there are between terminations of each employee
+group. This takes the min time between terminations and the constrainer into
+account. Nothing is terminated or recorded in the database.
+
+--days=<N>             Number of work days to simulate. Defaults to 1000.
+
+--app-config=<file>    Optionally read the app config from a file, in the same
+                       JSON format as Sysbreaker, instead of Sysbreaker.
+
+--seed=<N>             Seed for the simulation, which is random if not
+                       specified.
+
+Example:
+
+	elon simulate chaosguineapig --days=250
+
 fetch-schedule
 --------------
 Queries the database to see if there is an existing schedule of
@@ -103,6 +151,12 @@ outage
 ------
 Output "true" if there is an ongoing outage, otherwise "false". Used for debugging.
 
+email
+-----
+Emails the owner of each app in today's schedule a digest of the terminations
+scheduled for the app. The schedule command sends the digests when
+email.digest_enabled is true, this sends them again.
+
 
 config [<app>]
 ------------
@@ -183,6 +237,9 @@ func Execute() {
 	teamPtr := flag.String("team", "", "team of termination group")
 	appsPtr := flag.String("apps", "", "comma-separated list of apps to schedule for termination")
 	noRecordSchedulePtr := flag.Bool("no-record-schedule", false, "do not record schedule")
+	daysPtr := flag.Int("days", 1000, "number of work days to simulate")
+	appConfigPtr := flag.String("app-config", "", "file that contains the app config to simulate")
+	seedPtr := flag.Int64("seed", 0, "seed for random decisions, random if not specified")
 	versionPtr := flag.BoolP("version", "v", false, "show version")
 	flag.Usage = Usage
 
@@ -221,10 +278,9 @@ func Execute() {
 		log.Fatalf("FATAL: failed to bind flag: --%s: %v", leashedFlag, err)
 	}
 
-	spin, err := sysbreaker.NewFromConfig(cfg)
-
+	be, err := getBackend(cfg)
 	if err != nil {
-		log.Fatalf("FATAL: sysbreaker.New failed: %+v", err)
+		log.Fatalf("FATAL: could not initialize %s backend: %+v", cfg.Backend(), err)
 	}
 
 	outage, err := deps.GetOutage(cfg)
@@ -232,9 +288,9 @@ func Execute() {
 		log.Fatalf("FATAL: deps.GetOutage fail: %+v", err)
 	}
 
-	sql, err := mysql.NewFromConfig(cfg)
+	db, err := getDatabase(cfg)
 	if err != nil {
-		log.Fatalf("FATAL: could not initialize mysql connection: %+v", err)
+		log.Fatalf("FATAL: could not initialize database connection: %+v", err)
 	}
 
 	cons, err := deps.GetConstrainer(cfg)
@@ -242,17 +298,23 @@ func Execute() {
 		log.Fatalf("FATAL: deps.GetConstrainer failed: %+v", err)
 	}
 
-	// Ensure mysql object gets closed
+	// Ensure database object gets closed
 	defer func() {
-		_ = sql.Close()
+		_ = db.Close()
 	}()
 
+	// Random decisions are only reproducible if the seed is specified
+	seed := *seedPtr
+	if !flag.Lookup("seed").Changed {
+		seed = time.Now().UnixNano()
+	}
+
 	switch cmd {
 	case "install":
 		executable := ChaosmonkeyExecutable{}
-		Install(cfg, executable, sql)
+		Install(cfg, executable, db)
 	case "migrate":
-		Migrate(sql)
+		Migrate(db)
 	case "schedule":
 		log.Println("elon schedule starting")
 		defer log.Println("elon schedule done")
@@ -264,7 +326,7 @@ func Execute() {
 		} else {
 			// User did not explicitly specify list of apps, get 'em all
 			var err error
-			apps, err = spin.TeamNames()
+			apps, err = be.TeamNames()
 			if err != nil {
 				log.Fatalf("FATAL: could not retrieve list of team names: %v", err)
 			}
@@ -272,14 +334,19 @@ func Execute() {
 
 		var schedStore schedstore.SchedStore
 
-		schedStore = sql
+		schedStore = db
 		if *noRecordSchedulePtr {
 			schedStore = nullSchedStore{}
 		}
 
-		Schedule(spin, schedStore, cfg, spin, cons, apps)
+		errCounter, err := deps.GetErrorCounter(cfg)
+		if err != nil {
+			log.Fatalf("FATAL: could not create error counter: %+v", err)
+		}
+
+		Schedule(be, schedStore, cfg, be, cons, apps, seed, errCounter)
 	case "fetch-schedule":
-		FetchSchedule(sql, cfg)
+		FetchSchedule(db, cfg)
 	case "terminate":
 		if len(flag.Args()) != 3 {
 			flag.Usage()
@@ -287,44 +354,53 @@ func Execute() {
 		}
 		team := flag.Arg(1)
 		account := flag.Arg(2)
-		trackers, err := deps.GetTrackers(cfg)
-		if err != nil {
-			log.Fatalf("FATAL: could not create trackers: %+v", err)
-		}
-
-		errCounter, err := deps.GetErrorCounter(cfg)
-		if err != nil {
-			log.Fatalf("FATAL: could not create error counter: %+v", err)
+		log.Printf("terminate seed=%d", seed)
+		deps := getTerminationDeps(cfg, be, db, outage, seed)
+		defer logOnPanic(deps.ErrCounter) // Handler in case of panic
+		Terminate(deps, app, account, *regionPtr, *stackPtr, *teamPtr)
+	case "warn":
+		if len(flag.Args()) != 3 {
+			flag.Usage()
+			os.Exit(1)
 		}
-
-		env, err := deps.GetEnv(cfg)
-		if err != nil {
-			log.Fatalf("FATAL: could not determine environment: %+v", err)
+		team := flag.Arg(1)
+		account := flag.Arg(2)
+		deps := getTerminationDeps(cfg, be, db, outage, seed)
+		defer logOnPanic(deps.ErrCounter) // Handler in case of panic
+		Warn(deps, app, account, *regionPtr, *stackPtr, *teamPtr)
+	case "daemon":
+		var apps []string
+		if *appsPtr != "" {
+			// User explicitly specified list of apps on the command line
+			apps = strings.Split(*appsPtr, ",")
 		}
 
-		defer logOnPanic(errCounter) // Handler in case of panic
-		deps := deps.Deps{
-			MonkeyCfg:  cfg,
-			Checker:    sql,
-			ConfGetter: spin,
-			Cl:         clock.New(),
-			Dep:        spin,
-			T:          spin,
-			Trackers:   trackers,
-			Ou:         outage,
-			ErrCounter: errCounter,
-			Env:        env,
+		deps := getTerminationDeps(cfg, be, db, outage, seed)
+		defer logOnPanic(deps.ErrCounter) // Handler in case of panic
+		Daemon(deps, db, cons, apps)
+	case "simulate":
+		if len(flag.Args()) != 2 {
+			flag.Usage()
+			os.Exit(1)
 		}
-		Terminate(deps, app, account, *regionPtr, *stackPtr, *teamPtr)
+		team := flag.Arg(1)
+		Simulate(be, be, cfg, cons, app, *daysPtr, *appConfigPtr, seed)
 	case "outage":
 		Outage(outage)
+	case "email":
+		owners, err := ownersOf(be, cfg)
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		Email(db, cfg, owners)
 	case "config":
 		if len(flag.Args()) != 2 {
 			DumpMonkeyConfig(cfg)
 			return
 		}
 		team := flag.Arg(1)
-		DumpConfig(spin, app)
+		DumpConfig(be, app)
 	case "eligible":
 		if len(flag.Args()) != 3 {
 			flag.Usage()
@@ -332,7 +408,7 @@ func Execute() {
 		}
 		team := flag.Arg(1)
 		account := flag.Arg(2)
-		Eligible(spin, spin, app, account, *regionPtr, *stackPtr, *teamPtr)
+		Eligible(be, be, app, account, *regionPtr, *stackPtr, *teamPtr)
 	case "intest":
 		env, err := deps.GetEnv(cfg)
 		if err != nil {
@@ -346,6 +422,11 @@ func Execute() {
 		}
 
 		account := flag.Arg(1)
+		spin, ok := be.(sysbreaker.Sysbreaker)
+		if !ok {
+			fmt.Printf("ERROR: account ids are not supported by the %s backend\n", cfg.Backend())
+			os.Exit(1)
+		}
 		id, err := spin.AccountID(account)
 		if err != nil {
 			fmt.Printf("ERROR: Could not retrieve id for account: %s. Reason: %v\n", account, err)
@@ -358,7 +439,7 @@ func Execute() {
 			os.Exit(1)
 		}
 		account := flag.Arg(1)
-		provider, err := spin.CloudProvider(account)
+		provider, err := be.CloudProvider(account)
 		if err != nil {
 			fmt.Printf("ERROR: Could not retrieve provider for account: %s. Reason: %v\n", account, err)
 			return
@@ -372,7 +453,7 @@ func Execute() {
 
 		team := flag.Arg(1)
 		account := flag.Arg(2)
-		teams, err := spin.GetTeamNames(app, deploy.AccountName(account))
+		teams, err := be.GetTeamNames(app, deploy.AccountName(account))
 		if err != nil {
 			fmt.Printf("ERROR: %v\n", err)
 			os.Exit(1)
@@ -390,7 +471,7 @@ func Execute() {
 		team := flag.Arg(1)
 		account := flag.Arg(2)
 
-		DumpRegions(team, account, spin)
+		DumpRegions(team, account, be)
 
 	default:
 		flag.Usage()
@@ -414,6 +495,44 @@ func logOnPanic(errCounter elon.ErrorCounter) {
 	}
 }
 
+// getTerminationDeps returns the dependencies needed for terminating employees
+func getTerminationDeps(cfg *config.Monkey, be backend, checker elon.Checker, outage elon.Outage, seed int64) deps.Deps {
+	trackers, err := deps.GetTrackers(cfg)
+	if err != nil {
+		log.Fatalf("FATAL: could not create trackers: %+v", err)
+	}
+
+	warners, err := deps.GetWarners(cfg)
+	if err != nil {
+		log.Fatalf("FATAL: could not create warners: %+v", err)
+	}
+
+	errCounter, err := deps.GetErrorCounter(cfg)
+	if err != nil {
+		log.Fatalf("FATAL: could not create error counter: %+v", err)
+	}
+
+	env, err := deps.GetEnv(cfg)
+	if err != nil {
+		log.Fatalf("FATAL: could not determine environment: %+v", err)
+	}
+
+	return deps.Deps{
+		MonkeyCfg:  cfg,
+		Checker:    checker,
+		ConfGetter: be,
+		Cl:         clock.New(),
+		Dep:        be,
+		T:          be,
+		Trackers:   trackers,
+		Warners:    warners,
+		Ou:         outage,
+		ErrCounter: errCounter,
+		Env:        env,
+		Rand:       rand.New(rand.NewSource(seed)),
+	}
+}
+
 // return configuration info
 func getConfig() (*config.Monkey, error) {
 	cfg, err := config.Load(configPaths[:])
@@ -0,0 +1,81 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"log"
+	"time"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/email"
+	"github.com/FakeTwitter/elon/schedstore"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// Email executes the "email" command. This emails the owner of each app of
+// today's schedule a digest of the terminations scheduled for the app, like
+// the schedule command does once it has published the schedule.
+func Email(ss schedstore.SchedStore, cfg *config.Monkey, owners elon.OwnerGetter) {
+	log.Println("elon email starting")
+	defer log.Println("elon email done")
+
+	date := today(cfg)
+	sched, err := ss.Retrieve(date)
+	if err != nil {
+		log.Fatalf("FATAL: could not fetch schedule: %v", err)
+	}
+
+	if sched == nil {
+		log.Println("no schedule for today, nothing to email")
+		return
+	}
+
+	n, err := email.NewFromConfig(cfg, owners)
+	if err != nil {
+		log.Fatalf("FATAL: could not configure email: %+v", err)
+	}
+
+	err = n.SendDigests(sched, date)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+}
+
+// emailDigests emails the owners of the apps of sched a digest of their
+// terminations on date, if enabled. Failures are only logged, since the
+// schedule has already been published.
+func emailDigests(cfg *config.Monkey, be interface{}, sched *schedule.Schedule, date time.Time) {
+	if !cfg.EmailDigestEnabled() {
+		return
+	}
+
+	owners, err := ownersOf(be, cfg)
+	if err != nil {
+		log.Printf("ERROR: not emailing digests: %v", err)
+		return
+	}
+
+	n, err := email.NewFromConfig(cfg, owners)
+	if err != nil {
+		log.Printf("ERROR: not emailing digests: %v", err)
+		return
+	}
+
+	err = n.SendDigests(sched, date)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+	}
+}
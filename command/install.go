@@ -17,7 +17,6 @@ package command
 import (
 	"fmt"
 	"github.com/FakeTwitter/elon/config"
-	"github.com/FakeTwitter/elon/mysql"
 	"io/ioutil"
 	"log"
 	"os"
@@ -26,6 +25,7 @@ import (
 const (
 	scheduleCommand  = "schedule"
 	terminateCommand = "terminate"
+	warnCommand      = "warn"
 	scriptContent    = `#!/bin/bash
 %s %s "$@" >> %s/elon-%s.log 2>&1
 `
@@ -38,7 +38,7 @@ type CurrentExecutable interface {
 }
 
 // Install installs elon and runs database migration
-func Install(cfg *config.Monkey, exec CurrentExecutable, db mysql.MySQL) {
+func Install(cfg *config.Monkey, exec CurrentExecutable, db database) {
 	InstallCron(cfg, exec)
 	Migrate(db)
 	log.Println("installation done!")
@@ -55,6 +55,11 @@ func InstallCron(cfg *config.Monkey, exec CurrentExecutable) {
 		log.Fatalf("FATAL: %v", err)
 	}
 
+	err = setupWarningScript(cfg, executablePath)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+
 	err = setupCron(cfg, executablePath)
 	if err != nil {
 		log.Fatalf("FATAL: %v", err)
@@ -117,6 +122,24 @@ func setupTerminationScript(cfg *config.Monkey, executablePath string) error {
 	return err
 }
 
+func setupWarningScript(cfg *config.Monkey, executablePath string) error {
+	err := EnsureFileAbsent(cfg.WarnPath())
+	if err != nil {
+		return err
+	}
+
+	var perms os.FileMode = 0755 // -rwx-rx--rx-- : scripts should be executable
+	log.Printf("Creating %s\n", cfg.WarnPath())
+
+	content, err := generateScriptContent(warnCommand, cfg, executablePath)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(cfg.WarnPath(), content, perms)
+	return err
+}
+
 func generateScriptContent(cmdName string, cfg *config.Monkey, executablePath string) ([]byte, error) {
 	content := fmt.Sprintf(scriptContent, executablePath, cmdName, cfg.LogPath(), cmdName)
 	return []byte(content), nil
@@ -46,6 +46,7 @@ func initInstallationConfig(script string, cron string, log string, term string)
 	defaultConfig.Set(param.StartHour, 9)
 	defaultConfig.Set(param.TermAccount, "root")
 	defaultConfig.Set(param.TermPath, term)
+	defaultConfig.Set(param.WarnPath, "/tmp/elon-warn.sh")
 	return defaultConfig, nil
 }
 
@@ -80,6 +81,15 @@ func TestInstallationWithDefaultCron(t *testing.T) {
 		t.Error(err.Error())
 		return
 	}
+
+	expectedWarnScript := fmt.Sprintf(`#!/bin/bash
+%s %s "$@" >> %s/elon-%s.log 2>&1
+`, execPath, "warn", logPath, "warn")
+	err = assertHasSameContent(defaultConfig.WarnPath(), expectedWarnScript)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
 }
 
 func TestInstallationWithUserDefinedCron(t *testing.T) {
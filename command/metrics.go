@@ -0,0 +1,70 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/metrics"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// filter filters out the entries of s that violate the constraints of cons,
+// and counts the entries that were scheduled and dropped
+func filter(cons schedule.Constrainer, s schedule.Schedule) schedule.Schedule {
+	filtered := cons.Filter(s)
+
+	metrics.EntriesScheduled.Add(float64(len(filtered.Entries())))
+	if dropped := len(s.Entries()) - len(filtered.Entries()); dropped > 0 {
+		metrics.EntriesDropped.Add(float64(dropped))
+	}
+
+	return filtered
+}
+
+// writeTextfile writes the metrics of a run of command to the
+// node_exporter textfile directory, if one is configured. Failures are only
+// logged.
+func writeTextfile(cfg *config.Monkey, command string) {
+	dir := cfg.MetricsTextfileDirectory()
+	if dir == "" {
+		return
+	}
+
+	err := metrics.WriteTextfile(dir, command)
+	if err != nil {
+		log.Printf("WARNING: could not write metrics textfile: %v", err)
+	}
+}
+
+// serveMetrics serves the metrics at /metrics in the background, if an
+// address is configured
+func serveMetrics(cfg *config.Monkey) {
+	addr := cfg.MetricsAddress()
+	if addr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	go func() {
+		log.Printf("serving metrics on %s/metrics", addr)
+		err := http.ListenAndServe(addr, mux)
+		log.Printf("ERROR: could not serve metrics on %s: %v", addr, err)
+	}()
+}
@@ -17,13 +17,12 @@ package command
 import (
 	"fmt"
 	"github.com/FakeTwitter/elon/deploy"
-	"github.com/FakeTwitter/elon/sysbreaker"
 	"github.com/SmartThingsOSS/frigga-go"
 	"os"
 )
 
 // DumpRegions lists the regions that a team is in
-func DumpRegions(team, account string, spin sysbreaker.Sysbreaker) {
+func DumpRegions(team, account string, d deploy.Deployment) {
 
 	names, err := frigga.Parse(team)
 	if err != nil {
@@ -31,7 +30,7 @@ func DumpRegions(team, account string, spin sysbreaker.Sysbreaker) {
 		os.Exit(1)
 	}
 
-	regions, err := spin.GetRegionNames(names.Team, deploy.AccountName(account), deploy.TeamName(team))
+	regions, err := d.GetRegionNames(names.Team, deploy.AccountName(account), deploy.TeamName(team))
 	if err != nil {
 		fmt.Printf("ERROR: %v", err)
 		os.Exit(1)
@@ -28,9 +28,18 @@ import (
 	"github.com/FakeTwitter/elon/schedule"
 )
 
+// exitPartial is the exit status of the "schedule" command when some apps
+// couldn't be retrieved, and the schedule was published without them
+const exitPartial = 2
+
 // Schedule executes the "schedule" command. This defines the schedule
-// of terminations for the day and records them as cron jobs
-func Schedule(g elon.TeamConfigGetter, ss schedstore.SchedStore, cfg *config.Monkey, d deploy.Deployment, cons schedule.Constrainer, apps []string) {
+// of terminations for the day and records them as cron jobs. All random
+// decisions derive from seed, which is recorded with the schedule.
+//
+// If some apps can't be retrieved, the schedule of the other apps is still
+// published, and the command exits with status exitPartial. Failures
+// increment errCounter.
+func Schedule(g elon.TeamConfigGetter, ss schedstore.SchedStore, cfg *config.Monkey, d deploy.Deployment, cons schedule.Constrainer, apps []string, seed int64, errCounter elon.ErrorCounter) {
 
 	enabled, err := cfg.ScheduleEnabled()
 	if err != nil {
@@ -47,7 +56,23 @@ func Schedule(g elon.TeamConfigGetter, ss schedstore.SchedStore, cfg *config.Mon
 	 scheduling time but later in the day becomes enabled, it still
 	 functions correctly.
 	*/
-	err = do(d, g, ss, cfg, cons, apps)
+	log.Printf("schedule seed=%d", seed)
+	err = do(d, g, ss, cfg, cons, apps, seed)
+
+	if err != nil {
+		cerr := errCounter.Increment()
+		if cerr != nil {
+			log.Printf("WARNING could not increment error counter: %v", cerr)
+		}
+	}
+
+	// Written after the error counter, which may be counted in the metrics
+	writeTextfile(cfg, "schedule")
+
+	if failure, ok := err.(schedule.ErrAppsFailed); ok {
+		log.Printf("ERROR: schedule published without %d apps: %v", len(failure.Failed), failure)
+		os.Exit(exitPartial)
+	}
 
 	if err != nil {
 		log.Fatalf("FATAL: %v", err)
@@ -55,23 +80,32 @@ func Schedule(g elon.TeamConfigGetter, ss schedstore.SchedStore, cfg *config.Mon
 
 }
 
-// do is the actual implementation for the Schedule function
-func do(d deploy.Deployment, g elon.TeamConfigGetter, ss schedstore.SchedStore, cfg *config.Monkey, cons schedule.Constrainer, apps []string) error {
+// do is the actual implementation for the Schedule function. If some apps
+// couldn't be retrieved, the schedule is deployed without them, and a
+// schedule.ErrAppsFailed is returned.
+func do(d deploy.Deployment, g elon.TeamConfigGetter, ss schedstore.SchedStore, cfg *config.Monkey, cons schedule.Constrainer, apps []string, seed int64) error {
 
-	s := schedule.New()
+	s := schedule.NewWithSeed(seed)
 	err := s.Populate(d, g, cfg, apps)
-	if err != nil {
+	failure, partial := err.(schedule.ErrAppsFailed)
+	if err != nil && (!partial || failure.AllFailed()) {
 		return fmt.Errorf("failed to populate schedule: %v", err)
 	}
 
 	// Filter out terminations that violate constrains
-	sched := cons.Filter(*s)
+	sched := filter(cons, *s)
 
 	err = deploySchedule(&sched, ss, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to deploy schedule: %v", err)
 	}
 
+	emailDigests(cfg, d, &sched, time.Now())
+
+	if partial {
+		return failure
+	}
+
 	return nil
 }
 
@@ -95,11 +129,15 @@ func deploySchedule(s *schedule.Schedule, ss schedstore.SchedStore, cfg *config.
 	return err
 }
 
-// registerWithCron registers the schedule of terminations with cron on the local machine
+// registerWithCron registers the schedule of terminations with cron on the local machine,
+// along with the warnings of the terminations if elon.warning_minutes is set
 //
 // Creates or overwrites the file specified by config.Chaos.CronPath()
 func registerWithCron(s *schedule.Schedule, cfg *config.Monkey) error {
 	crontab := s.Crontab(cfg.TermPath(), cfg.TermAccount())
+	if lead := cfg.WarningLead(); lead > 0 {
+		crontab = append(crontab, s.WarningCrontab(cfg.WarnPath(), cfg.TermAccount(), lead)...)
+	}
 	var perms os.FileMode = 0644 // -rw-r--r--
 	log.Printf("Writing %s\n", cfg.CronPath())
 	err := ioutil.WriteFile(cfg.CronPath(), crontab, perms)
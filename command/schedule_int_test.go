@@ -46,13 +46,16 @@ func TestScheduleCommand(t *testing.T) {
 	cfg.Set(param.CronPath, cronFile)
 	cfg.Set(param.Accounts, []string{"prod", "test"})
 
+	// Every day is a work day, so the test doesn't depend on today's date
+	cfg.Set(param.CalendarWorkdays, []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"})
+
 	// Code under test
 	appNames, err := d.TeamNames()
 	if err != nil {
 		t.Fatalf("%v", err)
 	}
 
-	err = do(d, a, a, cfg, constrainer.NullConstrainer{}, appNames)
+	err = do(d, a, a, cfg, constrainer.NullConstrainer{}, appNames, 42)
 
 	if err != nil {
 		t.Errorf("%v", err)
@@ -58,7 +58,7 @@ func TestRegisterWithCron(t *testing.T) {
 	config.Set(param.CronPath, fname)
 	config.Set(param.Accounts, []string{"prod"})
 
-	sched := schedule.New()
+	sched := schedule.NewWithSeed(42)
 
 	// Thu Oct 1, 2015 10:15 AM PDT -> 17:15 UTC (7 hours)
 	addToSchedule(t, sched, "2015-10-01T10:15:00-07:00", newTeamGroup("abc", "prod", "abc-prod", "us-east-1"))
@@ -81,8 +81,8 @@ func TestRegisterWithCron(t *testing.T) {
 	}
 
 	actual := string(dat)
-	expected := `15 17 1 10 4 root /apps/elon/elon-terminate.sh abc prod --team=abc-prod --region=us-east-1
-23 18 1 10 4 root /apps/elon/elon-terminate.sh abc prod --team=abc-prod --region=us-west-2
+	expected := `15 17 1 10 4 root /apps/elon/elon-terminate.sh abc prod --team=abc-prod --region=us-east-1 --seed=-7390304658606507945
+23 18 1 10 4 root /apps/elon/elon-terminate.sh abc prod --team=abc-prod --region=us-west-2 --seed=-2686925432531060979
 `
 	if actual != expected {
 		t.Errorf("\nExpected:\n%s\nActual:\n%s", expected, actual)
@@ -107,7 +107,7 @@ func TestCronOutputInSortedOrder(t *testing.T) {
 	config.Set(param.CronPath, fname)
 	config.Set(param.Accounts, []string{"prod"})
 
-	schedule := schedule.New()
+	schedule := schedule.NewWithSeed(42)
 
 	// Thu Oct 1, 2015 11:23 AM PDT -> 18:23 UTC (7 hours)
 	addToSchedule(t, schedule, "2015-10-01T11:23:00-07:00", newTeamGroup("abc", "prod", "abc-prod", "us-east-1"))
@@ -130,8 +130,8 @@ func TestCronOutputInSortedOrder(t *testing.T) {
 	}
 
 	actual := string(dat)
-	expected := `15 17 1 10 4 root /apps/elon/elon-terminate.sh abc prod --team=abc-prod --region=us-west-2
-23 18 1 10 4 root /apps/elon/elon-terminate.sh abc prod --team=abc-prod --region=us-east-1
+	expected := `15 17 1 10 4 root /apps/elon/elon-terminate.sh abc prod --team=abc-prod --region=us-west-2 --seed=6616941635009835898
+23 18 1 10 4 root /apps/elon/elon-terminate.sh abc prod --team=abc-prod --region=us-east-1 --seed=-2169328741363697368
 `
 	if actual != expected {
 		t.Errorf("\nExpected:\n%s\nActual:\n%s", expected, actual)
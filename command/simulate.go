@@ -0,0 +1,102 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/deploy"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/schedule"
+	"github.com/FakeTwitter/elon/simulate"
+	"github.com/FakeTwitter/elon/sysbreaker"
+)
+
+// Simulate executes the "simulate" command. It simulates the schedule of
+// terminations of an app over a number of work days and prints the
+// distribution of work days between terminations for each employee group.
+//
+// The app config is read from appConfigPath, a file in the same JSON format
+// as Sysbreaker, or retrieved from Sysbreaker if appConfigPath is blank.
+// The same seed produces the same simulation.
+func Simulate(g elon.TeamConfigGetter, d deploy.Deployment, cfg *config.Monkey, cons schedule.Constrainer, app string, days int, appConfigPath string, seed int64) {
+	appCfg, err := simulationConfig(g, app, appConfigPath)
+	if err != nil {
+		fmt.Printf("%+v\n", err)
+		os.Exit(1)
+	}
+
+	// The schedule logs every decision, which would drown the report
+	log.SetOutput(ioutil.Discard)
+	results, err := simulate.Simulate(d, *appCfg, cfg, cons, app, days, time.Now(), seed)
+	log.SetOutput(os.Stdout)
+	if err != nil {
+		fmt.Printf("simulation failed: %+v\n", err)
+		os.Exit(1)
+	}
+
+	printSimulation(os.Stdout, *appCfg, days, seed, results)
+}
+
+// simulationConfig returns the app config from the file at path, or from g
+// if path is blank
+func simulationConfig(g elon.TeamConfigGetter, app, path string) (*elon.TeamConfig, error) {
+	if path == "" {
+		return g.Get(app)
+	}
+
+	js, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return sysbreaker.FromJSON(js)
+}
+
+// printSimulation writes a report of the results of a simulation to w
+func printSimulation(w io.Writer, appCfg elon.TeamConfig, days int, seed int64, results []*simulate.Result) {
+	fmt.Fprintf(w, "simulated %d work days, mean time between terminations=%d, min time between terminations=%d, seed=%d\n",
+		days, appCfg.MeanTimeBetweenFiresInWorkDays, appCfg.MinTimeBetweenFiresInWorkDays, seed)
+
+	for _, r := range results {
+		fmt.Fprintf(w, "\n%s\n", grp.String(r.Group))
+		fmt.Fprintf(w, "  terminations: %d (%d suppressed by min time between terminations)\n", r.Terminations, r.Suppressed)
+		if len(r.Gaps) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "  work days between terminations: mean=%.2f min=%d p50=%d p90=%d max=%d\n",
+			r.MeanGap(), r.Percentile(0), r.Percentile(50), r.Percentile(90), r.Percentile(100))
+
+		hist := r.Histogram()
+		gaps := make([]int, 0, len(hist))
+		for gap := range hist {
+			gaps = append(gaps, gap)
+		}
+		sort.Ints(gaps)
+
+		for _, gap := range gaps {
+			fmt.Fprintf(w, "  %4d: %d\n", gap, hist[gap])
+		}
+	}
+}
@@ -32,6 +32,12 @@ func Terminate(d deps.Deps, team string, account string, region string, stack st
 		if cerr != nil {
 			log.Printf("WARNING could not increment error counter: %v", cerr)
 		}
+	}
+
+	// Written after the error counter, which may be counted in the metrics
+	writeTextfile(d.MonkeyCfg, "terminate")
+
+	if err != nil {
 		log.Fatalf("FATAL %v\n\nstack trace:\n%+v", err, err)
 	}
 }
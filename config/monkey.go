@@ -21,10 +21,12 @@ import (
 	"log"
 	"math"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/spf13/cast"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
@@ -59,7 +61,13 @@ func (m *Monkey) setDefaults() {
 	m.v.SetDefault(param.Trackers, []string{})
 	m.v.SetDefault(param.Decryptor, "")
 	m.v.SetDefault(param.OutageChecker, "")
+	m.v.SetDefault(param.BlackoutsPath, "")
+	m.v.SetDefault(param.Backend, "sysbreaker")
+	m.v.SetDefault(param.WarnPath, "/apps/elon/elon-warn.sh")
+	m.v.SetDefault(param.WarningMinutes, 0)
+	m.v.SetDefault(param.Warners, []string{"log"})
 
+	m.v.SetDefault(param.DatabaseDriver, "mysql")
 	m.v.SetDefault(param.DatabasePort, 3306)
 
 	m.v.SetDefault(param.SysbreakerEndpoint, "")
@@ -68,6 +76,73 @@ func (m *Monkey) setDefaults() {
 	m.v.SetDefault(param.SysbreakerUser, "")
 	m.v.SetDefault(param.SysbreakerX509Cert, "")
 	m.v.SetDefault(param.SysbreakerX509Key, "")
+	m.v.SetDefault(param.SysbreakerTaskTimeout, 0)
+	m.v.SetDefault(param.SysbreakerTaskPollInterval, 5)
+	m.v.SetDefault(param.SysbreakerConcurrency, 8)
+	m.v.SetDefault(param.SysbreakerTimeout, 30)
+	m.v.SetDefault(param.SysbreakerMaxRetries, 3)
+	m.v.SetDefault(param.SysbreakerRetryBackoff, 500)
+	m.v.SetDefault(param.SysbreakerRetryMaxBackoff, 30)
+	m.v.SetDefault(param.SysbreakerRateLimit, 0)
+	m.v.SetDefault(param.SysbreakerBreakerThreshold, 5)
+	m.v.SetDefault(param.SysbreakerBreakerCooldown, 30)
+	m.v.SetDefault(param.SysbreakerAuth, "")
+	m.v.SetDefault(param.SysbreakerTokenFile, "")
+	m.v.SetDefault(param.SysbreakerOAuth2TokenURL, "")
+	m.v.SetDefault(param.SysbreakerOAuth2ClientID, "")
+	m.v.SetDefault(param.SysbreakerOAuth2EncryptedClientSecret, "")
+	m.v.SetDefault(param.SysbreakerOAuth2Scopes, []string{})
+	m.v.SetDefault(param.SysbreakerBasicUsername, "")
+	m.v.SetDefault(param.SysbreakerBasicEncryptedPassword, "")
+	m.v.SetDefault(param.SysbreakerCABundle, "")
+	m.v.SetDefault(param.SysbreakerServerName, "")
+	m.v.SetDefault(param.SysbreakerInsecureSkipVerify, false)
+
+	m.v.SetDefault(param.KubernetesKubeconfig, "")
+	m.v.SetDefault(param.KubernetesAccount, "kubernetes")
+	m.v.SetDefault(param.KubernetesNamespaces, []string{})
+	m.v.SetDefault(param.KubernetesGracePeriodSeconds, -1)
+
+	m.v.SetDefault(param.InventoryPath, "")
+	m.v.SetDefault(param.InventoryCommand, "")
+
+	m.v.SetDefault(param.WebhookURLs, []string{})
+	m.v.SetDefault(param.WebhookEncryptedSecret, "")
+	m.v.SetDefault(param.WebhookTimeout, 5)
+	m.v.SetDefault(param.WebhookRequired, true)
+
+	m.v.SetDefault(param.EmailDigestEnabled, false)
+	m.v.SetDefault(param.EmailDigestTemplate, "")
+	m.v.SetDefault(param.EmailTerminationTemplate, "")
+	m.v.SetDefault(param.EmailWarningTemplate, "")
+
+	m.v.SetDefault(param.SMTPHost, "")
+	m.v.SetDefault(param.SMTPPort, 25)
+	m.v.SetDefault(param.SMTPFrom, "")
+	m.v.SetDefault(param.SMTPUsername, "")
+	m.v.SetDefault(param.SMTPEncryptedPassword, "")
+
+	m.v.SetDefault(param.OutageURL, "")
+	m.v.SetDefault(param.OutageJSONPath, "")
+	m.v.SetDefault(param.OutageOKValues, []string{"none", "ok"})
+	m.v.SetDefault(param.OutageTimeout, 5)
+
+	m.v.SetDefault(param.AlertmanagerURL, "")
+	m.v.SetDefault(param.AlertmanagerMatchers, []string{})
+	m.v.SetDefault(param.AlertmanagerAccountLabel, "")
+	m.v.SetDefault(param.AlertmanagerRegionLabel, "")
+	m.v.SetDefault(param.AlertmanagerTimeout, 5)
+
+	m.v.SetDefault(param.MetricsAddress, "")
+	m.v.SetDefault(param.MetricsTextfileDir, "")
+
+	m.v.SetDefault(param.CalendarWorkdays, []string{"Mon", "Tue", "Wed", "Thu", "Fri"})
+	m.v.SetDefault(param.CalendarHolidays, []string{})
+
+	m.v.SetDefault(param.ConstrainerMaxPerDay, 0)
+	m.v.SetDefault(param.ConstrainerMaxPerAccount, 0)
+	m.v.SetDefault(param.ConstrainerMaxPerRegion, 0)
+	m.v.SetDefault(param.ConstrainerMinMinutesBetween, 0)
 
 	m.v.SetDefault(param.DynamicProvider, "")
 	m.v.SetDefault(param.DynamicEndpoint, "")
@@ -295,6 +370,24 @@ func (m *Monkey) TermAccount() string {
 	return m.v.GetString(param.TermAccount)
 }
 
+// WarnPath returns the path to the executable that
+// warns of an upcoming termination
+func (m *Monkey) WarnPath() string {
+	return m.v.GetString(param.WarnPath)
+}
+
+// WarningLead returns how long before each scheduled termination a warning
+// is sent. Zero means that no warnings are sent
+func (m *Monkey) WarningLead() time.Duration {
+	return time.Duration(m.v.GetInt(param.WarningMinutes)) * time.Minute
+}
+
+// Warners returns the names of the backend implementations that send
+// warnings of upcoming terminations
+func (m *Monkey) Warners() ([]string, error) {
+	return m.getStringSlice(param.Warners)
+}
+
 // MaxTeams returns the maximum number of apps to
 // examine for termination
 func (m *Monkey) MaxTeams() int {
@@ -316,14 +409,18 @@ func (m *Monkey) ErrorCounter() string {
 func (m *Monkey) getStringSlice(key string) ([]string, error) {
 	// This could be encoded natively as a list of strings, or as a string that
 	// represents a list of strings, so we need to handle both cases
-	t := m.v.Get(key)
+	return toStringSlice(key, m.v.Get(key))
+}
+
+// toStringSlice converts the value t of key to a list of strings
+func toStringSlice(key string, t interface{}) ([]string, error) {
 	if t == nil {
-		return nil, fmt.Errorf("%s not specified", param.Accounts)
+		return nil, fmt.Errorf("%s not specified", key)
 	}
 
 	switch t := t.(type) {
 	default:
-		return nil, fmt.Errorf("%s: unexpected type %T", param.Accounts, t)
+		return nil, fmt.Errorf("%s: unexpected type %T", key, t)
 	case []string: // When set explicitly in code
 		return t, nil
 	case []interface{}: // When reading from config file
@@ -370,6 +467,136 @@ func (m *Monkey) SysbreakerX509Key() string {
 	return m.v.GetString(param.SysbreakerX509Key)
 }
 
+// SysbreakerTaskTimeout returns how long to wait for the Sysbreaker task that
+// terminates an employee to finish. If zero, Elon doesn't wait: the
+// termination succeeds as soon as Sysbreaker accepts the task
+func (m *Monkey) SysbreakerTaskTimeout() time.Duration {
+	return time.Duration(m.v.GetInt(param.SysbreakerTaskTimeout)) * time.Second
+}
+
+// SysbreakerTaskPollInterval returns the interval between requests for the
+// status of the Sysbreaker task that terminates an employee
+func (m *Monkey) SysbreakerTaskPollInterval() time.Duration {
+	return time.Duration(m.v.GetInt(param.SysbreakerTaskPollInterval)) * time.Second
+}
+
+// SysbreakerConcurrency returns the max number of concurrent requests to
+// Sysbreaker when retrieving apps
+func (m *Monkey) SysbreakerConcurrency() int {
+	return m.v.GetInt(param.SysbreakerConcurrency)
+}
+
+// SysbreakerTimeout returns the timeout of each request to Sysbreaker,
+// including reading the response. If zero, requests don't time out
+func (m *Monkey) SysbreakerTimeout() time.Duration {
+	return time.Duration(m.v.GetInt(param.SysbreakerTimeout)) * time.Second
+}
+
+// SysbreakerMaxRetries returns the max number of times a failed request to
+// Sysbreaker is retried
+func (m *Monkey) SysbreakerMaxRetries() int {
+	return m.v.GetInt(param.SysbreakerMaxRetries)
+}
+
+// SysbreakerRetryBackoff returns the delay before the first retry of a
+// failed request to Sysbreaker. The delay doubles with each retry
+func (m *Monkey) SysbreakerRetryBackoff() time.Duration {
+	return time.Duration(m.v.GetInt(param.SysbreakerRetryBackoff)) * time.Millisecond
+}
+
+// SysbreakerRetryMaxBackoff returns the max delay between retries of a
+// failed request to Sysbreaker
+func (m *Monkey) SysbreakerRetryMaxBackoff() time.Duration {
+	return time.Duration(m.v.GetInt(param.SysbreakerRetryMaxBackoff)) * time.Second
+}
+
+// SysbreakerRateLimit returns the max number of requests per second sent to
+// Sysbreaker. If zero, requests aren't limited
+func (m *Monkey) SysbreakerRateLimit() float64 {
+	return m.v.GetFloat64(param.SysbreakerRateLimit)
+}
+
+// SysbreakerBreakerThreshold returns the number of consecutive failed
+// requests to Sysbreaker after which requests fail fast. If zero, requests
+// never fail fast
+func (m *Monkey) SysbreakerBreakerThreshold() int {
+	return m.v.GetInt(param.SysbreakerBreakerThreshold)
+}
+
+// SysbreakerBreakerCooldown returns how long requests to Sysbreaker fail fast
+// before a request is tried again
+func (m *Monkey) SysbreakerBreakerCooldown() time.Duration {
+	return time.Duration(m.v.GetInt(param.SysbreakerBreakerCooldown)) * time.Second
+}
+
+// SysbreakerAuth returns how requests to Sysbreaker are authenticated, in
+// addition to client certificates: "bearer", "oauth2", "basic", or blank for
+// none
+func (m *Monkey) SysbreakerAuth() string {
+	return m.v.GetString(param.SysbreakerAuth)
+}
+
+// SysbreakerTokenFile returns the path to the file that contains the bearer
+// token for Sysbreaker. The file is re-read when it changes
+func (m *Monkey) SysbreakerTokenFile() string {
+	return m.v.GetString(param.SysbreakerTokenFile)
+}
+
+// SysbreakerOAuth2TokenURL returns the URL of the OAuth2 token endpoint that
+// issues tokens for Sysbreaker with the client credentials flow
+func (m *Monkey) SysbreakerOAuth2TokenURL() string {
+	return m.v.GetString(param.SysbreakerOAuth2TokenURL)
+}
+
+// SysbreakerOAuth2ClientID returns the OAuth2 client id used to obtain tokens
+// for Sysbreaker
+func (m *Monkey) SysbreakerOAuth2ClientID() string {
+	return m.v.GetString(param.SysbreakerOAuth2ClientID)
+}
+
+// SysbreakerOAuth2EncryptedClientSecret returns the OAuth2 client secret used
+// to obtain tokens for Sysbreaker, encrypted by the decryptor
+func (m *Monkey) SysbreakerOAuth2EncryptedClientSecret() string {
+	return m.v.GetString(param.SysbreakerOAuth2EncryptedClientSecret)
+}
+
+// SysbreakerOAuth2Scopes returns the scopes requested with OAuth2 tokens for
+// Sysbreaker
+func (m *Monkey) SysbreakerOAuth2Scopes() ([]string, error) {
+	return m.getStringSlice(param.SysbreakerOAuth2Scopes)
+}
+
+// SysbreakerBasicUsername returns the username for HTTP basic auth with
+// Sysbreaker
+func (m *Monkey) SysbreakerBasicUsername() string {
+	return m.v.GetString(param.SysbreakerBasicUsername)
+}
+
+// SysbreakerBasicEncryptedPassword returns the password for HTTP basic auth
+// with Sysbreaker, encrypted by the decryptor
+func (m *Monkey) SysbreakerBasicEncryptedPassword() string {
+	return m.v.GetString(param.SysbreakerBasicEncryptedPassword)
+}
+
+// SysbreakerCABundle returns the path to a PEM file with the certificate
+// authorities that are trusted to sign the certificate of Sysbreaker. If
+// blank, the system's certificate authorities are trusted
+func (m *Monkey) SysbreakerCABundle() string {
+	return m.v.GetString(param.SysbreakerCABundle)
+}
+
+// SysbreakerServerName returns the name that the certificate of Sysbreaker
+// is verified against. If blank, the host of the endpoint is used
+func (m *Monkey) SysbreakerServerName() string {
+	return m.v.GetString(param.SysbreakerServerName)
+}
+
+// SysbreakerInsecureSkipVerify returns true if the certificate of Sysbreaker
+// isn't verified. This should only be used for testing
+func (m *Monkey) SysbreakerInsecureSkipVerify() bool {
+	return m.v.GetBool(param.SysbreakerInsecureSkipVerify)
+}
+
 // Decryptor returns an interface for decrypting secrets
 func (m *Monkey) Decryptor() string {
 	return m.v.GetString(param.Decryptor)
@@ -381,6 +608,198 @@ func (m *Monkey) OutageChecker() string {
 	return m.v.GetString(param.OutageChecker)
 }
 
+// Backend returns the name of the backend that provides the deployed apps and
+// terminates their employees: "sysbreaker", "kubernetes" or "inventory"
+func (m *Monkey) Backend() string {
+	return m.v.GetString(param.Backend)
+}
+
+// KubernetesKubeconfig returns the path to the kubeconfig file used to
+// connect to the Kubernetes cluster. If blank, Elon uses the in-cluster
+// config of the pod it runs in
+func (m *Monkey) KubernetesKubeconfig() string {
+	return m.v.GetString(param.KubernetesKubeconfig)
+}
+
+// KubernetesAccount returns the name of the account that stands for the
+// Kubernetes cluster
+func (m *Monkey) KubernetesAccount() string {
+	return m.v.GetString(param.KubernetesAccount)
+}
+
+// KubernetesNamespaces returns the namespaces whose workloads are examined
+// for termination. If empty, all namespaces are examined
+func (m *Monkey) KubernetesNamespaces() ([]string, error) {
+	return m.getStringSlice(param.KubernetesNamespaces)
+}
+
+// KubernetesGracePeriodSeconds returns the grace period of pod deletions. If
+// negative, the grace period of the pod is used
+func (m *Monkey) KubernetesGracePeriodSeconds() int {
+	return m.v.GetInt(param.KubernetesGracePeriodSeconds)
+}
+
+// InventoryPath returns the path to the YAML or JSON file that describes the
+// fleet, for the inventory backend
+func (m *Monkey) InventoryPath() string {
+	return m.v.GetString(param.InventoryPath)
+}
+
+// InventoryCommand returns the shell command that the inventory backend runs
+// to terminate an employee. If blank, terminations are only logged
+func (m *Monkey) InventoryCommand() string {
+	return m.v.GetString(param.InventoryCommand)
+}
+
+// WebhookURLs returns the URLs that the webhook tracker posts terminations to
+func (m *Monkey) WebhookURLs() ([]string, error) {
+	return m.getStringSlice(param.WebhookURLs)
+}
+
+// WebhookEncryptedSecret returns the key that signs the payloads of the
+// webhook tracker, encrypted by the decryptor
+func (m *Monkey) WebhookEncryptedSecret() string {
+	return m.v.GetString(param.WebhookEncryptedSecret)
+}
+
+// WebhookTimeout returns how long the webhook tracker waits for each URL to
+// accept a payload
+func (m *Monkey) WebhookTimeout() time.Duration {
+	return time.Duration(m.v.GetInt(param.WebhookTimeout)) * time.Second
+}
+
+// WebhookRequired returns true if terminations are aborted when the webhook
+// tracker can't deliver them. If false, failed deliveries are only logged
+func (m *Monkey) WebhookRequired() bool {
+	return m.v.GetBool(param.WebhookRequired)
+}
+
+// EmailDigestEnabled returns true if the owners of apps are emailed a digest
+// of the terminations scheduled for their apps, once the schedule is
+// published
+func (m *Monkey) EmailDigestEnabled() bool {
+	return m.v.GetBool(param.EmailDigestEnabled)
+}
+
+// EmailDigestTemplate returns the path of the text/template of the body of
+// digests. If blank, the built-in template is used
+func (m *Monkey) EmailDigestTemplate() string {
+	return m.v.GetString(param.EmailDigestTemplate)
+}
+
+// EmailTerminationTemplate returns the path of the text/template of the
+// body of termination notices. If blank, the built-in template is used
+func (m *Monkey) EmailTerminationTemplate() string {
+	return m.v.GetString(param.EmailTerminationTemplate)
+}
+
+// EmailWarningTemplate returns the path of the text/template of the body of
+// warnings of upcoming terminations. If blank, the built-in template is used
+func (m *Monkey) EmailWarningTemplate() string {
+	return m.v.GetString(param.EmailWarningTemplate)
+}
+
+// SMTPHost returns the host of the SMTP server that sends emails
+func (m *Monkey) SMTPHost() string {
+	return m.v.GetString(param.SMTPHost)
+}
+
+// SMTPPort returns the port of the SMTP server that sends emails
+func (m *Monkey) SMTPPort() int {
+	return m.v.GetInt(param.SMTPPort)
+}
+
+// SMTPFrom returns the sender address of emails
+func (m *Monkey) SMTPFrom() string {
+	return m.v.GetString(param.SMTPFrom)
+}
+
+// SMTPUsername returns the username for authenticating with the SMTP server.
+// If blank, Elon doesn't authenticate
+func (m *Monkey) SMTPUsername() string {
+	return m.v.GetString(param.SMTPUsername)
+}
+
+// SMTPEncryptedPassword returns the password for authenticating with the
+// SMTP server, encrypted by the decryptor
+func (m *Monkey) SMTPEncryptedPassword() string {
+	return m.v.GetString(param.SMTPEncryptedPassword)
+}
+
+// OutageURL returns the status endpoint that the http outage checker queries
+func (m *Monkey) OutageURL() string {
+	return m.v.GetString(param.OutageURL)
+}
+
+// OutageJSONPath returns the dot-separated path of the field of the JSON
+// response of the status endpoint that tells if there is an outage, e.g.
+// "status.indicator". If blank, the HTTP status of the response tells
+func (m *Monkey) OutageJSONPath() string {
+	return m.v.GetString(param.OutageJSONPath)
+}
+
+// OutageOKValues returns the values of the field at the JSON path that mean
+// there is no outage
+func (m *Monkey) OutageOKValues() ([]string, error) {
+	return m.getStringSlice(param.OutageOKValues)
+}
+
+// OutageTimeout returns how long the http outage checker waits for the status
+// endpoint to respond
+func (m *Monkey) OutageTimeout() time.Duration {
+	return time.Duration(m.v.GetInt(param.OutageTimeout)) * time.Second
+}
+
+// AlertmanagerURL returns the base URL of the Alertmanager that the
+// alertmanager outage checker queries, e.g. "http://alertmanager:9093"
+func (m *Monkey) AlertmanagerURL() string {
+	return m.v.GetString(param.AlertmanagerURL)
+}
+
+// AlertmanagerMatchers returns the label matchers of the alerts that mean
+// there is an outage. Each is a comma-separated list of matchers, e.g.
+// "severity=critical,team=~payments|checkout", that an alert must all match
+func (m *Monkey) AlertmanagerMatchers() ([]string, error) {
+	return m.getStringSlice(param.AlertmanagerMatchers)
+}
+
+// AlertmanagerAccountLabel returns the label of alerts that holds the account
+// they affect. If blank, alerts affect all accounts
+func (m *Monkey) AlertmanagerAccountLabel() string {
+	return m.v.GetString(param.AlertmanagerAccountLabel)
+}
+
+// AlertmanagerRegionLabel returns the label of alerts that holds the region
+// they affect. If blank, alerts affect all regions
+func (m *Monkey) AlertmanagerRegionLabel() string {
+	return m.v.GetString(param.AlertmanagerRegionLabel)
+}
+
+// AlertmanagerTimeout returns how long the alertmanager outage checker waits
+// for Alertmanager to respond
+func (m *Monkey) AlertmanagerTimeout() time.Duration {
+	return time.Duration(m.v.GetInt(param.AlertmanagerTimeout)) * time.Second
+}
+
+// MetricsAddress returns the address on which the daemon serves metrics at
+// /metrics, e.g. "localhost:9464". If blank, metrics are not served
+func (m *Monkey) MetricsAddress() string {
+	return m.v.GetString(param.MetricsAddress)
+}
+
+// MetricsTextfileDirectory returns the node_exporter textfile directory that
+// the schedule and terminate commands write their metrics to. If blank, no
+// textfile is written
+func (m *Monkey) MetricsTextfileDirectory() string {
+	return m.v.GetString(param.MetricsTextfileDir)
+}
+
+// DatabaseDriver returns the name of the database backend used to store
+// schedules and terminations, e.g. "mysql", "postgres" or "file"
+func (m *Monkey) DatabaseDriver() string {
+	return m.v.GetString(param.DatabaseDriver)
+}
+
 // DatabaseHost returns the hostname the database is running on
 func (m *Monkey) DatabaseHost() string {
 	return m.v.GetString(param.DatabaseHost)
@@ -408,6 +827,89 @@ func (m *Monkey) DatabaseEncryptedPassword() string {
 	return m.v.GetString(param.DatabaseEncryptedPassword)
 }
 
+// DatabasePath returns the path of the file that stores the Elon state
+// when using the "file" database driver
+func (m *Monkey) DatabasePath() string {
+	return m.v.GetString(param.DatabasePath)
+}
+
+// CalendarWorkdays returns the names of the days of the week that are work
+// days, e.g. ["Mon", "Tue", "Wed", "Thu", "Fri"]
+func (m *Monkey) CalendarWorkdays() ([]string, error) {
+	return m.getStringSlice(param.CalendarWorkdays)
+}
+
+// CalendarHolidays returns the paths of the files (.ics or .yaml) that list
+// the holidays that apply to all regions
+func (m *Monkey) CalendarHolidays() ([]string, error) {
+	return m.getStringSlice(param.CalendarHolidays)
+}
+
+// CalendarRegions returns the regions that have their own calendar
+func (m *Monkey) CalendarRegions() []string {
+	var regions []string
+	for region := range m.v.GetStringMap(param.CalendarRegions) {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// RegionCalendarWorkdays returns the work days of the week in region.
+// Defaults to CalendarWorkdays if the region doesn't specify its own.
+func (m *Monkey) RegionCalendarWorkdays(region string) ([]string, error) {
+	t, ok := m.regionCalendar(region)["workdays"]
+	if !ok {
+		return m.CalendarWorkdays()
+	}
+	return toStringSlice(param.CalendarRegions+"."+region+".workdays", t)
+}
+
+// RegionCalendarHolidays returns the paths of the files that list the
+// holidays that only apply to region
+func (m *Monkey) RegionCalendarHolidays(region string) ([]string, error) {
+	t, ok := m.regionCalendar(region)["holidays"]
+	if !ok {
+		return nil, nil
+	}
+	return toStringSlice(param.CalendarRegions+"."+region+".holidays", t)
+}
+
+// regionCalendar returns the calendar settings of region
+func (m *Monkey) regionCalendar(region string) map[string]interface{} {
+	return cast.ToStringMap(m.v.GetStringMap(param.CalendarRegions)[region])
+}
+
+// ConstrainerMaxPerDay returns the max number of terminations per day,
+// fleet-wide. Zero means no limit.
+func (m *Monkey) ConstrainerMaxPerDay() int {
+	return m.v.GetInt(param.ConstrainerMaxPerDay)
+}
+
+// ConstrainerMaxPerAccount returns the max number of terminations per day in
+// each account. Zero means no limit.
+func (m *Monkey) ConstrainerMaxPerAccount() int {
+	return m.v.GetInt(param.ConstrainerMaxPerAccount)
+}
+
+// ConstrainerMaxPerRegion returns the max number of terminations per day in
+// each region. Zero means no limit.
+func (m *Monkey) ConstrainerMaxPerRegion() int {
+	return m.v.GetInt(param.ConstrainerMaxPerRegion)
+}
+
+// ConstrainerMinMinutesBetween returns the minimum number of minutes between
+// any two scheduled terminations. Zero means no minimum.
+func (m *Monkey) ConstrainerMinMinutesBetween() int {
+	return m.v.GetInt(param.ConstrainerMinMinutesBetween)
+}
+
+// BlackoutsPath returns the path of the YAML file that lists the blackout
+// windows, during which no employees are terminated. Blank if there are none.
+func (m *Monkey) BlackoutsPath() string {
+	return m.v.GetString(param.BlackoutsPath)
+}
+
 // BindPFlag binds a specific parameter to a pflag
 func (m *Monkey) BindPFlag(parameter string, flag *pflag.Flag) (err error) {
 	return m.v.BindPFlag(parameter, flag)
@@ -35,6 +35,11 @@ const (
 	ScheduleCronPath = "elon.schedule_cron_path"
 	SchedulePath     = "elon.schedule_path"
 	LogPath          = "elon.log_path"
+	BlackoutsPath    = "elon.blackouts_path"
+	Backend          = "elon.backend"
+	WarnPath         = "elon.warn_path"
+	WarningMinutes   = "elon.warning_minutes"
+	Warners          = "elon.warners"
 
 	// sysbreaker
 	SysbreakerEndpoint          = "sysbreaker.endpoint"
@@ -43,12 +48,94 @@ const (
 	SysbreakerUser              = "sysbreaker.user"
 	SysbreakerX509Cert          = "sysbreaker.x509_cert"
 	SysbreakerX509Key           = "sysbreaker.x509_key"
+	SysbreakerTaskTimeout       = "sysbreaker.task_timeout_seconds"
+	SysbreakerTaskPollInterval  = "sysbreaker.task_poll_interval_seconds"
+	SysbreakerConcurrency       = "sysbreaker.concurrency"
+	SysbreakerTimeout           = "sysbreaker.timeout_seconds"
+	SysbreakerMaxRetries        = "sysbreaker.max_retries"
+	SysbreakerRetryBackoff      = "sysbreaker.retry_backoff_ms"
+	SysbreakerRetryMaxBackoff   = "sysbreaker.retry_max_backoff_seconds"
+	SysbreakerRateLimit         = "sysbreaker.rate_limit"
+	SysbreakerBreakerThreshold  = "sysbreaker.circuit_breaker_threshold"
+	SysbreakerBreakerCooldown   = "sysbreaker.circuit_breaker_cooldown_seconds"
+
+	SysbreakerAuth                        = "sysbreaker.auth"
+	SysbreakerTokenFile                   = "sysbreaker.token_file"
+	SysbreakerOAuth2TokenURL              = "sysbreaker.oauth2_token_url"
+	SysbreakerOAuth2ClientID              = "sysbreaker.oauth2_client_id"
+	SysbreakerOAuth2EncryptedClientSecret = "sysbreaker.oauth2_encrypted_client_secret"
+	SysbreakerOAuth2Scopes                = "sysbreaker.oauth2_scopes"
+	SysbreakerBasicUsername               = "sysbreaker.basic_username"
+	SysbreakerBasicEncryptedPassword      = "sysbreaker.basic_encrypted_password"
+	SysbreakerCABundle                    = "sysbreaker.ca_bundle"
+	SysbreakerServerName                  = "sysbreaker.server_name"
+	SysbreakerInsecureSkipVerify          = "sysbreaker.insecure_skip_verify"
+
+	// kubernetes
+	KubernetesKubeconfig         = "kubernetes.kubeconfig"
+	KubernetesAccount            = "kubernetes.account"
+	KubernetesNamespaces         = "kubernetes.namespaces"
+	KubernetesGracePeriodSeconds = "kubernetes.grace_period_seconds"
+
+	// inventory
+	InventoryPath    = "inventory.path"
+	InventoryCommand = "inventory.command"
+
+	// webhook tracker
+	WebhookURLs            = "webhook.urls"
+	WebhookEncryptedSecret = "webhook.encrypted_secret"
+	WebhookTimeout         = "webhook.timeout_seconds"
+	WebhookRequired        = "webhook.required"
+
+	// email
+	EmailDigestEnabled       = "email.digest_enabled"
+	EmailDigestTemplate      = "email.digest_template"
+	EmailTerminationTemplate = "email.termination_template"
+	EmailWarningTemplate     = "email.warning_template"
+
+	// smtp
+	SMTPHost              = "smtp.host"
+	SMTPPort              = "smtp.port"
+	SMTPFrom              = "smtp.from"
+	SMTPUsername          = "smtp.username"
+	SMTPEncryptedPassword = "smtp.encrypted_password"
+
+	// http outage checker
+	OutageURL      = "outage.url"
+	OutageJSONPath = "outage.json_path"
+	OutageOKValues = "outage.ok_values"
+	OutageTimeout  = "outage.timeout_seconds"
+
+	// alertmanager outage checker
+	AlertmanagerURL          = "alertmanager.url"
+	AlertmanagerMatchers     = "alertmanager.matchers"
+	AlertmanagerAccountLabel = "alertmanager.account_label"
+	AlertmanagerRegionLabel  = "alertmanager.region_label"
+	AlertmanagerTimeout      = "alertmanager.timeout_seconds"
+
+	// metrics
+	MetricsAddress     = "metrics.address"
+	MetricsTextfileDir = "metrics.textfile_directory"
+
 	// database
+	DatabaseDriver            = "database.driver"
 	DatabaseHost              = "database.host"
 	DatabasePort              = "database.port"
 	DatabaseUser              = "database.user"
 	DatabaseEncryptedPassword = "database.encrypted_password"
 	DatabaseName              = "database.name"
+	DatabasePath              = "database.path"
+
+	// calendar
+	CalendarWorkdays = "calendar.workdays"
+	CalendarHolidays = "calendar.holidays"
+	CalendarRegions  = "calendar.regions"
+
+	// constrainer
+	ConstrainerMaxPerDay         = "constrainer.max_per_day"
+	ConstrainerMaxPerAccount     = "constrainer.max_per_account"
+	ConstrainerMaxPerRegion      = "constrainer.max_per_region"
+	ConstrainerMinMinutesBetween = "constrainer.min_minutes_between"
 
 	// dynamic property provider
 	DynamicProvider = "dynamic.provider"
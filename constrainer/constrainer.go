@@ -15,7 +15,12 @@
 package constrainer
 
 import (
+	"time"
+
+	"github.com/pkg/errors"
+
 	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
 	"github.com/FakeTwitter/elon/deps"
 	"github.com/FakeTwitter/elon/schedule"
 )
@@ -24,7 +29,7 @@ import (
 type NullConstrainer struct{}
 
 func init() {
-	deps.GetConstrainer = getNullConstrainer
+	deps.GetConstrainer = getConstrainer
 }
 
 // Filter implements schedule.Constrainer.Filter
@@ -33,6 +38,57 @@ func (n NullConstrainer) Filter(s schedule.Schedule) schedule.Schedule {
 	return s
 }
 
-func getNullConstrainer(cfg *config.Monkey) (schedule.Constrainer, error) {
-	return NullConstrainer{}, nil
+// getConstrainer returns the chain of constrainers configured in the
+// "constrainer" section of cfg, or a no-op constrainer if none are configured.
+//
+// The constrainers are applied in this order: min time between terminations,
+// max per account, max per region, max per day.
+func getConstrainer(cfg *config.Monkey) (schedule.Constrainer, error) {
+	var chain Chain
+
+	minutes, err := limit(param.ConstrainerMinMinutesBetween, cfg.ConstrainerMinMinutesBetween())
+	if err != nil {
+		return nil, err
+	}
+	if minutes > 0 {
+		chain = append(chain, MinSpacing{Min: time.Duration(minutes) * time.Minute})
+	}
+
+	perAccount, err := limit(param.ConstrainerMaxPerAccount, cfg.ConstrainerMaxPerAccount())
+	if err != nil {
+		return nil, err
+	}
+	if perAccount > 0 {
+		chain = append(chain, MaxPerAccount{Max: perAccount})
+	}
+
+	perRegion, err := limit(param.ConstrainerMaxPerRegion, cfg.ConstrainerMaxPerRegion())
+	if err != nil {
+		return nil, err
+	}
+	if perRegion > 0 {
+		chain = append(chain, MaxPerRegion{Max: perRegion})
+	}
+
+	perDay, err := limit(param.ConstrainerMaxPerDay, cfg.ConstrainerMaxPerDay())
+	if err != nil {
+		return nil, err
+	}
+	if perDay > 0 {
+		chain = append(chain, MaxPerDay{Max: perDay})
+	}
+
+	if len(chain) == 0 {
+		return NullConstrainer{}, nil
+	}
+
+	return chain, nil
+}
+
+// limit returns value, or an error if the value of the key param is negative
+func limit(key string, value int) (int, error) {
+	if value < 0 {
+		return 0, errors.Errorf("%s must not be negative, was %d", key, value)
+	}
+	return value, nil
 }
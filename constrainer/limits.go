@@ -0,0 +1,150 @@
+// Copyright 2017 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package constrainer
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// Chain is a constrainer that applies a sequence of constrainers, each one to
+// the schedule produced by the previous one
+type Chain []schedule.Constrainer
+
+// Filter implements schedule.Constrainer.Filter
+func (c Chain) Filter(s schedule.Schedule) schedule.Schedule {
+	for _, cons := range c {
+		s = cons.Filter(s)
+	}
+	return s
+}
+
+// MaxPerDay limits the number of terminations in the schedule, fleet-wide
+type MaxPerDay struct {
+	Max int
+}
+
+// Filter implements schedule.Constrainer.Filter
+func (m MaxPerDay) Filter(s schedule.Schedule) schedule.Schedule {
+	var n int
+	return filter(s, func(e schedule.Entry) string {
+		if n >= m.Max {
+			return fmt.Sprintf("exceeds max of %d terminations per day", m.Max)
+		}
+		n++
+		return ""
+	})
+}
+
+// MaxPerAccount limits the number of terminations in the schedule for each
+// account
+type MaxPerAccount struct {
+	Max int
+}
+
+// Filter implements schedule.Constrainer.Filter
+func (m MaxPerAccount) Filter(s schedule.Schedule) schedule.Schedule {
+	counts := make(map[string]int)
+	return filter(s, func(e schedule.Entry) string {
+		account := e.Group.Account()
+		if counts[account] >= m.Max {
+			return fmt.Sprintf("exceeds max of %d terminations per day in account %s", m.Max, account)
+		}
+		counts[account]++
+		return ""
+	})
+}
+
+// MaxPerRegion limits the number of terminations in the schedule for each
+// region. Groups that span all regions are counted together, as if they were
+// a region of their own.
+type MaxPerRegion struct {
+	Max int
+}
+
+// Filter implements schedule.Constrainer.Filter
+func (m MaxPerRegion) Filter(s schedule.Schedule) schedule.Schedule {
+	counts := make(map[string]int)
+	return filter(s, func(e schedule.Entry) string {
+		region, ok := e.Group.Region()
+		if !ok {
+			region = "(all regions)"
+		}
+		if counts[region] >= m.Max {
+			return fmt.Sprintf("exceeds max of %d terminations per day in region %s", m.Max, region)
+		}
+		counts[region]++
+		return ""
+	})
+}
+
+// MinSpacing requires a minimum amount of time between any two terminations
+// in the schedule
+type MinSpacing struct {
+	Min time.Duration
+}
+
+// Filter implements schedule.Constrainer.Filter
+func (m MinSpacing) Filter(s schedule.Schedule) schedule.Schedule {
+	var last *schedule.Entry
+	return filter(s, func(e schedule.Entry) string {
+		if last != nil && e.Time.Sub(last.Time) < m.Min {
+			return fmt.Sprintf("less than %s after termination of %s at %s", m.Min, grp.String(last.Group), last.Time)
+		}
+		last = &e
+		return ""
+	})
+}
+
+// filter returns a schedule with the entries of s for which drop returns an
+// empty string. drop is called on each entry in order of time, so that the
+// earliest entries are kept. Entries scheduled at the same time are ordered
+// by group, which makes the result deterministic. The result has the same
+// seed as s.
+//
+// Dropped entries are logged along with the reason returned by drop.
+func filter(s schedule.Schedule, drop func(e schedule.Entry) string) schedule.Schedule {
+	entries := make([]schedule.Entry, len(s.Entries()))
+	copy(entries, s.Entries())
+	sort.Sort(byTimeAndGroup(entries))
+
+	result := schedule.NewWithSeed(s.Seed())
+	for _, e := range entries {
+		if reason := drop(e); reason != "" {
+			log.Printf("constrainer: dropping %s at %s: %s", grp.String(e.Group), e.Time, reason)
+			continue
+		}
+		result.Add(e.Time, e.Group)
+	}
+
+	return *result
+}
+
+// byTimeAndGroup sorts entries by time, then by group
+type byTimeAndGroup []schedule.Entry
+
+func (t byTimeAndGroup) Len() int      { return len(t) }
+func (t byTimeAndGroup) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t byTimeAndGroup) Less(i, j int) bool {
+	if !t[i].Time.Equal(t[j].Time) {
+		return t[i].Time.Before(t[j].Time)
+	}
+	return grp.String(t[i].Group) < grp.String(t[j].Group)
+}
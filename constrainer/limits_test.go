@@ -0,0 +1,111 @@
+// Copyright 2017 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package constrainer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// at returns 2016-06-20 at hour:minute in UTC
+func at(hour, minute int) time.Time {
+	return time.Date(2016, time.June, 20, hour, minute, 0, 0, time.UTC)
+}
+
+// testSchedule returns a schedule whose entries are deliberately not sorted
+func testSchedule() schedule.Schedule {
+	s := schedule.New()
+	s.Add(at(11, 0), grp.New("foo", "prod", "us-east-1", "", "foo-prod"))
+	s.Add(at(9, 0), grp.New("bar", "prod", "us-east-1", "", "bar-prod"))
+	s.Add(at(9, 0), grp.New("baz", "test", "us-west-2", "", "baz-test"))
+	s.Add(at(9, 20), grp.New("quux", "prod", "us-west-2", "", "quux-prod"))
+	s.Add(at(13, 0), grp.New("corge", "test", "", "", "corge-test"))
+	return *s
+}
+
+// apps returns the apps of the entries of s, in order
+func apps(s schedule.Schedule) []string {
+	var result []string
+	for _, e := range s.Entries() {
+		result = append(result, e.Group.Team())
+	}
+	return result
+}
+
+func TestConstrainers(t *testing.T) {
+	tests := []struct {
+		desc string
+		cons schedule.Constrainer
+		want []string
+	}{
+		{"max per day", MaxPerDay{Max: 3}, []string{"bar", "baz", "quux"}},
+		{"max per account", MaxPerAccount{Max: 1}, []string{"bar", "baz"}},
+		{"max per region", MaxPerRegion{Max: 1}, []string{"bar", "baz", "corge"}},
+		{"min spacing", MinSpacing{Min: 30 * time.Minute}, []string{"bar", "foo", "corge"}},
+		{"chain", Chain{MinSpacing{Min: 30 * time.Minute}, MaxPerAccount{Max: 1}}, []string{"bar", "corge"}},
+	}
+
+	for _, tt := range tests {
+		got := apps(tt.cons.Filter(testSchedule()))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.desc, got, tt.want)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.desc, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestGetConstrainer(t *testing.T) {
+	cfg := config.Defaults()
+
+	cons, err := getConstrainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cons.(NullConstrainer); !ok {
+		t.Errorf("got %T with no constraints configured, want NullConstrainer", cons)
+	}
+
+	cfg.Set(param.ConstrainerMaxPerDay, 2)
+	cfg.Set(param.ConstrainerMinMinutesBetween, 30)
+
+	cons, err = getConstrainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(cons.Filter(testSchedule()).Entries()), 2; got != want {
+		t.Errorf("got %d entries, want %d", got, want)
+	}
+
+	cfg.Set(param.ConstrainerMaxPerRegion, -1)
+
+	_, err = getConstrainer(cfg)
+	if err == nil {
+		t.Error("got nil error with negative max per region, want error")
+	}
+}
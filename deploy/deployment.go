@@ -47,6 +47,16 @@ type Deployment interface {
 	CloudProvider(account string) (provider string, err error)
 }
 
+// FailureReporter is implemented by deployments that report the apps that
+// couldn't be retrieved, so that callers can tell a partial result from a
+// complete one
+type FailureReporter interface {
+	// TeamsWithFailures is like Deployment.Teams, but calls failed for each
+	// app that couldn't be retrieved instead of skipping it silently. failed
+	// may be called concurrently.
+	TeamsWithFailures(c chan<- *Team, appNames []string, failed func(appName string, err error))
+}
+
 // Account represents the set of teams associated with an Team that reside
 // in one AWS account (e.g., "prod", "test").
 type Account struct {
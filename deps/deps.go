@@ -16,6 +16,8 @@
 package deps
 
 import (
+	"math/rand"
+
 	"github.com/FakeTwitter/elon"
 	"github.com/FakeTwitter/elon/clock"
 	"github.com/FakeTwitter/elon/config"
@@ -43,6 +45,12 @@ var (
 
 	// GetConstrainer returns an interface for constraining the schedule
 	GetConstrainer func(*config.Monkey) (schedule.Constrainer, error)
+
+	// GetOwners returns an interface for looking up the owners of apps
+	GetOwners func(*config.Monkey) (elon.OwnerGetter, error)
+
+	// GetWarners returns a list of warners of upcoming terminations
+	GetWarners func(*config.Monkey) ([]elon.Warner, error)
 )
 
 // Deps are a common set of external dependencies
@@ -54,7 +62,12 @@ type Deps struct {
 	Dep        deploy.Deployment
 	T          elon.Terminator
 	Trackers   []elon.Tracker
+	Warners    []elon.Warner
 	Ou         elon.Outage
 	ErrCounter elon.ErrorCounter
 	Env        elon.Env
+
+	// Rand is the source of randomness for selecting the employee to
+	// terminate. Seeding it with the same value selects the same employee.
+	Rand *rand.Rand
 }
@@ -29,6 +29,19 @@ const (
 	Team
 )
 
+const (
+	// Pending termination: permitted by the checker, but not executed yet
+	Pending Outcome = "pending"
+	// Succeeded termination: the employee was fired
+	Succeeded Outcome = "succeeded"
+	// Failed termination: the employee could not be fired. Failed terminations
+	// don't count toward the min time between terminations
+	Failed Outcome = "failed"
+	// Skipped termination: deliberately not executed, e.g., because Elon is
+	// leashed
+	Skipped Outcome = "skipped"
+)
+
 type (
 
 	// TeamConfig contains app-specific configuration parameters for Elon
@@ -40,6 +53,16 @@ type (
 		Grouping                       Group
 		Exceptions                     []Exception
 		Whitelist                      *[]Exception
+
+		// The termination window of the team. When set, these override the
+		// global start hour, end hour and time zone of Elon
+		StartHour *int
+		EndHour   *int
+		Location  *time.Location
+
+		// Days of the week on which employees of the team may be fired.
+		// If empty, employees may be fired on any work day
+		Days []time.Weekday
 	}
 
 	// Group describes what Elon considers a group of employees
@@ -94,6 +117,9 @@ type (
 		Leashed  bool      // If true, track the termination but do not execute it
 	}
 
+	// Outcome is the state of a termination that was permitted by the checker
+	Outcome string
+
 	// Tracker records termination events an a tracking system such as Chronos
 	Tracker interface {
 		// Track pushes a termination event to the tracking system
@@ -124,6 +150,13 @@ type (
 		Get(app string) (*TeamConfig, error)
 	}
 
+	// OwnerGetter retrieves the owners of apps
+	OwnerGetter interface {
+		// Owner returns the email address of the owner of app, or blank if
+		// it has none
+		Owner(app string) (string, error)
+	}
+
 	// Checker checks to see if a termination is permitted given min time between terminations
 	//
 	// if the termination is permitted, returns (true, nil)
@@ -131,13 +164,20 @@ type (
 	//
 	// Returns ErrViolatesMinTime if violates min time between terminations
 	//
-	// Note that this call may change the state of the team: if the checker returns true, the termination will be recorded.
+	// Note that this call may change the state of the team: if the checker returns true, the termination will be recorded
+	// as Pending, until its outcome is recorded with Complete.
 	Checker interface {
 		// Check checks if a termination is permitted and, if so, records the
 		// termination time on the team.
 		// The endHour (hour time when Elon stops fireing) is in the
 		// time zone specified by loc.
 		Check(term Termination, appCfg TeamConfig, endHour int, loc *time.Location) error
+
+		// Complete records the outcome of a termination that was permitted by
+		// Check. ref identifies the task that executed the termination, if
+		// any, and message describes why the termination failed or was
+		// skipped.
+		Complete(term Termination, outcome Outcome, ref string, message string) error
 	}
 
 	// Terminator provides an interface for fireing employees
@@ -146,6 +186,60 @@ type (
 		Execute(trm Termination) error
 	}
 
+	// TaskTerminator is a Terminator that fires employees by starting a task,
+	// e.g., a Sysbreaker task, that can be referred to later
+	TaskTerminator interface {
+		Terminator
+
+		// ExecuteTask terminates a running employee, and returns a reference
+		// to the task that does it
+		ExecuteTask(trm Termination) (ref string, err error)
+	}
+
+	// OutcomeTracker is a Tracker that also records the outcome of
+	// terminations, once they have been executed
+	OutcomeTracker interface {
+		Tracker
+
+		// TrackOutcome pushes the outcome of a termination to the tracking
+		// system. message describes why the termination failed or was skipped
+		TrackOutcome(t Termination, outcome Outcome, message string) error
+	}
+
+	// OptionalTracker is a Tracker that may not be required to record
+	// terminations
+	OptionalTracker interface {
+		Tracker
+
+		// Optional returns true if terminations should proceed when the
+		// tracker fails to record them. Otherwise, they are aborted
+		Optional() bool
+	}
+
+	// Warning is a heads-up that an employee of a group is about to be
+	// terminated. The employee is only picked at the time of the termination,
+	// so the warning identifies the group it will be picked from.
+	Warning struct {
+		App     string
+		Account string
+
+		// Region, Stack and Team are blank if the group isn't restricted to
+		// one of them
+		Region string
+		Stack  string
+		Team   string
+
+		Time    time.Time // Scheduled termination time
+		Leashed bool      // If true, the termination will only be tracked
+	}
+
+	// Warner sends warnings of upcoming terminations, e.g., to the owners of
+	// the apps, so that they can watch their dashboards
+	Warner interface {
+		// Warn sends a warning of an upcoming termination
+		Warn(w Warning) error
+	}
+
 	// Outage provides an interface for checking if there is currently an outage
 	// This provides a mechanism to check if there's an ongoing outage, since
 	// Elon doesn't run during outages
@@ -154,6 +248,17 @@ type (
 		Outage() (bool, error)
 	}
 
+	// ScopedOutage is an Outage that can tell which accounts and regions an
+	// outage affects, so that an outage elsewhere doesn't prevent terminations
+	ScopedOutage interface {
+		Outage
+
+		// OutageIn returns true if there is an ongoing outage that affects
+		// account and region. region is blank if the employee may be
+		// terminated in any region
+		OutageIn(account string, region string) (bool, error)
+	}
+
 	// ErrViolatesMinTime represents an error when trying to record a termination
 	// that violates the min time between terminations for that particular team
 	ErrViolatesMinTime struct {
@@ -161,6 +266,15 @@ type (
 		FiredAt   time.Time      // the time that the most recent employee was terminated
 		Loc        *time.Location // local time zone location
 	}
+
+	// ErrTaskTimeout represents an error when the task that executes a
+	// termination didn't finish in time, so the outcome of the termination
+	// is unknown
+	ErrTaskTimeout struct {
+		Ref     string        // reference of the task
+		Status  string        // last known status of the task
+		Timeout time.Duration // how long the task was waited for
+	}
 )
 
 // String returns a string representation for a Group
@@ -191,6 +305,40 @@ func NewTeamConfig(exceptions []Exception) TeamConfig {
 	return result
 }
 
+// Window returns the termination window of the team: the hours of the day
+// during which its employees may be fired, and the time zone of those hours.
+// Values that the team doesn't override are taken from the arguments.
+func (c TeamConfig) Window(startHour, endHour int, loc *time.Location) (int, int, *time.Location) {
+	if c.StartHour != nil {
+		startHour = *c.StartHour
+	}
+
+	if c.EndHour != nil {
+		endHour = *c.EndHour
+	}
+
+	if c.Location != nil {
+		loc = c.Location
+	}
+
+	return startHour, endHour, loc
+}
+
+// AllowsDay returns true if employees of the team may be fired on day
+func (c TeamConfig) AllowsDay(day time.Weekday) bool {
+	if len(c.Days) == 0 {
+		return true
+	}
+
+	for _, d := range c.Days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Matches returns true if an exception matches an ASG
 func (ex Exception) Matches(account, stack, detail, region string) bool {
 	return exFieldMatches(ex.Account, account) &&
@@ -215,3 +363,7 @@ func (e ErrViolatesMinTime) Error() string {
 
 	return s
 }
+
+func (e ErrTaskTimeout) Error() string {
+	return fmt.Sprintf("task %s did not finish within %s, last status: %s", e.Ref, e.Timeout, e.Status)
+}
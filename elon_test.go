@@ -16,6 +16,7 @@ package elon_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/FakeTwitter/elon"
 )
@@ -27,3 +28,51 @@ func TestExceptionMatches(t *testing.T) {
 		t.Error("Expected exception match")
 	}
 }
+
+func TestWindow(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start, end := 10, 16
+
+	tests := []struct {
+		cfg   elon.TeamConfig
+		start int
+		end   int
+		loc   *time.Location
+	}{
+		{elon.TeamConfig{}, 9, 15, la},
+		{elon.TeamConfig{StartHour: &start}, 10, 15, la},
+		{elon.TeamConfig{StartHour: &start, EndHour: &end, Location: paris}, 10, 16, paris},
+	}
+
+	for _, tt := range tests {
+		start, end, loc := tt.cfg.Window(9, 15, la)
+		if start != tt.start || end != tt.end || loc != tt.loc {
+			t.Errorf("got Window()=(%d, %d, %s), want (%d, %d, %s)", start, end, loc, tt.start, tt.end, tt.loc)
+		}
+	}
+}
+
+func TestAllowsDay(t *testing.T) {
+	var cfg elon.TeamConfig
+	if !cfg.AllowsDay(time.Saturday) {
+		t.Error("Expected all days to be allowed when none are specified")
+	}
+
+	cfg.Days = []time.Weekday{time.Tuesday, time.Thursday}
+	if !cfg.AllowsDay(time.Thursday) {
+		t.Error("Expected Thursday to be allowed")
+	}
+
+	if cfg.AllowsDay(time.Monday) {
+		t.Error("Expected Monday not to be allowed")
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package email notifies the owners of apps of their terminations by email
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// Sender sends emails through an SMTP server
+type Sender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSender returns a sender that sends emails from the address from
+// through the SMTP server at addr (host:port). auth may be nil, if the
+// server doesn't require authentication.
+func NewSender(addr, from string, auth smtp.Auth) Sender {
+	return Sender{addr: addr, from: from, auth: auth}
+}
+
+// Send sends a plain text email
+func (s Sender) Send(to []string, subject, body string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&msg, "Content-Transfer-Encoding: 8bit\r\n")
+	fmt.Fprintf(&msg, "\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	err := smtp.SendMail(s.addr, s.auth, s.from, to, msg.Bytes())
+	return errors.Wrapf(err, "could not send email to %s", strings.Join(to, ", "))
+}
+
+// Notifier emails the owners of apps a digest of the terminations that are
+// scheduled for their apps, a warning shortly before one of their employees
+// is terminated, and a notice once it has been terminated.
+//
+// Notifier is an elon.OutcomeTracker, so that it can send the notices. It is
+// optional, since an email that can't be sent is no reason not to
+// terminate. It is also an elon.Warner.
+type Notifier struct {
+	sender      Sender
+	owners      elon.OwnerGetter
+	digest      *template.Template
+	termination *template.Template
+	warning     *template.Template
+	loc         *time.Location
+}
+
+// DigestData is the data of the digest template
+type DigestData struct {
+	App          string
+	Date         time.Time
+	Terminations []Scheduled
+}
+
+// Scheduled is a scheduled termination of a digest
+type Scheduled struct {
+	// Time is the time of the termination, in the time zone of Elon
+	Time time.Time
+
+	// Group describes the group that an employee is terminated from, e.g.
+	// "app=foo account=prod region=us-east-1"
+	Group string
+
+	Account string
+	Region  string
+	Stack   string
+	Team    string
+}
+
+// TerminationData is the data of the termination template
+type TerminationData struct {
+	App           string
+	Account       string
+	Region        string
+	Stack         string
+	Team          string
+	ASG           string
+	EmployeeID    string
+	CloudProvider string
+
+	// Time is the time of the termination, in the time zone of Elon
+	Time time.Time
+}
+
+// NewFromConfig returns a notifier configured by cfg, that looks up the
+// owners of apps with owners
+func NewFromConfig(cfg *config.Monkey, owners elon.OwnerGetter) (Notifier, error) {
+	if cfg.SMTPHost() == "" {
+		return Notifier{}, errors.Errorf("%s not specified", param.SMTPHost)
+	}
+
+	if cfg.SMTPFrom() == "" {
+		return Notifier{}, errors.Errorf("%s not specified", param.SMTPFrom)
+	}
+
+	var auth smtp.Auth
+	if cfg.SMTPUsername() != "" {
+		decryptor, err := deps.GetDecryptor(cfg)
+		if err != nil {
+			return Notifier{}, err
+		}
+
+		password, err := decryptor.Decrypt(cfg.SMTPEncryptedPassword())
+		if err != nil {
+			return Notifier{}, errors.Wrap(err, "could not decrypt smtp password")
+		}
+
+		auth = smtp.PlainAuth("", cfg.SMTPUsername(), password, cfg.SMTPHost())
+	}
+
+	digest, err := loadTemplate("digest", cfg.EmailDigestTemplate(), defaultDigestTemplate)
+	if err != nil {
+		return Notifier{}, err
+	}
+
+	termination, err := loadTemplate("termination", cfg.EmailTerminationTemplate(), defaultTerminationTemplate)
+	if err != nil {
+		return Notifier{}, err
+	}
+
+	warning, err := loadTemplate("warning", cfg.EmailWarningTemplate(), defaultWarningTemplate)
+	if err != nil {
+		return Notifier{}, err
+	}
+
+	loc, err := cfg.Location()
+	if err != nil {
+		return Notifier{}, errors.Wrap(err, "could not retrieve location")
+	}
+
+	addr := net.JoinHostPort(cfg.SMTPHost(), strconv.Itoa(cfg.SMTPPort()))
+	return New(NewSender(addr, cfg.SMTPFrom(), auth), owners, digest, termination, warning, loc), nil
+}
+
+// New returns a notifier that sends emails with sender, and renders their
+// bodies with the digest, termination and warning templates, in the time
+// zone loc
+func New(sender Sender, owners elon.OwnerGetter, digest, termination, warning *template.Template, loc *time.Location) Notifier {
+	return Notifier{sender: sender, owners: owners, digest: digest, termination: termination, warning: warning, loc: loc}
+}
+
+// SendDigests emails the owner of each app of sched the terminations that
+// are scheduled for the app on date. Apps without owner are skipped. All
+// the digests are attempted, even if some fail.
+func (n Notifier) SendDigests(sched *schedule.Schedule, date time.Time) error {
+	byApp := make(map[string][]Scheduled)
+	for _, entry := range sched.Entries() {
+		app := entry.Group.Team()
+		byApp[app] = append(byApp[app], n.scheduled(entry))
+	}
+
+	apps := make([]string, 0, len(byApp))
+	for app := range byApp {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+
+	var failures []string
+	for _, app := range apps {
+		terminations := byApp[app]
+		sort.Slice(terminations, func(i, j int) bool { return terminations[i].Time.Before(terminations[j].Time) })
+
+		data := DigestData{App: app, Date: date.In(n.loc), Terminations: terminations}
+		subject := fmt.Sprintf("Elon: %d terminations of %s scheduled on %s", len(terminations), app, data.Date.Format("Mon Jan 2"))
+
+		err := n.send(app, subject, n.digest, data)
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("could not email digests of %d of %d apps: %s", len(failures), len(apps), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// scheduled returns the scheduled termination of a digest for entry
+func (n Notifier) scheduled(entry schedule.Entry) Scheduled {
+	region, _ := entry.Group.Region()
+	stack, _ := entry.Group.Stack()
+	team, _ := entry.Group.Team()
+
+	return Scheduled{
+		Time:    entry.Time.In(n.loc),
+		Group:   grp.String(entry.Group),
+		Account: entry.Group.Account(),
+		Region:  region,
+		Stack:   stack,
+		Team:    team,
+	}
+}
+
+// Track implements elon.Tracker.Track. Owners are only notified once
+// their employee has been terminated, by TrackOutcome.
+func (n Notifier) Track(trm elon.Termination) error {
+	return nil
+}
+
+// TrackOutcome implements elon.OutcomeTracker.TrackOutcome, it notifies the
+// owner of the app when an employee has been terminated
+func (n Notifier) TrackOutcome(trm elon.Termination, outcome elon.Outcome, message string) error {
+	if outcome != elon.Succeeded {
+		return nil
+	}
+
+	ins := trm.employee
+	data := TerminationData{
+		App:           ins.TeamName(),
+		Account:       ins.AccountName(),
+		Region:        ins.RegionName(),
+		Stack:         ins.StackName(),
+		Team:          ins.TeamName(),
+		ASG:           ins.ASGName(),
+		EmployeeID:    ins.ID(),
+		CloudProvider: ins.CloudProvider(),
+		Time:          trm.Time.In(n.loc),
+	}
+	subject := fmt.Sprintf("Elon: terminated %s of %s", data.EmployeeID, data.App)
+
+	return n.send(data.App, subject, n.termination, data)
+}
+
+// Warn implements elon.Warner.Warn, it warns the owner of the app of an
+// upcoming termination. The data of the warning template is the
+// elon.Warning, with its time in the time zone of Elon.
+func (n Notifier) Warn(w elon.Warning) error {
+	w.Time = w.Time.In(n.loc)
+	subject := fmt.Sprintf("Elon: terminating an employee of %s at %s", w.App, w.Time.Format("15:04 MST"))
+
+	return n.send(w.App, subject, n.warning, w)
+}
+
+// Optional implements elon.OptionalTracker.Optional
+func (n Notifier) Optional() bool {
+	return true
+}
+
+// send renders tmpl with data, and emails it to the owner of app, if any
+func (n Notifier) send(app, subject string, tmpl *template.Template, data interface{}) error {
+	owner, err := n.owners.Owner(app)
+	if err != nil {
+		return errors.Wrapf(err, "could not retrieve owner of app=%s", app)
+	}
+
+	if owner == "" {
+		log.Printf("not emailing: app=%s has no owner", app)
+		return nil
+	}
+
+	var body bytes.Buffer
+	err = tmpl.Execute(&body, data)
+	if err != nil {
+		return errors.Wrapf(err, "could not render %s email for app=%s", tmpl.Name(), app)
+	}
+
+	return n.sender.Send(addresses(owner), subject, body.String())
+}
+
+// addresses returns the addresses of a comma-separated list
+func addresses(list string) []string {
+	var result []string
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			result = append(result, addr)
+		}
+	}
+	return result
+}
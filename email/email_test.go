@@ -0,0 +1,294 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/mock"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// message is an email received by an smtpServer
+type message struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer is a local SMTP stand-in that accepts every email, and sends it
+// to messages
+type smtpServer struct {
+	ln       net.Listener
+	messages chan message
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &smtpServer{ln: ln, messages: make(chan message, 10)}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) Close() {
+	_ = s.ln.Close()
+}
+
+func (s *smtpServer) serve(c net.Conn) {
+	tp := textproto.NewConn(c)
+	defer func() { _ = tp.Close() }()
+
+	_ = tp.PrintfLine("220 localhost ESMTP")
+
+	var m message
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			_ = tp.PrintfLine("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			m.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			_ = tp.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			m.to = append(m.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			_ = tp.PrintfLine("250 OK")
+		case cmd == "DATA":
+			_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			lines, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			m.data = strings.Join(lines, "\n")
+			_ = tp.PrintfLine("250 OK")
+			s.messages <- m
+			m = message{}
+		case cmd == "QUIT":
+			_ = tp.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// receive returns the next email received by s
+func (s *smtpServer) receive(t *testing.T) message {
+	select {
+	case m := <-s.messages:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for email")
+		return message{}
+	}
+}
+
+// owners implements elon.OwnerGetter
+type owners map[string]string
+
+func (o owners) Owner(app string) (string, error) {
+	return o[app], nil
+}
+
+func testNotifier(t *testing.T, s *smtpServer) Notifier {
+	digest, err := loadTemplate("digest", "", defaultDigestTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	termination, err := loadTemplate("termination", "", defaultTerminationTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warning, err := loadTemplate("warning", "", defaultWarningTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := owners{"foo": "foo-team@example.com, foo-oncall@example.com"}
+	return New(NewSender(s.ln.Addr().String(), "elon@example.com", nil), o, digest, termination, warning, time.UTC)
+}
+
+func TestSendDigests(t *testing.T) {
+	s := newSMTPServer(t)
+	defer s.Close()
+	n := testNotifier(t, s)
+
+	sched := schedule.New()
+	sched.Add(time.Date(2017, 1, 16, 14, 30, 0, 0, time.UTC), grp.New("foo", "prod", "us-west-2", "", "foo-prod"))
+	sched.Add(time.Date(2017, 1, 16, 10, 15, 0, 0, time.UTC), grp.New("foo", "prod", "us-east-1", "", "foo-prod"))
+
+	// bar has no owner, so nobody is emailed about it
+	sched.Add(time.Date(2017, 1, 16, 11, 0, 0, 0, time.UTC), grp.New("bar", "prod", "us-east-1", "", "bar-prod"))
+
+	err := n.SendDigests(sched, time.Date(2017, 1, 16, 7, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := s.receive(t)
+
+	if got, want := m.from, "elon@example.com"; got != want {
+		t.Errorf("got from=%s, want %s", got, want)
+	}
+
+	if got, want := strings.Join(m.to, ","), "foo-team@example.com,foo-oncall@example.com"; got != want {
+		t.Errorf("got to=%s, want %s", got, want)
+	}
+
+	for _, want := range []string{
+		"Subject: Elon: 2 terminations of foo scheduled on Mon Jan 16",
+		"on Monday, January 16:",
+		"  10:15 UTC  app=foo account=prod region=us-east-1",
+		"  14:30 UTC  app=foo account=prod region=us-west-2",
+	} {
+		if !strings.Contains(m.data, want) {
+			t.Errorf("got email:\n%s\nwant it to contain %q", m.data, want)
+		}
+	}
+
+	// The terminations are in chronological order
+	if strings.Index(m.data, "10:15") > strings.Index(m.data, "14:30") {
+		t.Errorf("got email:\n%s\nwant terminations in chronological order", m.data)
+	}
+
+	select {
+	case m := <-s.messages:
+		t.Errorf("got unexpected email to %v", m.to)
+	default:
+	}
+}
+
+// TestTrackOutcome verifies that owners are only notified of terminations
+// that succeeded
+func TestTrackOutcome(t *testing.T) {
+	s := newSMTPServer(t)
+	defer s.Close()
+	n := testNotifier(t, s)
+
+	ins := mock.employee{Team: "foo", Account: "prod", Region: "us-east-1", ASG: "foo-prod-v001", EmployeeId: "i-703a0439"}
+	trm := elon.Termination{employee: ins, Time: time.Date(2017, 1, 16, 10, 15, 0, 0, time.UTC)}
+
+	for _, outcome := range []elon.Outcome{elon.Failed, elon.Skipped, elon.Succeeded} {
+		err := n.TrackOutcome(trm, outcome, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := s.receive(t)
+	for _, want := range []string{
+		"Subject: Elon: terminated i-703a0439 of foo",
+		"Elon terminated employee i-703a0439 of foo at 10:15 UTC on Monday, January 16.",
+		"ASG:     foo-prod-v001",
+	} {
+		if !strings.Contains(m.data, want) {
+			t.Errorf("got email:\n%s\nwant it to contain %q", m.data, want)
+		}
+	}
+
+	select {
+	case m := <-s.messages:
+		t.Errorf("got unexpected email:\n%s", m.data)
+	default:
+	}
+}
+
+// TestWarn verifies that owners are warned of upcoming terminations of their
+// apps
+func TestWarn(t *testing.T) {
+	s := newSMTPServer(t)
+	defer s.Close()
+	n := testNotifier(t, s)
+
+	at := time.Date(2017, 1, 16, 10, 15, 0, 0, time.UTC)
+	err := n.Warn(elon.Warning{App: "foo", Account: "prod", Region: "us-east-1", Time: at, Leashed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := s.receive(t)
+	for _, want := range []string{
+		"Subject: Elon: terminating an employee of foo at 10:15 UTC",
+		"Elon will terminate an employee of foo at 10:15 UTC on Monday, January 16.",
+		"Elon is leashed",
+		"Region:  us-east-1",
+		"Team:    any",
+	} {
+		if !strings.Contains(m.data, want) {
+			t.Errorf("got email:\n%s\nwant it to contain %q", m.data, want)
+		}
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	f, err := ioutil.TempFile("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	_, err = f.WriteString("{{.EmployeeID}} is gone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	tmpl, err := loadTemplate("termination", f.Name(), defaultTerminationTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	err = tmpl.Execute(&body, TerminationData{EmployeeID: "i-703a0439"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := body.String(), "i-703a0439 is gone"; got != want {
+		t.Errorf("got body=%q, want %q", got, want)
+	}
+
+	_, err = loadTemplate("termination", "", "{{.EmployeeID")
+	if err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+}
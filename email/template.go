@@ -0,0 +1,67 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"io/ioutil"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const defaultDigestTemplate = `Elon is scheduled to terminate employees of {{.App}} on {{.Date.Format "Monday, January 2"}}:
+{{range .Terminations}}
+  {{.Time.Format "15:04 MST"}}  {{.Group}}{{end}}
+
+One employee of each group is terminated at the scheduled time, unless Elon
+is disabled for the app by then.
+`
+
+const defaultTerminationTemplate = `Elon terminated employee {{.EmployeeID}} of {{.App}} at {{.Time.Format "15:04 MST on Monday, January 2"}}.
+
+  Account: {{.Account}}
+  Region:  {{.Region}}
+  Team:    {{.Team}}
+  ASG:     {{.ASG}}
+`
+
+const defaultWarningTemplate = `Elon will terminate an employee of {{.App}} at {{.Time.Format "15:04 MST on Monday, January 2"}}.
+{{if .Leashed}}
+Elon is leashed, so the employee will not actually be terminated.
+{{end}}
+  Account: {{.Account}}
+  Region:  {{or .Region "any"}}
+  Team:    {{or .Team "any"}}
+`
+
+// loadTemplate returns the text/template in the file at path, or the
+// template def if path is blank
+func loadTemplate(name, path, def string) (*template.Template, error) {
+	text := def
+	if path != "" {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read %s template", name)
+		}
+		text = string(contents)
+	}
+
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s template", name)
+	}
+
+	return tmpl, nil
+}
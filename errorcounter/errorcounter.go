@@ -18,12 +18,13 @@ import (
 	"github.com/FakeTwitter/elon"
 	"github.com/FakeTwitter/elon/config"
 	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/metrics"
 	"github.com/pkg/errors"
 )
 
 // Fake Twitter uses Atlas for tracking error events.
-// In the open-source build, we currently only support a null (no-op) error
-// counter
+// In the open-source build, we support a null (no-op) error counter, and a
+// counter that is exposed with the other Prometheus metrics
 
 type nullErrorCounter struct{}
 
@@ -31,15 +32,25 @@ func (n nullErrorCounter) Increment() error {
 	return nil
 }
 
+// prometheusErrorCounter counts errors in metrics.Errors
+type prometheusErrorCounter struct{}
+
+func (p prometheusErrorCounter) Increment() error {
+	metrics.Errors.Inc()
+	return nil
+}
+
 func init() {
-	deps.GetErrorCounter = getNullErrorCounter
+	deps.GetErrorCounter = getErrorCounter
 }
 
-func getNullErrorCounter(cfg *config.Monkey) (elon.ErrorCounter, error) {
-	kind := cfg.ErrorCounter()
-	if kind != "" {
+func getErrorCounter(cfg *config.Monkey) (elon.ErrorCounter, error) {
+	switch kind := cfg.ErrorCounter(); kind {
+	case "":
+		return nullErrorCounter{}, nil
+	case "prometheus":
+		return prometheusErrorCounter{}, nil
+	default:
 		return nil, errors.Errorf("unsupported error counter: %s", kind)
 	}
-
-	return nullErrorCounter{}, nil
 }
@@ -0,0 +1,262 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	c "github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/filestore"
+	"github.com/FakeTwitter/elon/mock"
+)
+
+var endHour = 15 // 3PM
+
+// newFileStore returns a FileStore backed by a file in a new temporary
+// directory, and a function that removes the directory
+func newFileStore(t *testing.T) (filestore.FileStore, func()) {
+	dir, err := ioutil.TempDir("", "filestore")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := filestore.New(filepath.Join(dir, "elon.json"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return s, func() { os.RemoveAll(dir) }
+}
+
+// testSetup returns some values useful for test setup
+func testSetup(t *testing.T) (ins c.employee, loc *time.Location, appCfg c.TeamConfig) {
+
+	ins = mock.employee{
+		Team:        "myapp",
+		Account:    "prod",
+		Stack:      "mystack",
+		Team:    "myteam",
+		Region:     "us-east-1",
+		ASG:        "myapp-mystack-myteam-V123",
+		EmployeeId: "i-a96a0166",
+	}
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	appCfg = c.TeamConfig{
+		Enabled:                        true,
+		RegionsAreIndependent:          true,
+		MeanTimeBetweenFiresInWorkDays: 5,
+		MinTimeBetweenFiresInWorkDays:  1,
+		Grouping:                       c.Team,
+		Exceptions:                     nil,
+	}
+
+	return
+}
+
+// TestCheckForbidden verifies check fails if previous termination is too recent
+func TestCheckForbidden(t *testing.T) {
+	s, cleanup := newFileStore(t)
+	defer cleanup()
+
+	ins, loc, appCfg := testSetup(t)
+
+	trm := c.Termination{employee: ins, Time: time.Now(), Leashed: false}
+
+	// First check should succeed
+	err := s.Check(trm, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Second check should fail
+	err = s.Check(trm, appCfg, endHour, loc)
+	if _, ok := err.(c.ErrViolatesMinTime); !ok {
+		t.Fatalf("Expected Err.ViolatesMinTime, got %v", err)
+	}
+}
+
+// When we are going to commit an unleashed termination, we only care
+// about unleashed previous terminations
+func TestCheckLeashed(t *testing.T) {
+	s, cleanup := newFileStore(t)
+	defer cleanup()
+
+	ins, loc, appCfg := testSetup(t)
+
+	err := s.Check(c.Termination{employee: ins, Time: time.Now(), Leashed: true}, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Check(c.Termination{employee: ins, Time: time.Now(), Leashed: false}, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatalf("Should have allowed an unleashed termination after leashed: %v", err)
+	}
+}
+
+// Check that only one termination is permitted on concurrent attempts
+func TestConcurrentChecks(t *testing.T) {
+	s, cleanup := newFileStore(t)
+	defer cleanup()
+
+	ins, loc, appCfg := testSetup(t)
+
+	trm := c.Termination{employee: ins, Time: time.Now()}
+
+	n := 10
+	ch := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			ch <- s.Check(trm, appCfg, endHour, loc)
+		}()
+	}
+
+	var success int
+	for i := 0; i < n; i++ {
+		err := <-ch
+		switch err.(type) {
+		case nil:
+			success++
+		case c.ErrViolatesMinTime:
+		default:
+			t.Fatalf("Unexpected error: %+v", err)
+		}
+	}
+
+	if got, want := success, 1; got != want {
+		t.Errorf("got %d succeses, want: %d", got, want)
+	}
+}
+
+func TestCheckMinTimeEnforced(t *testing.T) {
+
+	cfg := c.TeamConfig{
+		Enabled:                        true,
+		RegionsAreIndependent:          true,
+		MeanTimeBetweenFiresInWorkDays: 5,
+		MinTimeBetweenFiresInWorkDays:  2,
+		Grouping:                       c.Team,
+	}
+
+	// The current fire time
+	now := "Thu Dec 17 11:35:00 2015 -0800"
+
+	// Since MinTimeBetweenFiresInWorkDays is 2 here, then the most recent
+	// fire permitted is Tue Dec 15 15:00:00 2015 -0800
+
+	// this is a magic date used by go for parsing strings
+	refDate := "Mon Jan  2 15:04:05 2006 -0700"
+	tnow, err := time.Parse(refDate, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ins, loc, _ := testSetup(t)
+
+	tests := []struct {
+		last    string
+		allowed bool
+	}{
+		{"Tue Dec 15 15:01:00 2015 -0800", false},
+		{"Tue Dec 15 14:59:59 2015 -0800", true},
+	}
+
+	for _, tt := range tests {
+		func() {
+			s, cleanup := newFileStore(t)
+			defer cleanup()
+
+			last, err := time.Parse(refDate, tt.last)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.Check(c.Termination{employee: ins, Time: last}, cfg, endHour, loc)
+			if err != nil {
+				t.Fatalf("Failed to write the initial termination, should always succeed: %v", err)
+			}
+
+			err = s.Check(c.Termination{employee: ins, Time: tnow}, cfg, endHour, loc)
+
+			switch err.(type) {
+			case nil:
+				if !tt.allowed {
+					t.Errorf("%s termination should have been forbidden, was allowed", tt.last)
+				}
+			case c.ErrViolatesMinTime:
+				if tt.allowed {
+					t.Errorf("%s termination should have been allowed, got: %v", tt.last, err)
+				}
+			default:
+				t.Errorf("%s termination returned unexpected err: %v", tt.last, err)
+			}
+		}()
+	}
+}
+
+// TestCompleteFailedDoesNotCount verifies that a failed termination doesn't
+// count toward the min time between terminations, but a succeeded one does
+func TestCompleteFailedDoesNotCount(t *testing.T) {
+	s, cleanup := newFileStore(t)
+	defer cleanup()
+
+	var err error
+
+	ins, loc, appCfg := testSetup(t)
+
+	trm := c.Termination{employee: ins, Time: time.Now(), Leashed: false}
+
+	err = s.Check(trm, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Complete(trm, c.Failed, "", "POST failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed termination was completed already
+	err = s.Complete(trm, c.Succeeded, "", "")
+	if err == nil {
+		t.Error("Complete() succeeded on a termination that isn't pending")
+	}
+
+	trm = c.Termination{employee: ins, Time: time.Now(), Leashed: false}
+
+	err = s.Check(trm, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatalf("Should have allowed a termination after a failed one: %v", err)
+	}
+
+	err = s.Complete(trm, c.Succeeded, "/tasks/01", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Check(c.Termination{employee: ins, Time: time.Now(), Leashed: false}, appCfg, endHour, loc)
+	if _, ok := err.(c.ErrViolatesMinTime); !ok {
+		t.Fatalf("Expected Err.ViolatesMinTime, got %v", err)
+	}
+}
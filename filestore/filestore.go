@@ -0,0 +1,431 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filestore implements a store for schedules and terminations that is
+// backed by a single file on local disk. It is intended for single-node
+// deployments that don't want to operate a database server.
+package filestore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/cal"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/schedstore"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// FileStore represents a file-backed store for schedules and terminations
+//
+// Every process that shares the file (e.g., the cron-driven "terminate"
+// invocations) takes an exclusive lock on a sibling ".lock" file before
+// modifying it, so that checking and recording a termination is atomic.
+type FileStore struct {
+	path string
+
+	// calendars determine the work days used for min time between fires
+	calendars cal.Regional
+}
+
+// state is the content of the file
+type state struct {
+	Schedules    map[string][]entry `json:"schedules"`
+	Seeds        map[string]int64   `json:"seeds"`
+	Terminations []termination      `json:"terminations"`
+}
+
+// entry is a scheduled termination
+type entry struct {
+	Time    time.Time `json:"time"`
+	App     string    `json:"app"`
+	Account string    `json:"account"`
+	Region  string    `json:"region"`
+	Stack   string    `json:"stack"`
+	Team    string    `json:"team"`
+}
+
+// termination is a record of a termination that was permitted by the checker
+type termination struct {
+	App        string    `json:"app"`
+	Account    string    `json:"account"`
+	Stack      string    `json:"stack"`
+	Team       string    `json:"team"`
+	Region     string    `json:"region"`
+	ASG        string    `json:"asg"`
+	EmployeeID string    `json:"employee_id"`
+	FiredAt    time.Time `json:"fired_at"`
+	Leashed    bool      `json:"leashed"`
+
+	// Status is the outcome of the termination. It is blank for terminations
+	// recorded before outcomes were, which are treated as pending
+	Status       elon.Outcome `json:"status"`
+	ErrorMessage string       `json:"error_message"`
+	TaskRef      string       `json:"task_ref"`
+}
+
+// NewFromConfig creates a new FileStore taking config parameters from cfg
+func NewFromConfig(cfg *config.Monkey) (FileStore, error) {
+	if cfg.DatabasePath() == "" {
+		return FileStore{}, errors.Errorf("%s not specified", param.DatabasePath)
+	}
+
+	calendars, err := cal.NewFromConfig(cfg)
+	if err != nil {
+		return FileStore{}, err
+	}
+
+	s, err := New(cfg.DatabasePath())
+	if err != nil {
+		return FileStore{}, err
+	}
+
+	s.calendars = calendars
+	return s, nil
+}
+
+// New creates a new FileStore that stores its state in the file at path,
+// using the default calendar of work days
+func New(path string) (FileStore, error) {
+	dir := filepath.Dir(path)
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return FileStore{}, errors.Wrapf(err, "cannot access directory %s", dir)
+	}
+
+	if !fi.IsDir() {
+		return FileStore{}, errors.Errorf("%s is not a directory", dir)
+	}
+
+	return FileStore{path: path}, nil
+}
+
+// Close is a no-op, it exists to mirror the SQL-backed stores
+func (s FileStore) Close() error {
+	return nil
+}
+
+// dateKey returns the key used to store the schedule for the given date.
+// The year/month/day are taken from date's own location, like the DATE
+// column of the SQL-backed stores.
+func dateKey(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+// Retrieve retrieves the schedule for the given date
+// The seed of the schedule is zero if none was recorded.
+func (s FileStore) Retrieve(date time.Time) (*schedule.Schedule, error) {
+	st, err := s.load()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve schedule for %s", date)
+	}
+
+	sched := schedule.NewWithSeed(st.Seeds[dateKey(date)])
+	for _, e := range st.Schedules[dateKey(date)] {
+		sched.Add(e.Time.UTC(), grp.New(e.App, e.Account, e.Region, e.Stack, e.Team))
+	}
+
+	return sched, nil
+}
+
+// Publish publishes the schedule for the given date
+func (s FileStore) Publish(date time.Time, sched *schedule.Schedule) error {
+	return s.update(func(st *state) error {
+		key := dateKey(date)
+		if len(st.Schedules[key]) > 0 {
+			return schedstore.ErrAlreadyExists
+		}
+
+		var entries []entry
+		for _, e := range sched.Entries() {
+			var region, stack, team string
+			if val, ok := e.Group.Region(); ok {
+				region = val
+			}
+			if val, ok := e.Group.Stack(); ok {
+				stack = val
+			}
+			if val, ok := e.Group.Team(); ok {
+				team = val
+			}
+
+			entries = append(entries, entry{
+				Time:    e.Time.In(time.UTC),
+				App:     e.Group.Team(),
+				Account: e.Group.Account(),
+				Region:  region,
+				Stack:   stack,
+				Team:    team,
+			})
+		}
+
+		st.Schedules[key] = entries
+		st.Seeds[key] = sched.Seed()
+		return nil
+	})
+}
+
+// Check checks if a termination is permitted and, if so, records the
+// termination time on the team
+func (s FileStore) Check(term elon.Termination, appCfg elon.TeamConfig, endHour int, loc *time.Location) error {
+	return s.update(func(st *state) error {
+		err := respectsMinTimeBetweenFires(st, s.calendars.ForRegion(term.employee.RegionName()), term.Time, term, appCfg, endHour, loc)
+		if err != nil {
+			return err
+		}
+
+		i := term.employee
+		st.Terminations = append(st.Terminations, termination{
+			App:        i.TeamName(),
+			Account:    i.AccountName(),
+			Stack:      i.StackName(),
+			Team:       i.TeamName(),
+			Region:     i.RegionName(),
+			ASG:        i.ASGName(),
+			EmployeeID: i.ID(),
+			FiredAt:    term.Time.In(time.UTC),
+			Leashed:    term.Leashed,
+			Status:     elon.Pending,
+		})
+		return nil
+	})
+}
+
+// Complete records the outcome of a termination that was permitted by Check
+func (s FileStore) Complete(term elon.Termination, outcome elon.Outcome, ref string, message string) error {
+	return s.update(func(st *state) error {
+		for i := range st.Terminations {
+			t := &st.Terminations[i]
+			if t.EmployeeID != term.employee.ID() || !t.FiredAt.Equal(term.Time) || !t.pending() {
+				continue
+			}
+
+			t.Status = outcome
+			t.TaskRef = ref
+			t.ErrorMessage = message
+			return nil
+		}
+
+		return errors.Errorf("no pending termination of %s at %s", term.employee.ID(), term.Time)
+	})
+}
+
+// pending returns true if the outcome of t hasn't been recorded
+func (t termination) pending() bool {
+	return t.Status == elon.Pending || t.Status == ""
+}
+
+// respectsMinTimeBetweenFires checks if this termination will respect or
+// violate the min time between fires value. If this termination is too close
+// to the most recent one, this will return an error.
+// If this termination would violate the min time, returns an ErrViolatesMinTime
+func respectsMinTimeBetweenFires(st *state, c cal.Calendar, now time.Time, term elon.Termination, appCfg elon.TeamConfig, endHour int, loc *time.Location) error {
+	threshold, err := cal.NoFiresSince(c, appCfg.MinTimeBetweenFiresInWorkDays, now, endHour, loc)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range st.Terminations {
+		if t.FiredAt.Before(threshold) {
+			continue
+		}
+
+		same, err := sameGroup(t, term, appCfg)
+		if err != nil {
+			return err
+		}
+
+		if same {
+			return elon.ErrViolatesMinTime{EmployeeId: t.EmployeeID, FiredAt: t.FiredAt.UTC(), Loc: loc}
+		}
+	}
+
+	return nil
+}
+
+// sameGroup returns true if the previous termination t counts against term
+// for the purposes of the min time between fires
+func sameGroup(t termination, term elon.Termination, appCfg elon.TeamConfig) (bool, error) {
+	i := term.employee
+
+	if t.App != i.TeamName() || t.Account != i.AccountName() {
+		return false, nil
+	}
+
+	switch appCfg.Grouping {
+	case elon.Team:
+		// nothing to do
+	case elon.Stack:
+		if t.Stack != i.StackName() {
+			return false, nil
+		}
+	case elon.Team:
+		if t.Team != i.TeamName() {
+			return false, nil
+		}
+	default:
+		return false, errors.Errorf("unknown group: %v", appCfg.Grouping)
+	}
+
+	if appCfg.RegionsAreIndependent && t.Region != i.RegionName() {
+		return false, nil
+	}
+
+	// For unleashed (real) terminations, we only care about previous
+	// terminations that were also unleashed. That's because a previous
+	// leashed termination wasn't a real one, so that wouldn't violate
+	// the min time between terminations
+	if !term.Leashed && t.Leashed {
+		return false, nil
+	}
+
+	// Failed terminations didn't fire anyone
+	if t.Status == elon.Failed {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// update atomically applies fn to the state stored in the file.
+// If fn returns an error, the file is left unchanged.
+func (s FileStore) update(fn func(st *state) error) (err error) {
+	lock, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open lock file")
+	}
+	defer lock.Close()
+
+	err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		return errors.Wrap(err, "failed to lock file")
+	}
+
+	defer func() {
+		uerr := syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+		if err == nil && uerr != nil {
+			err = errors.Wrap(uerr, "failed to unlock file")
+		}
+	}()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	err = fn(st)
+	if err != nil {
+		return err
+	}
+
+	return s.save(st)
+}
+
+// load reads the state from the file. A missing file is an empty state.
+func (s FileStore) load() (*state, error) {
+	st := &state{Schedules: make(map[string][]entry), Seeds: make(map[string]int64)}
+
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", s.path)
+	}
+
+	err = json.Unmarshal(data, st)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s", s.path)
+	}
+
+	if st.Schedules == nil {
+		st.Schedules = make(map[string][]entry)
+	}
+
+	if st.Seeds == nil {
+		st.Seeds = make(map[string]int64)
+	}
+
+	return st, nil
+}
+
+// save writes the state to the file. It writes to a temporary file first and
+// renames it so that readers never observe a partially written file.
+func (s FileStore) save(st *state) (err error) {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode state")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", tmp.Name())
+	}
+
+	err = os.Rename(tmp.Name(), s.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rename %s to %s", tmp.Name(), s.path)
+	}
+
+	return nil
+}
+
+// Migrate creates the file if it doesn't exist yet. There are no schema
+// versions to upgrade.
+func Migrate(s FileStore) error {
+	_, err := os.Stat(s.path)
+	if err == nil {
+		log.Printf("%s already exists, nothing to migrate", s.path)
+		return nil
+	}
+
+	if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "cannot access %s", s.path)
+	}
+
+	err = s.update(func(st *state) error { return nil })
+	if err != nil {
+		return errors.Wrap(err, "failed to create file")
+	}
+	log.Printf("Successfully created %s", s.path)
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/schedstore"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+func TestPublishRetrieveMultipleEntries(t *testing.T) {
+	s, cleanup := newFileStore(t)
+	defer cleanup()
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	psched := schedule.NewWithSeed(42)
+
+	pEntries := []schedule.Entry{
+		{Time: time.Date(2016, time.June, 20, 11, 40, 0, 0, loc), Group: grp.New("doesnotexist", "test", "us-east-1", "", "doesnotexist-foo-bar")},
+		{Time: time.Date(2016, time.June, 20, 12, 35, 0, 0, loc), Group: grp.New("foobar", "other", "us-west-2", "", "foobar-baz-quux")},
+		{Time: time.Date(2016, time.June, 20, 9, 7, 0, 0, loc), Group: grp.New("chaosguineapig", "prod", "us-east-1", "", "chaosguineapig-prod")},
+	}
+
+	for _, v := range pEntries {
+		psched.Add(v.Time, v.Group)
+	}
+
+	date := time.Date(2016, time.June, 20, 0, 0, 0, 0, loc)
+
+	err = s.Publish(date, psched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsched, err := s.Retrieve(date)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rEntries := rsched.Entries()
+	if got, want := len(rEntries), len(pEntries); got != want {
+		t.Fatalf("got len(entries)=%d, want %d", got, want)
+	}
+
+	for i := range pEntries {
+		if got, want := rEntries[i], pEntries[i]; !got.Equal(&want) {
+			t.Errorf("got entry[%d]=%v, want %v", i, got, want)
+		}
+	}
+
+	if got, want := rsched.Seed(), int64(42); got != want {
+		t.Errorf("got rsched.Seed()=%d, want %d", got, want)
+	}
+
+	// Nothing was published for the next day
+	rsched, err = s.Retrieve(date.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(rsched.Entries()), 0; got != want {
+		t.Errorf("got len(entries)=%d, want %d", got, want)
+	}
+}
+
+func TestScheduleAlreadyExists(t *testing.T) {
+	s, cleanup := newFileStore(t)
+	defer cleanup()
+
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2016, time.June, 20, 0, 0, 0, 0, loc)
+
+	psched1 := schedule.New()
+	psched1.Add(time.Date(2016, time.June, 20, 11, 40, 0, 0, loc), grp.New("imaginaryproject", "test", "us-west-2", "", ""))
+
+	err = s.Publish(date, psched1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	psched2 := schedule.New()
+	psched2.Add(time.Date(2016, time.June, 20, 12, 35, 0, 0, loc), grp.New("foobar", "other", "us-west-2", "", "foobar-baz-quux"))
+
+	err = s.Publish(date, psched2)
+	if got, want := err, schedstore.ErrAlreadyExists; got != want {
+		t.Fatalf(`got s.Publish()="%v" want "%v"`, got, want)
+	}
+}
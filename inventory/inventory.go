@@ -0,0 +1,316 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inventory implements a backend that reads the fleet from a static
+// inventory file instead of Sysbreaker, for air-gapped and test environments,
+// and for fleets that are managed by other tools.
+//
+// The inventory is a YAML or JSON file that lists the apps, with their Elon
+// attributes in the same shape as Sysbreaker apps, and their employees by
+// account, team, region and ASG, like deploy.TeamMap. Example:
+//
+//	apps:
+//	  foo:
+//	    attributes:
+//	      elon:
+//	        enabled: true
+//	        meanTimeBetweenFiresInWorkDays: 5
+//	        minTimeBetweenFiresInWorkDays: 1
+//	        grouping: team
+//	        exceptions: []
+//	    accounts:
+//	      prod:
+//	        cloudProvider: aws
+//	        teams:
+//	          foo-web:
+//	            us-east-1:
+//	              foo-web-v001: [i-0a1b2c3d, i-4e5f6a7b]
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	D "github.com/FakeTwitter/elon/deploy"
+	"github.com/FakeTwitter/elon/sysbreaker"
+)
+
+// Inventory implements the deploy.Deployment and elon.TeamConfigGetter
+// interfaces from an inventory file, and the elon.Terminator interface by
+// running a local command
+type Inventory struct {
+	apps map[string]app
+
+	// command is the shell command that terminates an employee. If blank,
+	// terminations are only logged
+	command string
+}
+
+// app is an app of the inventory
+type app struct {
+	Attributes map[string]json.RawMessage `json:"attributes"`
+	Accounts   map[string]account         `json:"accounts"`
+}
+
+// account is the deployment of an app in an account
+type account struct {
+	CloudProvider string                                    `json:"cloudProvider"`
+	Teams         map[string]map[string]map[string][]string `json:"teams"`
+}
+
+// NewFromConfig returns an Inventory based on config
+func NewFromConfig(cfg *config.Monkey) (Inventory, error) {
+	path := cfg.InventoryPath()
+	if path == "" {
+		return Inventory{}, errors.New("no inventory path specified in config")
+	}
+
+	return Load(path, cfg.InventoryCommand())
+}
+
+// Load returns an Inventory read from the YAML or JSON file at path, which
+// terminates employees by running command. The file is read once.
+func Load(path, command string) (Inventory, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Inventory{}, errors.Wrapf(err, "failed to read inventory %s", path)
+	}
+
+	inv, err := parse(data, command)
+	if err != nil {
+		return Inventory{}, errors.Wrapf(err, "invalid inventory %s", path)
+	}
+
+	return inv, nil
+}
+
+// parse returns the Inventory of the YAML or JSON document data
+func parse(data []byte, command string) (Inventory, error) {
+	// JSON is YAML, so both are parsed as YAML, and then converted to JSON so
+	// that the attributes can be parsed like Sysbreaker's
+	var doc interface{}
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return Inventory{}, err
+	}
+
+	js, err := json.Marshal(jsonValue(doc))
+	if err != nil {
+		return Inventory{}, err
+	}
+
+	var parsed struct {
+		Apps map[string]app `json:"apps"`
+	}
+
+	err = json.Unmarshal(js, &parsed)
+	if err != nil {
+		return Inventory{}, err
+	}
+
+	return Inventory{apps: parsed.Apps, command: command}, nil
+}
+
+// jsonValue converts a value parsed from YAML to one that can be marshaled
+// to JSON, i.e., with string keys in maps
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = jsonValue(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = jsonValue(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// Get implements elon.TeamConfigGetter.Get
+func (inv Inventory) Get(appName string) (*elon.TeamConfig, error) {
+	a, ok := inv.apps[appName]
+	if !ok {
+		return nil, errors.Errorf("app=%s is not in the inventory", appName)
+	}
+
+	js, err := json.Marshal(map[string]interface{}{"name": appName, "attributes": a.Attributes})
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid attributes for app=%s", appName)
+	}
+
+	return sysbreaker.FromJSON(js)
+}
+
+// Owner implements elon.OwnerGetter.Owner, the owner is the "email"
+// attribute of the app
+func (inv Inventory) Owner(appName string) (string, error) {
+	a, ok := inv.apps[appName]
+	if !ok {
+		return "", errors.Errorf("app=%s is not in the inventory", appName)
+	}
+
+	raw, ok := a.Attributes["email"]
+	if !ok {
+		return "", nil
+	}
+
+	var email string
+	err := json.Unmarshal(raw, &email)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid email attribute for app=%s", appName)
+	}
+
+	return email, nil
+}
+
+// Teams implements deploy.Deployment.Teams
+func (inv Inventory) Teams(c chan<- *D.Team, appNames []string) {
+	// Close the channel we're done
+	defer close(c)
+
+	for _, appName := range appNames {
+		app, err := inv.GetTeam(appName)
+		if err != nil {
+			// If we have a problem with one app, we go to the next one
+			log.Printf("WARNING: GetTeam failed for %s: %v", appName, err)
+			continue
+		}
+
+		c <- app
+	}
+}
+
+// GetTeam implements deploy.Deployment.GetTeam
+func (inv Inventory) GetTeam(appName string) (*D.Team, error) {
+	a, ok := inv.apps[appName]
+	if !ok {
+		return nil, errors.Errorf("app=%s is not in the inventory", appName)
+	}
+
+	// data arg is a map like {accountName: {teamName: {regionName: {asgName: [EmployeeId]}}}}
+	data := make(D.TeamMap)
+	for accountName, acc := range a.Accounts {
+		teams := make(D.TeamMap)
+		for teamName, regions := range acc.Teams {
+			teams[D.TeamName(teamName)] = make(map[D.RegionName]map[D.ASGName][]D.EmployeeId)
+			for region, asgs := range regions {
+				teams[D.TeamName(teamName)][D.RegionName(region)] = make(map[D.ASGName][]D.EmployeeId)
+				for asg, ids := range asgs {
+					teams[D.TeamName(teamName)][D.RegionName(region)][D.ASGName(asg)] = employeeIds(ids)
+				}
+			}
+		}
+
+		data[D.AccountName(accountName)] = D.AccountInfo{CloudProvider: acc.CloudProvider, Teams: teams}
+	}
+
+	return D.NewTeam(appName, data), nil
+}
+
+// TeamNames returns the names of the apps of the inventory
+func (inv Inventory) TeamNames() ([]string, error) {
+	result := make([]string, 0, len(inv.apps))
+	for name := range inv.apps {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// GetEmployeeIds returns the employees of a team in a region. If the team has
+// several ASGs in the region, the last one in lexical order is used, i.e.,
+// the most recent push.
+func (inv Inventory) GetEmployeeIds(appName string, account D.AccountName, cloudProvider string, region D.RegionName, team D.TeamName) (D.ASGName, []D.EmployeeId, error) {
+	asgs := inv.apps[appName].Accounts[string(account)].Teams[string(team)][string(region)]
+	if len(asgs) == 0 {
+		return "", nil, errors.Errorf("no ASG in the inventory for app=%s account=%s team=%s region=%s", appName, account, team, region)
+	}
+
+	var names []string
+	for name := range asgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	asg := names[len(names)-1]
+	return D.ASGName(asg), employeeIds(asgs[asg]), nil
+}
+
+// GetTeamNames returns the names of the teams of an app in an account
+func (inv Inventory) GetTeamNames(appName string, account D.AccountName) ([]D.TeamName, error) {
+	var names []string
+	for name := range inv.apps[appName].Accounts[string(account)].Teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]D.TeamName, len(names))
+	for i, name := range names {
+		result[i] = D.TeamName(name)
+	}
+
+	return result, nil
+}
+
+// GetRegionNames returns the regions of a team
+func (inv Inventory) GetRegionNames(appName string, account D.AccountName, team D.TeamName) ([]D.RegionName, error) {
+	var names []string
+	for name := range inv.apps[appName].Accounts[string(account)].Teams[string(team)] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]D.RegionName, len(names))
+	for i, name := range names {
+		result[i] = D.RegionName(name)
+	}
+
+	return result, nil
+}
+
+// CloudProvider returns the cloud provider of an account, as found in the
+// first app that is deployed in it
+func (inv Inventory) CloudProvider(account string) (string, error) {
+	apps, _ := inv.TeamNames()
+	for _, name := range apps {
+		if acc, ok := inv.apps[name].Accounts[account]; ok {
+			return acc.CloudProvider, nil
+		}
+	}
+
+	return "", errors.Errorf("account %s is not in the inventory", account)
+}
+
+// employeeIds converts ids to employee ids
+func employeeIds(ids []string) []D.EmployeeId {
+	result := make([]D.EmployeeId, len(ids))
+	for i, id := range ids {
+		result[i] = D.EmployeeId(id)
+	}
+	return result
+}
@@ -0,0 +1,318 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon"
+	D "github.com/FakeTwitter/elon/deploy"
+	"github.com/FakeTwitter/elon/mock"
+)
+
+const yamlInventory = `
+apps:
+  foo:
+    attributes:
+      email: foo-team@example.com
+      elon:
+        enabled: true
+        meanTimeBetweenFiresInWorkDays: 3
+        minTimeBetweenFiresInWorkDays: 1
+        grouping: team
+        exceptions: []
+    accounts:
+      prod:
+        cloudProvider: aws
+        teams:
+          foo-web:
+            us-east-1:
+              foo-web-v001: [i-1, i-2]
+              foo-web-v002: [i-3]
+            us-west-2:
+              foo-web-v001: [i-4]
+          foo-db:
+            us-east-1:
+              foo-db-v000: [i-5]
+  bar:
+    accounts:
+      test:
+        cloudProvider: kubernetes
+        teams:
+          bar:
+            default:
+              bar: [bar-0]
+`
+
+const jsonInventory = `{
+  "apps": {
+    "foo": {
+      "attributes": {"elon": {"enabled": true, "meanTimeBetweenFiresInWorkDays": 3, "minTimeBetweenFiresInWorkDays": 1, "grouping": "team", "exceptions": []}},
+      "accounts": {"prod": {"cloudProvider": "aws", "teams": {"foo-web": {"us-east-1": {"foo-web-v001": ["i-1"]}}}}}
+    }
+  }
+}`
+
+// testInventory parses contents
+func testInventory(t *testing.T, contents string, command string) Inventory {
+	inv, err := parse([]byte(contents), command)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return inv
+}
+
+func TestTeamNames(t *testing.T) {
+	inv := testInventory(t, yamlInventory, "")
+
+	names, err := inv.TeamNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := names, []string{"bar", "foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got names=%v, want %v", got, want)
+	}
+}
+
+func TestGetTeam(t *testing.T) {
+	inv := testInventory(t, yamlInventory, "")
+
+	app, err := inv.GetTeam("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(app.Accounts()), 1; got != want {
+		t.Fatalf("got len(app.Accounts())=%d, want %d", got, want)
+	}
+
+	account := app.Accounts()[0]
+	if got, want := account.CloudProvider(), "aws"; got != want {
+		t.Errorf("got account.CloudProvider()=%s, want %s", got, want)
+	}
+
+	if got, want := len(account.Teams()), 2; got != want {
+		t.Errorf("got len(account.Teams())=%d, want %d", got, want)
+	}
+
+	_, err = inv.GetTeam("baz")
+	if err == nil {
+		t.Error("Expected an error for an app that isn't in the inventory")
+	}
+}
+
+func TestGetEmployeeIds(t *testing.T) {
+	inv := testInventory(t, yamlInventory, "")
+
+	// The most recent ASG is used
+	asg, ids, err := inv.GetEmployeeIds("foo", "prod", "aws", "us-east-1", "foo-web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := asg, D.ASGName("foo-web-v002"); got != want {
+		t.Errorf("got asg=%s, want %s", got, want)
+	}
+
+	if got, want := ids, []D.EmployeeId{"i-3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got ids=%v, want %v", got, want)
+	}
+
+	_, _, err = inv.GetEmployeeIds("foo", "prod", "aws", "eu-west-1", "foo-web")
+	if err == nil {
+		t.Error("Expected an error for a region without ASGs")
+	}
+}
+
+func TestGetTeamNamesAndRegionNames(t *testing.T) {
+	inv := testInventory(t, yamlInventory, "")
+
+	teams, err := inv.GetTeamNames("foo", "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := teams, []D.TeamName{"foo-db", "foo-web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got teams=%v, want %v", got, want)
+	}
+
+	regions, err := inv.GetRegionNames("foo", "prod", "foo-web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := regions, []D.RegionName{"us-east-1", "us-west-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got regions=%v, want %v", got, want)
+	}
+}
+
+func TestCloudProvider(t *testing.T) {
+	inv := testInventory(t, yamlInventory, "")
+
+	provider, err := inv.CloudProvider("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := provider, "kubernetes"; got != want {
+		t.Errorf("got provider=%s, want %s", got, want)
+	}
+
+	_, err = inv.CloudProvider("staging")
+	if err == nil {
+		t.Error("Expected an error for an account that isn't in the inventory")
+	}
+}
+
+func TestGet(t *testing.T) {
+	for _, contents := range []string{yamlInventory, jsonInventory} {
+		inv := testInventory(t, contents, "")
+
+		cfg, err := inv.Get("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := cfg.MeanTimeBetweenFiresInWorkDays, 3; got != want {
+			t.Errorf("got MeanTimeBetweenFiresInWorkDays=%d, want %d", got, want)
+		}
+	}
+
+	// bar has no Elon attributes
+	inv := testInventory(t, yamlInventory, "")
+	_, err := inv.Get("bar")
+	if err == nil {
+		t.Error("Expected an error for an app without config")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "inventory.yml")
+	err = ioutil.WriteFile(path, []byte(yamlInventory), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := Load(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(inv.apps), 2; got != want {
+		t.Errorf("got len(inv.apps)=%d, want %d", got, want)
+	}
+
+	_, err = Load(filepath.Join(dir, "missing.yml"), "")
+	if err == nil {
+		t.Error("Expected an error for a missing inventory")
+	}
+
+	_, err = parse([]byte("apps: [foo"), "")
+	if err == nil {
+		t.Error("Expected an error for an invalid inventory")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "out")
+	inv := testInventory(t, yamlInventory, `echo "$ELON_ACCOUNT $ELON_REGION $ELON_EMPLOYEE_ID" > `+out)
+
+	ins := mock.employee{Team: "foo", Account: "prod", Region: "us-east-1", EmployeeId: "i-3"}
+	err = inv.Execute(elon.Termination{employee: ins, Time: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := strings.TrimSpace(string(data)), "prod us-east-1 i-3"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExecuteFailure(t *testing.T) {
+	inv := testInventory(t, yamlInventory, "echo no such employee; exit 1")
+
+	ins := mock.employee{Team: "foo", Account: "prod", Region: "us-east-1", EmployeeId: "i-3"}
+	err := inv.Execute(elon.Termination{employee: ins, Time: time.Now()})
+	if err == nil {
+		t.Fatal("Expected an error when the command fails")
+	}
+
+	if !strings.Contains(err.Error(), "no such employee") {
+		t.Errorf("got err=%v, want it to include the output of the command", err)
+	}
+}
+
+func TestExecuteWithoutCommand(t *testing.T) {
+	inv := testInventory(t, yamlInventory, "")
+
+	ins := mock.employee{Team: "foo", Account: "prod", Region: "us-east-1", EmployeeId: "i-3"}
+	err := inv.Execute(elon.Termination{employee: ins, Time: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOwner(t *testing.T) {
+	inv := testInventory(t, yamlInventory, "")
+
+	tests := []struct {
+		app  string
+		want string
+	}{
+		{"foo", "foo-team@example.com"},
+		{"bar", ""},
+	}
+
+	for _, tt := range tests {
+		owner, err := inv.Owner(tt.app)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := owner; got != tt.want {
+			t.Errorf("app=%s: got Owner()=%s, want %s", tt.app, got, tt.want)
+		}
+	}
+
+	_, err := inv.Owner("baz")
+	if err == nil {
+		t.Error("Expected an error for an app that isn't in the inventory")
+	}
+}
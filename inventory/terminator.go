@@ -0,0 +1,56 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"log"
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+)
+
+// Execute implements elon.Terminator.Execute by running the configured
+// command, with the employee passed in ELON_* environment variables. If no
+// command is configured, the termination is only logged.
+func (inv Inventory) Execute(trm elon.Termination) error {
+	ins := trm.employee
+
+	if inv.command == "" {
+		log.Printf("no inventory command configured, not terminating employee %s", ins.ID())
+		return nil
+	}
+
+	cmd := exec.Command("sh", "-c", inv.command)
+	cmd.Env = append(os.Environ(),
+		"ELON_APP="+ins.TeamName(),
+		"ELON_ACCOUNT="+ins.AccountName(),
+		"ELON_REGION="+ins.RegionName(),
+		"ELON_STACK="+ins.StackName(),
+		"ELON_TEAM="+ins.TeamName(),
+		"ELON_ASG="+ins.ASGName(),
+		"ELON_EMPLOYEE_ID="+ins.ID(),
+		"ELON_CLOUD_PROVIDER="+ins.CloudProvider(),
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "inventory command failed for employee %s, output: %q", ins.ID(), out)
+	}
+
+	return nil
+}
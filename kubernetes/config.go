@@ -0,0 +1,81 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build kubernetes
+
+package kubernetes
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/sysbreaker"
+)
+
+// ConfigAnnotation is the annotation of workloads that contains the Elon
+// config of their app, in the same format as the "elon" attribute of
+// Sysbreaker apps. Example:
+//
+//	metadata:
+//	  annotations:
+//	    elon.faketwitter.com/config: |
+//	      {"enabled": true, "meanTimeBetweenFiresInWorkDays": 5, "minTimeBetweenFiresInWorkDays": 1, "grouping": "team", "exceptions": []}
+//
+// Every workload of an app that has the annotation must have the same one.
+const ConfigAnnotation = "elon.faketwitter.com/config"
+
+// Get implements elon.TeamConfigGetter.Get. The config of an app is read
+// from the ConfigAnnotation of its workloads.
+func (k Kubernetes) Get(app string) (*elon.TeamConfig, error) {
+	workloads, err := k.appWorkloads(app)
+	if err != nil {
+		return nil, err
+	}
+
+	var value string
+	var from workload
+	for _, w := range workloads {
+		v, ok := w.annotations[ConfigAnnotation]
+		if !ok {
+			continue
+		}
+
+		if value != "" && v != value {
+			return nil, errors.Errorf("conflicting %s annotations for app=%s on %s/%s and %s/%s", ConfigAnnotation, app, from.namespace, from.name, w.namespace, w.name)
+		}
+
+		value, from = v, w
+	}
+
+	if value == "" {
+		return nil, errors.Errorf("no workload of app=%s has a %s annotation", app, ConfigAnnotation)
+	}
+
+	js, err := json.Marshal(map[string]interface{}{
+		"name":       app,
+		"attributes": map[string]json.RawMessage{"elon": json.RawMessage(value)},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s annotation on %s/%s", ConfigAnnotation, from.namespace, from.name)
+	}
+
+	cfg, err := sysbreaker.FromJSON(js)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s annotation on %s/%s", ConfigAnnotation, from.namespace, from.name)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,382 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build kubernetes
+
+// Package kubernetes provides an interface to the Kubernetes API, for
+// workloads that aren't managed by Sysbreaker.
+//
+// Workloads (Deployments and StatefulSets) are mapped onto the deploy model:
+// the account is the cluster, a region is a namespace, a team is a workload,
+// named app-stack-detail like Sysbreaker teams, the ASG of a team in a
+// region is the workload itself, and an employee is a running pod of the
+// workload.
+package kubernetes
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/SmartThingsOSS/frigga-go"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/FakeTwitter/elon/config"
+	D "github.com/FakeTwitter/elon/deploy"
+)
+
+// cloudProvider is the cloud provider of the employees
+const cloudProvider = "kubernetes"
+
+// Kubernetes implements the deploy.Deployment interface by querying the
+// Kubernetes API, and the elon.Terminator interface by deleting pods
+type Kubernetes struct {
+	client kube.Interface
+
+	// account is the name of the account that stands for the cluster
+	account string
+
+	// namespaces are the namespaces that are examined, all of them if empty
+	namespaces []string
+
+	// gracePeriod is the grace period of pod deletions, in seconds. If nil,
+	// the grace period of the pod is used
+	gracePeriod *int64
+}
+
+// workload is a Deployment or a StatefulSet
+type workload struct {
+	name        string
+	namespace   string
+	selector    *metav1.LabelSelector
+	annotations map[string]string
+}
+
+// NewFromConfig returns a Kubernetes based on config
+func NewFromConfig(cfg *config.Monkey) (Kubernetes, error) {
+	restCfg, err := clientcmd.BuildConfigFromFlags("", cfg.KubernetesKubeconfig())
+	if err != nil {
+		return Kubernetes{}, errors.Wrap(err, "failed to load kubernetes client config")
+	}
+
+	client, err := kube.NewForConfig(restCfg)
+	if err != nil {
+		return Kubernetes{}, errors.Wrap(err, "failed to create kubernetes client")
+	}
+
+	namespaces, err := cfg.KubernetesNamespaces()
+	if err != nil {
+		return Kubernetes{}, err
+	}
+
+	var gracePeriod *int64
+	if seconds := cfg.KubernetesGracePeriodSeconds(); seconds >= 0 {
+		gp := int64(seconds)
+		gracePeriod = &gp
+	}
+
+	return New(client, cfg.KubernetesAccount(), namespaces, gracePeriod), nil
+}
+
+// New returns a Kubernetes that uses client to examine the workloads in
+// namespaces, or in all namespaces if empty. The cluster is known as account.
+func New(client kube.Interface, account string, namespaces []string, gracePeriod *int64) Kubernetes {
+	return Kubernetes{client: client, account: account, namespaces: namespaces, gracePeriod: gracePeriod}
+}
+
+// Teams implements deploy.Deployment.Teams
+func (k Kubernetes) Teams(c chan<- *D.Team, appNames []string) {
+	// Close the channel we're done
+	defer close(c)
+
+	for _, appName := range appNames {
+		app, err := k.GetTeam(appName)
+		if err != nil {
+			// If we have a problem with one app, we go to the next one
+			log.Printf("WARNING: GetTeam failed for %s: %v", appName, err)
+			continue
+		}
+
+		c <- app
+	}
+}
+
+// GetTeam implements deploy.Deployment.GetTeam
+func (k Kubernetes) GetTeam(appName string) (*D.Team, error) {
+	workloads, err := k.appWorkloads(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	// data arg is a map like {accountName: {teamName: {regionName: {asgName: [EmployeeId]}}}}
+	data := make(D.TeamMap)
+	if len(workloads) == 0 {
+		return D.NewTeam(appName, data), nil
+	}
+
+	account := D.AccountName(k.account)
+	data[account] = D.AccountInfo{
+		CloudProvider: cloudProvider,
+		Teams:         make(map[D.TeamName]map[D.RegionName]map[D.ASGName][]D.EmployeeId),
+	}
+
+	for _, w := range workloads {
+		ids, err := k.pods(w)
+		if err != nil {
+			return nil, err
+		}
+
+		teamName := D.TeamName(w.name)
+		if _, present := data[account].Teams[teamName]; !present {
+			data[account].Teams[teamName] = make(map[D.RegionName]map[D.ASGName][]D.EmployeeId)
+		}
+
+		data[account].Teams[teamName][D.RegionName(w.namespace)] = map[D.ASGName][]D.EmployeeId{
+			D.ASGName(w.name): ids,
+		}
+	}
+
+	return D.NewTeam(appName, data), nil
+}
+
+// TeamNames returns the names of the apps of all the workloads
+func (k Kubernetes) TeamNames() ([]string, error) {
+	workloads, err := k.workloads()
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]bool)
+	for _, w := range workloads {
+		app, err := appName(w.name)
+		if err != nil {
+			log.Printf("WARNING: ignoring workload %s/%s: %v", w.namespace, w.name, err)
+			continue
+		}
+		m[app] = true
+	}
+
+	result := make([]string, 0, len(m))
+	for app := range m {
+		result = append(result, app)
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// GetEmployeeIds returns the names of the running pods of the workload named
+// team in the namespace region. The ASG name is the name of the workload.
+func (k Kubernetes) GetEmployeeIds(app string, account D.AccountName, cloudProvider string, region D.RegionName, team D.TeamName) (D.ASGName, []D.EmployeeId, error) {
+	err := k.checkAccount(string(account))
+	if err != nil {
+		return "", nil, err
+	}
+
+	w, err := k.workload(string(region), string(team))
+	if err != nil {
+		return "", nil, err
+	}
+
+	ids, err := k.pods(w)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return D.ASGName(w.name), ids, nil
+}
+
+// GetTeamNames returns the names of the workloads of an app
+func (k Kubernetes) GetTeamNames(app string, account D.AccountName) ([]D.TeamName, error) {
+	err := k.checkAccount(string(account))
+	if err != nil {
+		return nil, err
+	}
+
+	workloads, err := k.appWorkloads(app)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[D.TeamName]bool)
+	result := []D.TeamName{}
+	for _, w := range workloads {
+		name := D.TeamName(w.name)
+		if !m[name] {
+			m[name] = true
+			result = append(result, name)
+		}
+	}
+
+	return result, nil
+}
+
+// GetRegionNames returns the namespaces of the workloads named team
+func (k Kubernetes) GetRegionNames(app string, account D.AccountName, team D.TeamName) ([]D.RegionName, error) {
+	err := k.checkAccount(string(account))
+	if err != nil {
+		return nil, err
+	}
+
+	workloads, err := k.appWorkloads(app)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []D.RegionName{}
+	for _, w := range workloads {
+		if w.name == string(team) {
+			result = append(result, D.RegionName(w.namespace))
+		}
+	}
+
+	return result, nil
+}
+
+// CloudProvider returns "kubernetes" for the account of the cluster
+func (k Kubernetes) CloudProvider(account string) (string, error) {
+	err := k.checkAccount(account)
+	if err != nil {
+		return "", err
+	}
+
+	return cloudProvider, nil
+}
+
+// checkAccount returns an error if account isn't the account of the cluster
+func (k Kubernetes) checkAccount(account string) error {
+	if account != k.account {
+		return errors.Errorf("unknown account: %s, the kubernetes cluster is account %s", account, k.account)
+	}
+	return nil
+}
+
+// appName returns the name of the app of a workload, following the
+// app-stack-detail convention
+func appName(workloadName string) (string, error) {
+	names, err := frigga.Parse(workloadName)
+	if err != nil {
+		return "", err
+	}
+
+	return names.Team, nil
+}
+
+// appWorkloads returns the workloads of an app
+func (k Kubernetes) appWorkloads(app string) ([]workload, error) {
+	workloads, err := k.workloads()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []workload
+	for _, w := range workloads {
+		name, err := appName(w.name)
+		if err != nil || name != app {
+			continue
+		}
+		result = append(result, w)
+	}
+
+	return result, nil
+}
+
+// workloads returns the Deployments and StatefulSets of the namespaces that
+// are examined, sorted by namespace and name
+func (k Kubernetes) workloads() ([]workload, error) {
+	namespaces := k.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var result []workload
+	for _, ns := range namespaces {
+		deployments, err := k.client.AppsV1().Deployments(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list deployments in namespace %q", ns)
+		}
+
+		for _, d := range deployments.Items {
+			result = append(result, workload{name: d.Name, namespace: d.Namespace, selector: d.Spec.Selector, annotations: d.Annotations})
+		}
+
+		statefulSets, err := k.client.AppsV1().StatefulSets(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list statefulsets in namespace %q", ns)
+		}
+
+		for _, s := range statefulSets.Items {
+			result = append(result, workload{name: s.Name, namespace: s.Namespace, selector: s.Spec.Selector, annotations: s.Annotations})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].namespace != result[j].namespace {
+			return result[i].namespace < result[j].namespace
+		}
+		return result[i].name < result[j].name
+	})
+
+	return result, nil
+}
+
+// workload returns the Deployment or StatefulSet named name in namespace
+func (k Kubernetes) workload(namespace, name string) (workload, error) {
+	d, err := k.client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err == nil {
+		return workload{name: d.Name, namespace: d.Namespace, selector: d.Spec.Selector, annotations: d.Annotations}, nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return workload{}, errors.Wrapf(err, "failed to retrieve deployment %s/%s", namespace, name)
+	}
+
+	s, err := k.client.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return workload{}, errors.Wrapf(err, "failed to retrieve workload %s/%s", namespace, name)
+	}
+
+	return workload{name: s.Name, namespace: s.Namespace, selector: s.Spec.Selector, annotations: s.Annotations}, nil
+}
+
+// pods returns the names of the running pods of a workload that aren't
+// being deleted already, sorted by name
+func (k Kubernetes) pods(w workload) ([]D.EmployeeId, error) {
+	selector, err := metav1.LabelSelectorAsSelector(w.selector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid selector of workload %s/%s", w.namespace, w.name)
+	}
+
+	pods, err := k.client.CoreV1().Pods(w.namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list pods of workload %s/%s", w.namespace, w.name)
+	}
+
+	ids := []D.EmployeeId{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		ids = append(ids, D.EmployeeId(pod.Name))
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build kubernetes
+
+package kubernetes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/FakeTwitter/elon"
+	D "github.com/FakeTwitter/elon/deploy"
+	"github.com/FakeTwitter/elon/mock"
+)
+
+const fooConfig = `{"enabled": true, "meanTimeBetweenFiresInWorkDays": 3, "minTimeBetweenFiresInWorkDays": 1, "grouping": "team"}`
+
+func deployment(namespace, name string, annotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Annotations: annotations},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"workload": name}},
+		},
+	}
+}
+
+func statefulSet(namespace, name string) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"workload": name}},
+		},
+	}
+}
+
+func pod(namespace, name, workload string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"workload": workload}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+// testKubernetes returns a Kubernetes backed by a fake clientset with the
+// workloads of apps foo and bar
+func testKubernetes(objects ...runtime.Object) Kubernetes {
+	objects = append(objects,
+		deployment("default", "foo-web", map[string]string{ConfigAnnotation: fooConfig}),
+		pod("default", "foo-web-1", "foo-web", corev1.PodRunning),
+		pod("default", "foo-web-2", "foo-web", corev1.PodRunning),
+		pod("default", "foo-web-3", "foo-web", corev1.PodPending),
+		deployment("staging", "foo-web", nil),
+		pod("staging", "foo-web-1", "foo-web", corev1.PodRunning),
+		statefulSet("default", "foo-db"),
+		pod("default", "foo-db-0", "foo-db", corev1.PodRunning),
+		deployment("default", "bar", nil),
+	)
+
+	gracePeriod := int64(10)
+	return New(fake.NewSimpleClientset(objects...), "k8s", nil, &gracePeriod)
+}
+
+func TestTeamNames(t *testing.T) {
+	k := testKubernetes()
+
+	names, err := k.TeamNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := names, []string{"bar", "foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got TeamNames()=%v, want %v", got, want)
+	}
+}
+
+func TestGetEmployeeIds(t *testing.T) {
+	k := testKubernetes()
+
+	tests := []struct {
+		region D.RegionName
+		team   D.TeamName
+		want   []D.EmployeeId
+	}{
+		// Pods that aren't running aren't employees
+		{"default", "foo-web", []D.EmployeeId{"foo-web-1", "foo-web-2"}},
+		{"staging", "foo-web", []D.EmployeeId{"foo-web-1"}},
+		{"default", "foo-db", []D.EmployeeId{"foo-db-0"}},
+	}
+
+	for _, tt := range tests {
+		asg, ids, err := k.GetEmployeeIds("foo", "k8s", "kubernetes", tt.region, tt.team)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := asg, D.ASGName(tt.team); got != want {
+			t.Errorf("got asg=%s, want %s", got, want)
+		}
+
+		if got := ids; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s/%s: got ids=%v, want %v", tt.region, tt.team, got, tt.want)
+		}
+	}
+
+	_, _, err := k.GetEmployeeIds("foo", "prod", "kubernetes", "default", "foo-web")
+	if err == nil {
+		t.Error("Expected an error for an account that isn't the cluster")
+	}
+}
+
+func TestGetTeamNamesAndRegionNames(t *testing.T) {
+	k := testKubernetes()
+
+	teams, err := k.GetTeamNames("foo", "k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := teams, []D.TeamName{"foo-db", "foo-web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got GetTeamNames()=%v, want %v", got, want)
+	}
+
+	regions, err := k.GetRegionNames("foo", "k8s", "foo-web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := regions, []D.RegionName{"default", "staging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got GetRegionNames()=%v, want %v", got, want)
+	}
+}
+
+func TestGetTeam(t *testing.T) {
+	k := testKubernetes()
+
+	app, err := k.GetTeam("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(app.Accounts()), 1; got != want {
+		t.Fatalf("got len(app.Accounts())=%d, want %d", got, want)
+	}
+
+	account := app.Accounts()[0]
+	if got, want := account.Name(), "k8s"; got != want {
+		t.Errorf("got account.Name()=%s, want %s", got, want)
+	}
+
+	if got, want := account.CloudProvider(), "kubernetes"; got != want {
+		t.Errorf("got account.CloudProvider()=%s, want %s", got, want)
+	}
+
+	if got, want := len(account.Teams()), 2; got != want {
+		t.Errorf("got len(account.Teams())=%d, want %d", got, want)
+	}
+}
+
+func TestGet(t *testing.T) {
+	k := testKubernetes()
+
+	cfg, err := k.Get("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cfg.MeanTimeBetweenFiresInWorkDays, 3; got != want {
+		t.Errorf("got MeanTimeBetweenFiresInWorkDays=%d, want %d", got, want)
+	}
+
+	// No workload of bar has a config
+	_, err = k.Get("bar")
+	if err == nil {
+		t.Error("Expected an error for an app without config annotation")
+	}
+
+	// The workloads of an app can't disagree
+	k = testKubernetes(deployment("prod", "foo-api", map[string]string{ConfigAnnotation: `{"enabled": false}`}))
+	_, err = k.Get("foo")
+	if err == nil {
+		t.Error("Expected an error for conflicting config annotations")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	k := testKubernetes()
+
+	ins := mock.employee{Team: "foo", Account: "k8s", Region: "default", EmployeeId: "foo-web-1"}
+	err := k.Execute(elon.Termination{employee: ins, Time: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ids, err := k.GetEmployeeIds("foo", "k8s", "kubernetes", "default", "foo-web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ids, []D.EmployeeId{"foo-web-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got ids=%v, want %v", got, want)
+	}
+
+	// The pod of the other namespace is still there
+	_, err = k.client.CoreV1().Pods("staging").Get(context.TODO(), "foo-web-1", metav1.GetOptions{})
+	if err != nil {
+		t.Error(err)
+	}
+}
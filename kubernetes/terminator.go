@@ -0,0 +1,44 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build kubernetes
+
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/FakeTwitter/elon"
+)
+
+// Execute implements elon.Terminator.Execute by deleting the pod of the
+// employee, with the configured grace period
+func (k Kubernetes) Execute(trm elon.Termination) error {
+	ins := trm.employee
+
+	err := k.checkAccount(ins.AccountName())
+	if err != nil {
+		return err
+	}
+
+	err = k.client.CoreV1().Pods(ins.RegionName()).Delete(context.TODO(), ins.ID(), metav1.DeleteOptions{GracePeriodSeconds: k.gracePeriod})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete pod %s/%s", ins.RegionName(), ins.ID())
+	}
+
+	return nil
+}
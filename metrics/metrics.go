@@ -0,0 +1,157 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics collects the metrics of schedule and termination runs, and
+// exposes them in the Prometheus text format
+package metrics
+
+import (
+	"net/http"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Registry is the registry of the metrics of Elon
+var Registry = prometheus.NewRegistry()
+
+// The metrics of Elon
+var (
+	AppsScanned = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elon_apps_scanned_total",
+		Help: "Number of apps examined when generating schedules.",
+	})
+	GroupsEligible = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elon_groups_eligible_total",
+		Help: "Number of employee groups eligible for termination when generating schedules.",
+	})
+	EntriesScheduled = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elon_schedule_entries_total",
+		Help: "Number of terminations scheduled.",
+	})
+	EntriesDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elon_schedule_entries_dropped_total",
+		Help: "Number of terminations dropped from schedules by the constrainer.",
+	})
+
+	TerminationsAttempted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elon_terminations_attempted_total",
+		Help: "Number of terminations attempted.",
+	})
+	TerminationsSucceeded = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elon_terminations_succeeded_total",
+		Help: "Number of employees terminated.",
+	})
+	TerminationsSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "elon_terminations_skipped_total",
+		Help: "Number of terminations that were skipped, by reason.",
+	}, []string{"reason"})
+	TerminationsFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elon_terminations_failed_total",
+		Help: "Number of terminations that failed.",
+	})
+
+	Errors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elon_errors_total",
+		Help: "Number of errors reported to the prometheus error counter.",
+	})
+
+	SysbreakerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "elon_sysbreaker_request_duration_seconds",
+		Help:    "Duration of requests to Sysbreaker, until the response headers are received.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "code"})
+)
+
+// Reasons for skipping terminations
+const (
+	SkipOutage     = "outage"
+	SkipDisabled   = "disabled"
+	SkipBlackout   = "blackout"
+	SkipMinTime    = "min_time"
+	SkipLeashed    = "leashed"
+	SkipNoEligible = "no_eligible"
+)
+
+func init() {
+	Registry.MustRegister(
+		AppsScanned,
+		GroupsEligible,
+		EntriesScheduled,
+		EntriesDropped,
+		TerminationsAttempted,
+		TerminationsSucceeded,
+		TerminationsSkipped,
+		TerminationsFailed,
+		Errors,
+		SysbreakerRequestDuration,
+	)
+}
+
+// Handler returns a handler that serves the metrics in the Prometheus text
+// format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// WriteTextfile writes the metrics of a run of command to the file
+// elon_<command>.prom in dir, for the textfile collector of the Prometheus
+// node_exporter. This is meant for commands that run from cron, and is
+// called once at the end of a run.
+//
+// The file holds the metrics of the last run only, along with the time of
+// the run in elon_last_run_timestamp_seconds. Every metric is labeled with
+// the command, so that the files of different commands don't collide.
+func WriteTextfile(dir, command string) error {
+	lastRun := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "elon_last_run_timestamp_seconds",
+		Help: "Time at which the command last ran, in seconds since the epoch.",
+	})
+	lastRun.SetToCurrentTime()
+
+	run := prometheus.NewRegistry()
+	run.MustRegister(lastRun)
+
+	g := labeledGatherer{
+		g:     prometheus.Gatherers{Registry, run},
+		label: &dto.LabelPair{Name: proto.String("command"), Value: proto.String(command)},
+	}
+
+	return prometheus.WriteToTextfile(filepath.Join(dir, "elon_"+command+".prom"), g)
+}
+
+// labeledGatherer adds a label to every metric that g gathers
+type labeledGatherer struct {
+	g     prometheus.Gatherer
+	label *dto.LabelPair
+}
+
+// Gather implements prometheus.Gatherer.Gather
+func (l labeledGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := l.g.Gather()
+	for _, family := range families {
+		for _, m := range family.Metric {
+			m.Label = append(m.Label, l.label)
+			sort.Slice(m.Label, func(i, j int) bool {
+				return m.Label[i].GetName() < m.Label[j].GetName()
+			})
+		}
+	}
+
+	return families, err
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	TerminationsSkipped.WithLabelValues(SkipOutage).Inc()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got, want := w.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8"; got != want {
+		t.Errorf("got Content-Type=%s, want %s", got, want)
+	}
+
+	if got, want := w.Body.String(), `elon_terminations_skipped_total{reason="outage"}`; !strings.Contains(got, want) {
+		t.Errorf("got body:\n%s\nwant it to contain %s", got, want)
+	}
+}
+
+// TestWriteTextfile verifies that every metric of the textfile is labeled
+// with the command, and that the time of the run is recorded
+func TestWriteTextfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	TerminationsSkipped.WithLabelValues(SkipLeashed).Inc()
+
+	err = WriteTextfile(dir, "terminate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "elon_terminate.prom"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`elon_terminations_attempted_total{command="terminate"} `,
+		`elon_terminations_skipped_total{command="terminate",reason="leashed"} `,
+		`elon_last_run_timestamp_seconds{command="terminate"} `,
+	} {
+		if got := string(contents); !strings.Contains(got, want) {
+			t.Errorf("got:\n%s\nwant it to contain %s", got, want)
+		}
+	}
+}
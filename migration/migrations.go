@@ -1,6 +1,11 @@
 // Code generated by go-bindata.
 // sources:
 // migration/mysql/1.0.0_initial_schema.sql
+// migration/mysql/1.1.0_schedule_seeds.sql
+// migration/mysql/1.2.0_termination_outcomes.sql
+// migration/postgres/1.0.0_initial_schema.sql
+// migration/postgres/1.1.0_schedule_seeds.sql
+// migration/postgres/1.2.0_termination_outcomes.sql
 // DO NOT EDIT!
 
 package migration
@@ -88,6 +93,106 @@ func migrationMysql100_initial_schemaSql() (*asset, error) {
 	return a, nil
 }
 
+var _migrationMysql110_schedule_seedsSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x8c\x90\xc1\x4e\xeb\x30\x10\x45\xf7\xfe\x8a\xbb\xeb\x7b\xa2\xfe\x82\x8a\x45\x4b\x4c\x65\x11\xd2\x92\xa6\x12\x5d\x55\xc1\x1e\x88\x85\x63\x47\xb1\xab\x22\xbe\x1e\xb9\xc1\xd0\x25\xb3\x1c\xcd\xbd\x67\x74\x38\xc7\x4d\x6f\xde\xc6\x36\x12\xf6\x03\xe3\x1c\xbb\xa7\x12\xc6\x21\x90\x8a\xc6\x3b\xcc\xf6\xc3\x0c\x26\x80\x3e\x48\x9d\x22\x69\x9c\x3b\x72\x88\x9d\x09\x98\x72\xe9\xc8\x04\xb4\xc3\x60\x0d\x69\x76\x57\x8b\x65\x23\xd0\x2c\x57\xa5\x80\xbc\x47\xb5\x69\x20\x9e\xe5\xae\xd9\x21\xa8\x8e\xf4\xc9\xd2\x31\x10\xe9\x80\x7f\x0c\x00\x74\x22\xe7\x29\x52\x34\x25\xaa\x7d\x59\x62\x5b\xcb\xc7\x65\x7d\xc0\x83\x38\xcc\xc1\xf9\x74\xea\x5f\x11\x69\xec\x8d\x9b\xc8\xb9\x73\x9e\x7e\xb6\x5e\xb5\x16\xd1\xf4\x84\x4f\xef\xe8\xd2\x9f\x58\xb9\x1e\x2b\xb9\x96\x55\xf3\x4b\xb8\x1a\xce\xa7\xd3\xd4\xdf\x11\xc6\xd6\x69\xdf\x43\x93\x32\xc1\x78\x17\xf2\x3e\xf3\x2e\xdd\xff\x99\xa8\xd6\xb2\x12\xb7\xd2\x39\x5f\xac\x16\x8c\xb1\x6b\x9f\x85\x3f\xbb\x6c\xf4\x47\x67\x5a\xfe\x49\xe8\xe8\xad\x25\x8d\x97\x56\xbd\xb3\xa2\xde\x6c\xbf\x95\xe6\x07\x8e\x81\x48\x87\x05\xfb\x1a\x00\xfa\xab\xd4\x68\xc1\x01\x00\x00")
+
+func migrationMysql110_schedule_seedsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrationMysql110_schedule_seedsSql,
+		"migration/mysql/1.1.0_schedule_seeds.sql",
+	)
+}
+
+func migrationMysql110_schedule_seedsSql() (*asset, error) {
+	bytes, err := migrationMysql110_schedule_seedsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migration/mysql/1.1.0_schedule_seeds.sql", size: 449, mode: os.FileMode(420), modTime: time.Unix(1792194717, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrationMysql120_termination_outcomesSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x8c\x92\x51\x6f\xd3\x30\x14\x85\xdf\xf3\x2b\xce\x5b\x98\x48\x24\x36\x01\x42\xda\x53\x58\x8a\x78\x08\x2d\x94\x84\xd7\xca\x8d\x4f\x1a\x2b\x8d\x1d\xd9\x37\x0a\xfb\xf7\x28\xeb\xba\xb5\x5b\x41\xf8\xf1\xfa\xfa\x7e\xe7\xf8\x9e\x34\xc5\xdb\xde\xec\xbc\x12\xa2\x1a\xa2\x34\xc5\xcf\x1f\x05\x8c\x45\x60\x2d\xc6\x59\xc4\xd5\x10\xc3\x04\xf0\x37\xeb\x51\xa8\x31\xb5\xb4\x90\xd6\x04\x1c\xde\xcd\x4d\x26\x40\x0d\xc3\xde\x50\xcf\x13\xca\x96\x70\xa3\xd4\xae\x27\x5c\x03\xa1\xef\x8d\x7d\x68\x0c\xf0\xac\x9d\xd7\xd4\xd8\xb2\x71\x9e\x17\x06\x8d\xb6\xb3\x6e\xb2\x09\x82\x9b\x87\x49\xcb\x7b\x28\x4f\xec\xd9\x08\x06\x5a\x6d\xec\x2e\x81\xb2\x1a\x1d\x39\xa0\x76\xa3\x15\x63\x77\x10\x37\x29\xaf\x21\x2d\xd1\x1b\x0b\x31\x3d\xb1\xa5\x4c\xa4\x45\x63\x3c\x43\x94\x15\xe5\x62\x8d\x32\xfb\x5c\x2c\xce\x44\x45\x00\x90\xe5\x39\xee\x56\x45\xf5\x6d\x89\x20\x4a\xc6\x80\xc7\xf3\x2b\x5b\xdf\x7d\xcd\xd6\x6f\xae\x3f\x5e\x01\x58\xae\x4a\x2c\xab\xa2\x40\xbe\xf8\x92\x55\x45\x89\xf8\x51\x52\x9c\x20\x4d\x9f\xf5\x85\xb1\xae\x49\x4d\x9d\xa0\x51\x66\x4f\x0d\xe7\x11\x3a\x33\x0c\xd4\x2f\x79\xf4\xde\xf9\x4d\xcf\x10\xd4\x8e\x4f\xbc\x9b\x77\xef\x3f\x5d\x5d\xe0\xc5\xc9\x51\x59\x9a\x62\x6a\xef\x1f\x1c\x9f\xd8\x39\xe1\x4d\x2a\xfc\x8d\x29\x2a\x74\x1b\xcf\xe6\xdc\xe3\x87\xeb\x9b\xab\x4b\x1e\xe3\xdb\x13\xa6\x67\x43\x4f\x5b\x1f\x96\x3b\xc3\x55\xe8\x20\xad\x92\xe7\x90\xbc\xd0\x14\x45\xd1\x69\xd0\x72\x37\xd9\x63\xd4\x9e\x72\x36\x17\xff\x2b\x69\xde\xed\x67\x7f\x5b\x55\x77\xff\x5e\x69\xbe\x5e\x7d\x3f\xfa\x3d\xec\x34\x79\x55\x3f\xfb\xfb\xd7\xd7\xa2\x42\xb7\xf1\x6c\x6e\xa3\x3f\x03\x00\x1e\xce\x18\x70\x29\x03\x00\x00")
+
+func migrationMysql120_termination_outcomesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrationMysql120_termination_outcomesSql,
+		"migration/mysql/1.2.0_termination_outcomes.sql",
+	)
+}
+
+func migrationMysql120_termination_outcomesSql() (*asset, error) {
+	bytes, err := migrationMysql120_termination_outcomesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migration/mysql/1.2.0_termination_outcomes.sql", size: 809, mode: os.FileMode(420), modTime: time.Unix(1792194909, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrationPostgres100_initial_schemaSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xac\x54\x51\x6f\xda\x3c\x14\x7d\xcf\xaf\x38\x6f\x80\x3e\xf2\x89\x56\xeb\x36\xa9\x4f\x29\xb8\x1a\x5a\x9a\x74\x21\xd9\xda\xbd\x20\x37\xb9\x80\xd5\xc4\xb1\x62\xa3\xb6\xfb\xf5\x93\x13\xd2\x04\xd8\x34\xaa\x2d\x6f\xbe\x1c\x9f\x73\x7c\xef\xe1\xba\x2e\xfe\x2b\xc4\xba\xe2\x86\x90\x28\xc7\x75\xb1\xf8\xe2\x43\x48\x68\x4a\x8d\x28\x25\x06\x89\x1a\x40\x68\xd0\x33\xa5\x5b\x43\x19\x9e\x36\x24\x61\x36\x42\xa3\xb9\x67\x41\x42\x83\x2b\x95\x0b\xca\x9c\x69\xc4\xbc\x98\x21\xf6\xae\x7c\x86\xf9\x35\x82\x30\x06\xbb\x9b\x2f\xe2\x05\x74\xba\xa1\x6c\x9b\x93\xc6\xd0\x01\x00\x91\xa1\xfb\x16\x2c\x9a\x7b\x3e\x6e\xa3\xf9\x8d\x17\xdd\xe3\x33\xbb\x1f\xd7\xa0\xcc\x3a\x6b\xbf\x99\xa5\xb6\x8c\x41\xe2\xfb\xe3\xb6\x0a\xd7\x6d\x60\xe5\x0a\x86\xaa\x42\xc8\xc6\x55\xab\x37\xb6\xef\xc9\xcb\x94\xe7\x30\xa2\x20\xfc\x28\x25\xd5\xdc\xf5\xa9\x25\x89\xe7\x37\x6c\x11\x7b\x37\xb7\xfb\x02\xae\xdb\x5c\x12\x12\x49\x3c\xfd\x1f\x57\x94\xf2\xad\x6e\xb4\x6c\x3d\x13\xab\x15\x55\x24\x53\x1a\xa3\xe0\x2f\xbb\x33\x56\x55\x59\xd4\xa6\x6a\x21\xae\x54\x2b\x03\x7c\xf5\xa2\xe9\x27\x2f\x1a\x5e\x9c\x9d\x8f\x3a\xad\x06\x97\xa6\xe5\x56\x9a\x7d\xdc\xd9\x64\x72\x88\xab\x68\x6d\x1f\x78\xc0\x37\x19\xa1\xc3\xd9\xa6\x58\x9f\x0f\x39\x97\x8f\xd0\xa6\x12\x72\x0d\x53\x42\xc8\x4c\xa4\xb6\x59\xb2\x34\x50\x15\x69\x92\xa6\xd6\xd6\x86\xa7\x8f\x38\xe0\x3c\xbf\xb8\x18\xfd\x05\xa7\x21\x5e\xe0\x90\xf3\xc3\xfb\x8f\x1d\x27\xde\xcc\x39\xba\x74\xda\x90\xcd\x83\x19\xbb\x3b\x08\x99\xed\xf9\x52\xc8\x8c\x9e\x11\x06\xfd\xc8\xd9\x1f\x7a\x77\x7f\x15\xd0\x5e\x78\xde\x94\xd1\x7f\x3d\xde\x13\x46\x71\x62\x7b\xff\x10\x97\x7d\x18\xd7\x6b\x1c\xd1\x9d\x4d\x8e\xfd\x51\xa1\xf2\xf2\x85\x68\x29\xb2\x0e\xf8\xee\x48\x76\x25\x2a\xca\x96\xdc\xfc\xe6\xef\x85\x19\xbb\xf6\x12\x3f\xc6\x30\x08\xbf\x0d\x47\xf0\xe2\x1a\x84\xef\x61\xc0\x30\x48\xe2\xe9\x60\xd4\xc8\xe5\xc4\xf5\x86\x76\x83\xb8\x0a\x43\x9f\x79\xc1\x31\xcb\xb5\xe7\x2f\xd8\x29\x09\xe1\x4a\x2d\x5b\x6b\x5d\x52\xf6\x67\xcf\x95\x1a\xa3\x05\xd9\xd4\x38\xfd\x45\x39\x2b\x9f\x64\xbb\x2a\x5f\xf7\xa4\x2d\x9e\xb4\x29\xab\x32\xcf\x29\xc3\x03\x4f\x1f\x9d\x59\x14\xde\xee\x76\xe5\x6b\x54\x2f\xfb\xd5\xbe\xad\x4b\xe7\xe7\x00\xbc\xbb\x0d\x64\xae\x05\x00\x00")
+
+func migrationPostgres100_initial_schemaSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrationPostgres100_initial_schemaSql,
+		"migration/postgres/1.0.0_initial_schema.sql",
+	)
+}
+
+func migrationPostgres100_initial_schemaSql() (*asset, error) {
+	bytes, err := migrationPostgres100_initial_schemaSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migration/postgres/1.0.0_initial_schema.sql", size: 1454, mode: os.FileMode(420), modTime: time.Unix(1792193676, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrationPostgres110_schedule_seedsSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x8c\x90\x4d\x4e\xc3\x30\x14\x84\xf7\x3e\xc5\xec\x0a\xa2\x3e\x41\x57\x2d\x0d\x28\x22\xa4\x25\x3f\x12\x5d\x55\xc1\x7e\x10\x0b\xc7\x8e\xf2\x5c\x15\x71\x7a\x64\x82\xa1\x4b\xbc\x7c\x9a\x99\xcf\xfa\xa4\xc4\xcd\x60\xde\xa6\x2e\x10\xda\x51\x48\x89\xfa\xa9\x80\x71\x60\x52\xc1\x78\x87\x45\x3b\x2e\x60\x18\xf4\x41\xea\x14\x48\xe3\xdc\x93\x43\xe8\x0d\x63\xee\xc5\x90\x61\x74\xe3\x68\x0d\x69\x71\x5b\x65\xeb\x26\x43\xb3\xde\x14\x19\xf2\x3b\x94\xbb\x06\xd9\x73\x5e\x37\x35\x58\xf5\xa4\x4f\x96\x8e\x4c\xa4\x19\x57\x02\x00\x74\x24\xa7\xb7\x8d\xd5\xd8\x28\xdb\xa2\xc0\xbe\xca\x1f\xd7\xd5\x01\x0f\xd9\x61\x09\x29\xe7\xa8\x7f\x45\xa0\x69\x30\x6e\x26\xa7\xcd\x65\xfc\xb3\xf5\xaa\xb3\x08\x66\x20\x7c\x7a\x47\xdf\xfb\x91\x95\xe6\xb1\xc9\xef\xf3\xb2\xf9\x23\x5c\x3c\x29\xe7\x68\xdc\xef\x09\x53\xe7\xb4\x1f\xa0\x49\x19\x36\xde\x71\xba\x27\x9e\xb8\x5e\x09\x21\x2e\xed\x6d\xfd\xd9\x25\x7f\xbf\xf2\xe2\xf1\x5f\xfa\x26\x6f\x2d\x69\xbc\x74\xea\x5d\x6c\xab\xdd\xfe\x47\x60\xc2\x1d\x99\x48\xf3\x4a\x7c\x0d\x00\x90\xce\x05\xaa\xaf\x01\x00\x00")
+
+func migrationPostgres110_schedule_seedsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrationPostgres110_schedule_seedsSql,
+		"migration/postgres/1.1.0_schedule_seeds.sql",
+	)
+}
+
+func migrationPostgres110_schedule_seedsSql() (*asset, error) {
+	bytes, err := migrationPostgres110_schedule_seedsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migration/postgres/1.1.0_schedule_seeds.sql", size: 431, mode: os.FileMode(420), modTime: time.Unix(1792194717, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrationPostgres120_termination_outcomesSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x8c\x92\x51\x6f\xd3\x30\x14\x85\xdf\xf3\x2b\xce\x5b\x98\x48\x24\x36\x01\x42\xda\x53\x58\x8a\x78\x08\x2d\x94\x84\xd7\xca\x8d\x4f\x1a\x2b\x8d\x1d\xd9\x37\x0a\xfb\xf7\x28\xeb\xba\xb5\x5b\x41\xf8\xf1\xfa\xfa\x7e\xe7\xf8\x9e\x34\xc5\xdb\xde\xec\xbc\x12\xa2\x1a\xa2\x34\xc5\xcf\x1f\x05\x8c\x45\x60\x2d\xc6\x59\xc4\xd5\x10\xc3\x04\xf0\x37\xeb\x51\xa8\x31\xb5\xb4\x90\xd6\x04\x1c\xde\xcd\x4d\x26\x40\x0d\xc3\xde\x50\xcf\x13\xca\x96\x70\xa3\xd4\xae\x27\x5c\x03\xa1\xef\x8d\x7d\x68\x0c\xf0\xac\x9d\xd7\xd4\xd8\xb2\x71\x9e\x17\x06\x8d\xb6\xb3\x6e\xb2\x09\x82\x9b\x87\x49\xcb\x7b\x28\x4f\xec\xd9\x08\x06\x5a\x6d\xec\x2e\x81\xb2\x1a\x1d\x39\xa0\x76\xa3\x15\x63\x77\x10\x37\x29\xaf\x21\x2d\xd1\x1b\x0b\x31\x3d\xb1\xa5\x4c\xa4\x45\x63\x3c\x43\x94\x15\xe5\x62\x8d\x32\xfb\x5c\x2c\xce\x44\x45\x00\x90\xe5\x39\xee\x56\x45\xf5\x6d\x89\x20\x4a\xc6\x80\xc7\xf3\x2b\x5b\xdf\x7d\xcd\xd6\x6f\xae\x3f\x5e\x01\x58\xae\x4a\x2c\xab\xa2\x40\xbe\xf8\x92\x55\x45\x89\xf8\x51\x52\x9c\x20\x4d\x9f\xf5\x85\xb1\xae\x49\x4d\x9d\xa0\x51\x66\x4f\x0d\xe7\x11\x3a\x33\x0c\xd4\x2f\x79\xf4\xde\xf9\x4d\xcf\x10\xd4\x8e\x4f\xbc\x9b\x77\xef\x3f\x5d\x5d\xe0\xc5\xc9\x51\x59\x9a\x62\x6a\xef\x1f\x1c\x9f\xd8\x39\xe1\x4d\x2a\xfc\x8d\x29\x2a\x74\x1b\xcf\xe6\xdc\xe3\x87\xeb\x9b\xab\x4b\x1e\xe3\xdb\x13\xa6\x67\x43\x4f\x5b\x1f\x96\x3b\xc3\x55\xe8\x20\xad\x92\xe7\x90\xbc\xd0\x14\x45\xd1\x69\xd0\x72\x37\xd9\x63\xd4\x9e\x72\x36\x17\xff\x2b\x69\xde\xed\x67\x7f\x5b\x55\x77\xff\x5e\x69\xbe\x5e\x7d\x3f\xfa\x3d\xec\x34\x79\x55\x3f\xfb\xfb\xd7\xd7\xa2\x42\xb7\xf1\x6c\x6e\xa3\x3f\x03\x00\x1e\xce\x18\x70\x29\x03\x00\x00")
+
+func migrationPostgres120_termination_outcomesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrationPostgres120_termination_outcomesSql,
+		"migration/postgres/1.2.0_termination_outcomes.sql",
+	)
+}
+
+func migrationPostgres120_termination_outcomesSql() (*asset, error) {
+	bytes, err := migrationPostgres120_termination_outcomesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migration/postgres/1.2.0_termination_outcomes.sql", size: 809, mode: os.FileMode(420), modTime: time.Unix(1792194909, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -141,6 +246,11 @@ func AssetNames() []string {
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
 	"migration/mysql/1.0.0_initial_schema.sql": migrationMysql100_initial_schemaSql,
+	"migration/mysql/1.1.0_schedule_seeds.sql": migrationMysql110_schedule_seedsSql,
+	"migration/mysql/1.2.0_termination_outcomes.sql": migrationMysql120_termination_outcomesSql,
+	"migration/postgres/1.0.0_initial_schema.sql": migrationPostgres100_initial_schemaSql,
+	"migration/postgres/1.1.0_schedule_seeds.sql": migrationPostgres110_schedule_seedsSql,
+	"migration/postgres/1.2.0_termination_outcomes.sql": migrationPostgres120_termination_outcomesSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -187,6 +297,13 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"migration": {nil, map[string]*bintree{
 		"mysql": {nil, map[string]*bintree{
 			"1.0.0_initial_schema.sql": {migrationMysql100_initial_schemaSql, map[string]*bintree{}},
+			"1.1.0_schedule_seeds.sql": {migrationMysql110_schedule_seedsSql, map[string]*bintree{}},
+			"1.2.0_termination_outcomes.sql": {migrationMysql120_termination_outcomesSql, map[string]*bintree{}},
+		}},
+		"postgres": {nil, map[string]*bintree{
+			"1.0.0_initial_schema.sql": {migrationPostgres100_initial_schemaSql, map[string]*bintree{}},
+			"1.1.0_schedule_seeds.sql": {migrationPostgres110_schedule_seedsSql, map[string]*bintree{}},
+			"1.2.0_termination_outcomes.sql": {migrationPostgres120_termination_outcomesSql, map[string]*bintree{}},
 		}},
 	}},
 }}
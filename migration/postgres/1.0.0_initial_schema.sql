@@ -0,0 +1,35 @@
+-- +migrate Up
+-- SQL in section 'Up' is executed when this migration is applied
+CREATE TABLE IF NOT EXISTS schedules (
+    id           SERIAL PRIMARY KEY,
+    date         DATE NOT NULL,         -- date of termination schedule, in local time zone
+    time         TIMESTAMP NOT NULL,    -- time in UTC. Because of time difference, may differ from date
+    app          VARCHAR(512) NOT NULL,
+    account      VARCHAR(100) NOT NULL,
+    region       VARCHAR(50)  NOT NULL, -- use blank string to indicate not present
+    stack        VARCHAR(255) NOT NULL, -- use blank string to indicate not present
+    team         VARCHAR(768) NOT NULL  -- use blank string to indicate not present
+    );
+
+CREATE INDEX IF NOT EXISTS date_index ON schedules (date);
+
+CREATE TABLE IF NOT EXISTS terminations (
+    id           SERIAL PRIMARY KEY,
+    app          VARCHAR(512) NOT NULL,
+    account      VARCHAR(100) NOT NULL,
+    stack        VARCHAR(255) NOT NULL,
+    team         VARCHAR(768) NOT NULL,
+    region       VARCHAR(50) NOT NULL,
+    asg          VARCHAR(1000) NOT NULL,
+    employee_id  VARCHAR(48) NOT NULL,
+    fired_at     TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    leashed      BOOLEAN NOT NULL DEFAULT FALSE
+    );
+
+CREATE INDEX IF NOT EXISTS app_fired_at_index ON terminations (app, fired_at);
+
+
+-- +migrate Down
+-- SQL section 'Down' is executed when this migration is rolled back
+DROP TABLE schedules;
+DROP TABLE terminations;
@@ -0,0 +1,16 @@
+-- +migrate Up
+-- SQL in section 'Up' is executed when this migration is applied
+-- The outcome of terminations recorded before this migration is unknown, so
+-- they are left pending, and keep counting toward the min time between fires
+ALTER TABLE terminations
+    ADD COLUMN status        VARCHAR(16)   NOT NULL DEFAULT 'pending', -- pending, succeeded, failed or skipped
+    ADD COLUMN error_message VARCHAR(2048) NOT NULL DEFAULT '',        -- why the termination failed or was skipped
+    ADD COLUMN task_ref      VARCHAR(512)  NOT NULL DEFAULT '';        -- reference of the task that executed the termination
+
+
+-- +migrate Down
+-- SQL section 'Down' is executed when this migration is rolled back
+ALTER TABLE terminations
+    DROP COLUMN status,
+    DROP COLUMN error_message,
+    DROP COLUMN task_ref;
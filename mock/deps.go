@@ -16,6 +16,7 @@ package mock
 
 import (
 	"io/ioutil"
+	"math/rand"
 	"time"
 
 	"github.com/FakeTwitter/elon"
@@ -31,9 +32,16 @@ type (
 		Error error
 	}
 
-	// Tracker implements elon.Tracker
+	// Tracker implements elon.OptionalTracker
 	Tracker struct {
-		Error error
+		Error      error
+		IsOptional bool
+	}
+
+	// Warner implements elon.Warner, it records the warnings
+	Warner struct {
+		Warnings []elon.Warning
+		Error    error
 	}
 
 	// ErrorCounter implements elon.Publisher
@@ -56,11 +64,27 @@ func (c Checker) Check(term elon.Termination, appCfg elon.TeamConfig, endHour in
 
 }
 
+// Complete implements deps.Checker.Complete
+func (c Checker) Complete(term elon.Termination, outcome elon.Outcome, ref string, message string) error {
+	return nil
+}
+
 // Track implements elon.Tracker.Track
 func (t Tracker) Track(trm elon.Termination) error {
 	return t.Error
 }
 
+// Optional implements elon.OptionalTracker.Optional
+func (t Tracker) Optional() bool {
+	return t.IsOptional
+}
+
+// Warn implements elon.Warner.Warn
+func (w *Warner) Warn(warning elon.Warning) error {
+	w.Warnings = append(w.Warnings, warning)
+	return w.Error
+}
+
 // Increment implements elon.ErrorCounter.Increment
 func (e ErrorCounter) Increment() error {
 	return nil
@@ -110,5 +134,6 @@ func Deps() deps.Deps {
 		Ou:         Outage{},
 		ErrCounter: ErrorCounter{},
 		Env:        Env{false},
+		Rand:       rand.New(rand.NewSource(0)),
 	}
 }
@@ -21,3 +21,21 @@ type Outage struct{}
 func (o Outage) Outage() (bool, error) {
 	return false, nil
 }
+
+// ScopedOutage is a mock implementation of elon.ScopedOutage, with an ongoing
+// outage in Account, limited to Region if it isn't blank
+type ScopedOutage struct {
+	Account string
+	Region  string
+}
+
+// Outage implements elon.Outage.Outage
+func (o ScopedOutage) Outage() (bool, error) {
+	return true, nil
+}
+
+// OutageIn implements elon.ScopedOutage.OutageIn. Like the alertmanager
+// outage checker, a blank region is affected by an outage in any region.
+func (o ScopedOutage) OutageIn(account string, region string) (bool, error) {
+	return account == o.Account && (o.Region == "" || region == "" || region == o.Region), nil
+}
@@ -21,6 +21,7 @@
 package mysql_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -381,3 +382,85 @@ func TestCheckMinTimeEnforced(t *testing.T) {
 		}
 	}
 }
+
+// TestCompleteFailedDoesNotCount verifies that a failed termination doesn't
+// count toward the min time between terminations, but a succeeded one does
+func TestCompleteFailedDoesNotCount(t *testing.T) {
+	err := initDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := mysql.New("localhost", port, "root", password, "elon")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ins, loc, appCfg := testSetup(t)
+
+	trm := c.Termination{employee: ins, Time: time.Now(), Leashed: false}
+
+	err = s.Check(trm, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Complete(trm, c.Failed, "", "POST failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed termination was completed already
+	err = s.Complete(trm, c.Succeeded, "", "")
+	if err == nil {
+		t.Error("Complete() succeeded on a termination that isn't pending")
+	}
+
+	trm = c.Termination{employee: ins, Time: time.Now(), Leashed: false}
+
+	err = s.Check(trm, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatalf("Should have allowed a termination after a failed one: %v", err)
+	}
+
+	err = s.Complete(trm, c.Succeeded, "/tasks/01", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Check(c.Termination{employee: ins, Time: time.Now(), Leashed: false}, appCfg, endHour, loc)
+	if _, ok := err.(c.ErrViolatesMinTime); !ok {
+		t.Fatalf("Expected Err.ViolatesMinTime, got %v", err)
+	}
+}
+
+func TestCompleteTruncatesLongErrorMessage(t *testing.T) {
+	err := initDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := mysql.New("localhost", port, "root", password, "elon")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ins, loc, appCfg := testSetup(t)
+
+	trm := c.Termination{employee: ins, Time: time.Now(), Leashed: false}
+
+	err = s.Check(trm, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Complete(trm, c.Failed, "", strings.Repeat("é", 5000))
+	if err != nil {
+		t.Fatalf("Should have stored an over-long error message: %v", err)
+	}
+
+	err = s.Check(c.Termination{employee: ins, Time: time.Now(), Leashed: false}, appCfg, endHour, loc)
+	if err != nil {
+		t.Fatalf("Should have allowed a termination after a failed one: %v", err)
+	}
+}
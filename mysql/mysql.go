@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
@@ -39,6 +40,9 @@ import (
 // MySQL represents a MySQL-backed store for schedules and terminations
 type MySQL struct {
 	db *sql.DB
+
+	// calendars determine the work days used for min time between fires
+	calendars cal.Regional
 }
 
 // TxDeadlock returns true if the error is because of a transaction deadlock
@@ -79,17 +83,28 @@ func NewFromConfig(cfg *config.Monkey) (MySQL, error) {
 		return MySQL{}, err
 	}
 
-	return New(cfg.DatabaseHost(), cfg.DatabasePort(), cfg.DatabaseUser(), password, cfg.DatabaseName())
+	calendars, err := cal.NewFromConfig(cfg)
+	if err != nil {
+		return MySQL{}, err
+	}
+
+	m, err := New(cfg.DatabaseHost(), cfg.DatabasePort(), cfg.DatabaseUser(), password, cfg.DatabaseName())
+	if err != nil {
+		return MySQL{}, err
+	}
+
+	m.calendars = calendars
+	return m, nil
 }
 
-// New creates a new MySQL
+// New creates a new MySQL that uses the default calendar of work days
 func New(host string, port int, user string, password string, dbname string) (MySQL, error) {
 	db, err := sql.Open("mysql", dsn(host, port, user, password, dbname))
 	if err != nil {
 		return MySQL{}, errors.Wrap(err, "sql.Open failed")
 	}
 
-	return MySQL{db}, nil
+	return MySQL{db: db}, nil
 }
 
 // Close closes the underlying sql.DB
@@ -107,13 +122,20 @@ func utcDate(date time.Time) time.Time {
 }
 
 // Retrieve  retrieves the schedule for the given date
+// The seed of the schedule is zero if none was recorded.
 func (m MySQL) Retrieve(date time.Time) (sched *schedule.Schedule, err error) {
+	var seed int64
+	err = m.db.QueryRow("SELECT seed FROM schedule_seeds WHERE date = DATE(?)", utcDate(date)).Scan(&seed)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "failed to retrieve schedule seed for %s", date)
+	}
+
 	rows, err := m.db.Query("SELECT time, app, account, region, stack, team FROM schedules WHERE date = DATE(?)", utcDate(date))
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to retrieve schedule for %s", date)
 	}
 
-	sched = schedule.New()
+	sched = schedule.NewWithSeed(seed)
 
 	defer func() {
 		if cerr := rows.Close(); cerr != nil && err == nil {
@@ -208,6 +230,12 @@ func (m MySQL) PublishWithDelay(date time.Time, sched *schedule.Schedule, delay
 		}
 	}
 
+	// An empty schedule has no rows, so it may be published again
+	_, err = tx.Exec("INSERT INTO schedule_seeds (date, seed) VALUES (DATE(?), ?) ON DUPLICATE KEY UPDATE seed = VALUES(seed)", utcDate(date), sched.Seed())
+	if err != nil {
+		return errors.Wrap(err, "failed to record schedule seed")
+	}
+
 	return nil
 }
 
@@ -282,7 +310,7 @@ func (m MySQL) CheckWithDelay(term elon.Termination, appCfg elon.TeamConfig, end
 		}
 	}()
 
-	err = respectsMinTimeBetweenFires(tx, term.Time, term, appCfg, endHour, loc)
+	err = respectsMinTimeBetweenFires(tx, m.calendars.ForRegion(term.employee.RegionName()), term.Time, term, appCfg, endHour, loc)
 	if err != nil {
 		return err
 	}
@@ -296,14 +324,49 @@ func (m MySQL) CheckWithDelay(term elon.Termination, appCfg elon.TeamConfig, end
 
 }
 
+// Complete records the outcome of a termination that was permitted by Check.
+// The termination is identified by its employee and its time, which the
+// database may have rounded to the second.
+func (m MySQL) Complete(term elon.Termination, outcome elon.Outcome, ref string, message string) error {
+	t := term.Time.In(time.UTC)
+	res, err := m.db.Exec("UPDATE terminations SET status = ?, task_ref = ?, error_message = ? WHERE employee_id = ? AND status = ? AND fired_at BETWEEN ? AND ?",
+		outcome, ref, truncate(message, maxErrorMessage), term.employee.ID(), elon.Pending, t.Add(-time.Second), t.Add(time.Second))
+	if err != nil {
+		return errors.Wrapf(err, "failed to record outcome of termination of %s", term.employee.ID())
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve number of updated terminations")
+	}
+
+	if n == 0 {
+		return errors.Errorf("no pending termination of %s at %s", term.employee.ID(), term.Time)
+	}
+
+	return nil
+}
+
+// maxErrorMessage is the size of the error_message column of terminations
+const maxErrorMessage = 2048
+
+// truncate returns the first n characters of s
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
+
 // respectsMinTimeBetweenFires checks if this termination will respect or
 // violate the min time between fires value. If this termination is too close
 // to the most recent one, this will return an error.
 // If this termination would violate the min time, returns an ErrViolatesMinTime
-func respectsMinTimeBetweenFires(tx *sql.Tx, now time.Time, term elon.Termination, appCfg elon.TeamConfig, endHour int, loc *time.Location) (err error) {
+func respectsMinTimeBetweenFires(tx *sql.Tx, c cal.Calendar, now time.Time, term elon.Termination, appCfg elon.TeamConfig, endHour int, loc *time.Location) (err error) {
 	team := term.employee.TeamName()
 	account := term.employee.AccountName()
-	threshold, err := noFiresSince(appCfg.MinTimeBetweenFiresInWorkDays, now, endHour, loc)
+	threshold, err := cal.NoFiresSince(c, appCfg.MinTimeBetweenFiresInWorkDays, now, endHour, loc)
 	if err != nil {
 		return err
 	}
@@ -339,6 +402,9 @@ func respectsMinTimeBetweenFires(tx *sql.Tx, now time.Time, term elon.Terminatio
 		query += " AND leashed = FALSE"
 	}
 
+	// Failed terminations didn't fire anyone
+	query += " AND status <> 'failed'"
+
 	// We need at most one entry
 	query += " LIMIT 1"
 
@@ -365,75 +431,12 @@ func respectsMinTimeBetweenFires(tx *sql.Tx, now time.Time, term elon.Terminatio
 	return nil
 }
 
-// noFiresSince computes the date of the most recent fire
-// that conforms to the min time between fires specified
-// by days
-//
-// Note that the calculation is min time in work days, so it does not count weekends.
-//
-// chrono is an interface for returning the current time
-// endHour is the hour of the end of a workday in 24-hour time. For example, if
-// workday ends at 5PM, this would be 17
-// loc is the location that corresponds to endHour, e.g. America/Los_Angeles for PST
-//
-// The returned time will be in UTC
-//
-// If days=1, then we allow
-// fires each day, so the most recent fire will be at the
-// end of the previous workday. For example:
-//
-//  days: 1
-//  endHour: 17 (i.e. work day ends at 5PM local time)
-//  loc:  America/Los_Angeles (PST)
-//  chrono.Now(): Wed, Dec. 16, 2015 2:30 PM PST
-//  Output: Tue, Dec. 15, 2015 5:00 PM PST
-//
-//
-// If days=0, returns the the current date, with
-// the time set to endHour. For example:
-//
-//  days: 0
-//  endHour: 17 (i.e. work day ends at 5PM local time)
-//  loc:  America/Los_Angeles (PST)
-//  chrono.Now(): Wed, Dec. 16, 2015 2:30 PM PST
-//  Output: Wed, Dec. 16, 2015 5:00 PM PST
-//
-// noFiresSince returns the a datetime that is the last allowed time that a fire
-// is permitted to have happened.
-func noFiresSince(days int, now time.Time, endHour int, loc *time.Location) (time.Time, error) {
-	if days < 0 {
-		return time.Time{}, errors.Errorf("noFiresSince passed illegal input: days=%d", days)
-	}
-
-	oneDay := time.Hour * 24
-
-	// Tail-recursive helper function reads clearer than writing a
-	// traditional loop
-	//
-	// It expects a time localized to the zone associated with endHour because
-	// workday and year-month-day values depend on the local timezone
-	var helper func(N int, tInLoc time.Time) time.Time
-
-	helper = func(N int, tInLoc time.Time) time.Time {
-		switch {
-		case !cal.IsWorkday(tInLoc):
-			return helper(N, tInLoc.Add(-oneDay))
-		case N == 0:
-			return time.Date(tInLoc.Year(), tInLoc.Month(), tInLoc.Day(), endHour, 0, 0, 0, loc).UTC()
-		default:
-			return helper(N-1, tInLoc.Add(-oneDay))
-		}
-	}
-
-	return helper(days, now.In(loc)), nil
-}
-
 func recordTermination(tx *sql.Tx, term elon.Termination, loc *time.Location) (err error) {
 
 	i := term.employee
 
-	_, err = tx.Exec("INSERT INTO terminations (app, account, stack, team, region, asg, employee_id, fired_at, leashed) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-		i.TeamName(), i.AccountName(), i.StackName(), i.TeamName(), i.RegionName(), i.ASGName(), i.ID(), term.Time.In(time.UTC), term.Leashed)
+	_, err = tx.Exec("INSERT INTO terminations (app, account, stack, team, region, asg, employee_id, fired_at, leashed, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		i.TeamName(), i.AccountName(), i.StackName(), i.TeamName(), i.RegionName(), i.ASGName(), i.ID(), term.Time.In(time.UTC), term.Leashed, elon.Pending)
 
 	return err
 }
@@ -98,7 +98,7 @@ func TestPublishRetrieveMultipleEntries(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	psched := schedule.New()
+	psched := schedule.NewWithSeed(42)
 
 	pEntries := []schedule.Entry{
 		{Time: time.Date(2016, time.June, 20, 11, 40, 0, 0, loc), Group: grp.New("doesnotexist", "test", "us-east-1", "", "doesnotexist-foo-bar")},
@@ -132,6 +132,10 @@ func TestPublishRetrieveMultipleEntries(t *testing.T) {
 			t.Errorf("got entry[%d]=%v, want %v", i, got, want)
 		}
 	}
+
+	if got, want := rsched.Seed(), int64(42); got != want {
+		t.Errorf("got rsched.Seed()=%d, want %d", got, want)
+	}
 }
 
 func TestScheduleAlreadyExists(t *testing.T) {
@@ -0,0 +1,283 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outage
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+)
+
+// alertmanager is an outage checker that queries the alerts API v2 of
+// Prometheus Alertmanager. There is an outage when an active alert that isn't
+// silenced or inhibited matches all the label matchers of any of the sets of
+// matchers.
+//
+// It is an elon.ScopedOutage: with an account or region label, an alert only
+// affects the account or region in its label. Alerts without the label
+// affect all of them.
+type alertmanager struct {
+	url          string
+	matchers     [][]matcher
+	accountLabel string
+	regionLabel  string
+	client       *http.Client
+}
+
+// alert is an alert of the response of the alerts API
+type alert struct {
+	Labels map[string]string `json:"labels"`
+	Status struct {
+		// State is "active", or "suppressed" if the alert is silenced or
+		// inhibited
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+// matcher matches the value of a label, like the label matchers of
+// Alertmanager: op is "=", "!=", "=~" or "!~". A missing label has a blank
+// value.
+type matcher struct {
+	name  string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// newAlertmanager returns an alertmanager outage checker configured by cfg
+func newAlertmanager(cfg *config.Monkey) (alertmanager, error) {
+	if cfg.AlertmanagerURL() == "" {
+		return alertmanager{}, errors.Errorf("%s not specified", param.AlertmanagerURL)
+	}
+
+	sets, err := cfg.AlertmanagerMatchers()
+	if err != nil {
+		return alertmanager{}, err
+	}
+
+	if len(sets) == 0 {
+		return alertmanager{}, errors.Errorf("%s not specified", param.AlertmanagerMatchers)
+	}
+
+	var matchers [][]matcher
+	for _, s := range sets {
+		m, err := parseMatchers(s)
+		if err != nil {
+			return alertmanager{}, errors.Wrapf(err, "invalid %s", param.AlertmanagerMatchers)
+		}
+		matchers = append(matchers, m)
+	}
+
+	return alertmanager{
+		url:          strings.TrimSuffix(cfg.AlertmanagerURL(), "/"),
+		matchers:     matchers,
+		accountLabel: cfg.AlertmanagerAccountLabel(),
+		regionLabel:  cfg.AlertmanagerRegionLabel(),
+		client:       &http.Client{Timeout: cfg.AlertmanagerTimeout()},
+	}, nil
+}
+
+// parseMatchers parses a comma-separated list of label matchers, e.g.
+// `severity=critical,team=~"payments|checkout"`. Values that contain commas
+// must be quoted, e.g. `instance=~"db-[0-9]{1,3}"`.
+func parseMatchers(s string) ([]matcher, error) {
+	var result []matcher
+	for _, m := range splitMatchers(s) {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+
+		i := strings.IndexAny(m, "=!")
+		if i <= 0 {
+			return nil, errors.Errorf("invalid matcher: %s", m)
+		}
+
+		var op string
+		for _, o := range []string{"=~", "!~", "!=", "="} {
+			if strings.HasPrefix(m[i:], o) {
+				op = o
+				break
+			}
+		}
+
+		if op == "" {
+			return nil, errors.Errorf("invalid matcher: %s", m)
+		}
+
+		mt := matcher{
+			name:  strings.TrimSpace(m[:i]),
+			op:    op,
+			value: strings.Trim(strings.TrimSpace(m[i+len(op):]), `"`),
+		}
+
+		if op == "=~" || op == "!~" {
+			re, err := regexp.Compile("^(?:" + mt.value + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid regular expression in matcher: %s", m)
+			}
+			mt.re = re
+		}
+
+		result = append(result, mt)
+	}
+
+	if len(result) == 0 {
+		return nil, errors.Errorf("no matchers in %q", s)
+	}
+
+	return result, nil
+}
+
+// splitMatchers splits s at the commas that are outside of double quotes
+func splitMatchers(s string) []string {
+	var result []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			// skip the escaped character
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == ',' && !quoted:
+			result = append(result, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(result, s[start:])
+}
+
+// matches returns true if m matches labels
+func (m matcher) matches(labels map[string]string) bool {
+	v := labels[m.name]
+	switch m.op {
+	case "=":
+		return v == m.value
+	case "!=":
+		return v != m.value
+	case "=~":
+		return m.re.MatchString(v)
+	default:
+		return !m.re.MatchString(v)
+	}
+}
+
+// Outage implements elon.Outage.Outage, it reports an outage if any alert
+// that matches is firing, whichever account or region it affects. If
+// Alertmanager can't be queried, it reports an outage along with the error,
+// so that nothing is terminated.
+func (a alertmanager) Outage() (bool, error) {
+	return a.outage(func(labels map[string]string) bool { return true })
+}
+
+// OutageIn implements elon.ScopedOutage.OutageIn
+func (a alertmanager) OutageIn(account string, region string) (bool, error) {
+	return a.outage(func(labels map[string]string) bool {
+		return affects(labels, a.accountLabel, account) && affects(labels, a.regionLabel, region)
+	})
+}
+
+// affects returns true if an alert with labels affects value, e.g. an
+// account, that is held by label. Alerts affect all values if label is
+// blank, or if they don't have it. A blank value stands for any value.
+func affects(labels map[string]string, label string, value string) bool {
+	if label == "" || value == "" {
+		return true
+	}
+
+	v := labels[label]
+	return v == "" || v == value
+}
+
+// outage returns true if an alert that matches, and that affected returns
+// true for, is firing
+func (a alertmanager) outage(affected func(labels map[string]string) bool) (bool, error) {
+	alerts, err := a.alerts()
+	if err != nil {
+		return true, err
+	}
+
+	for _, al := range alerts {
+		if al.Status.State != "active" || !a.matches(al.Labels) || !affected(al.Labels) {
+			continue
+		}
+
+		log.Printf("outage: alert %s is firing: %v", al.Labels["alertname"], al.Labels)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// matches returns true if labels match all the matchers of any set
+func (a alertmanager) matches(labels map[string]string) bool {
+	for _, set := range a.matchers {
+		all := true
+		for _, m := range set {
+			if !m.matches(labels) {
+				all = false
+				break
+			}
+		}
+
+		if all {
+			return true
+		}
+	}
+
+	return false
+}
+
+// alerts returns the active alerts that aren't silenced or inhibited
+func (a alertmanager) alerts() ([]alert, error) {
+	query := url.Values{}
+	query.Set("active", "true")
+	query.Set("silenced", "false")
+	query.Set("inhibited", "false")
+	u := a.url + "/api/v2/alerts?" + query.Encode()
+
+	resp, err := a.client.Get(u)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not query alertmanager at %s", a.url)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil, errors.Errorf("alertmanager at %s responded with status %d", a.url, resp.StatusCode)
+	}
+
+	var alerts []alert
+	err = json.NewDecoder(resp.Body).Decode(&alerts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decode alerts of alertmanager at %s", a.url)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outage
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// alertmanagerServer returns a test server that serves alerts, the JSON
+// response of the alerts API
+func alertmanagerServer(t *testing.T, alerts string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Path, "/api/v2/alerts"; got != want {
+			t.Errorf("got path=%s, want %s", got, want)
+		}
+
+		for param, want := range map[string]string{"active": "true", "silenced": "false", "inhibited": "false"} {
+			if got := r.URL.Query().Get(param); got != want {
+				t.Errorf("got %s=%s, want %s", param, got, want)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, alerts)
+	}))
+}
+
+func testAlertmanager(t *testing.T, url string, sets ...string) alertmanager {
+	var matchers [][]matcher
+	for _, s := range sets {
+		m, err := parseMatchers(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matchers = append(matchers, m)
+	}
+
+	return alertmanager{url: url, matchers: matchers, accountLabel: "account", regionLabel: "region", client: http.DefaultClient}
+}
+
+const testAlerts = `[
+  {"labels": {"alertname": "HighLatency", "severity": "warning"}, "status": {"state": "active", "silencedBy": [], "inhibitedBy": []}},
+  {"labels": {"alertname": "Maintenance", "severity": "critical"}, "status": {"state": "suppressed", "silencedBy": ["5d0c9b2e"], "inhibitedBy": []}},
+  {"labels": {"alertname": "ReplicaDown", "severity": "page"}, "status": {"state": "suppressed", "silencedBy": [], "inhibitedBy": ["9f1d2a7c"]}},
+  {"labels": {"alertname": "Incident", "incident": "true", "account": "prod", "region": "us-east-1"}, "status": {"state": "active", "silencedBy": [], "inhibitedBy": []}}
+]`
+
+func TestAlertmanagerOutage(t *testing.T) {
+	ts := alertmanagerServer(t, testAlerts)
+	defer ts.Close()
+
+	tests := []struct {
+		matchers []string
+		want     bool
+	}{
+		// The critical alert is silenced
+		{[]string{"severity=critical"}, false},
+		{[]string{"severity=critical", "incident=true"}, true},
+		// The page is inhibited
+		{[]string{"severity=page"}, false},
+		{[]string{"severity=~warning|critical"}, true},
+		{[]string{"severity=warning,alertname!=HighLatency"}, false},
+		{[]string{`incident="true",alertname!~"High.*"`}, true},
+	}
+
+	for _, tt := range tests {
+		a := testAlertmanager(t, ts.URL, tt.matchers...)
+
+		got, err := a.Outage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%q: got %t, want %t", tt.matchers, got, tt.want)
+		}
+	}
+}
+
+// TestAlertmanagerOutageIn verifies that an alert only affects the account
+// and region in its labels
+func TestAlertmanagerOutageIn(t *testing.T) {
+	ts := alertmanagerServer(t, testAlerts)
+	defer ts.Close()
+
+	tests := []struct {
+		matchers []string
+		account  string
+		region   string
+		want     bool
+	}{
+		{[]string{"incident=true"}, "prod", "us-east-1", true},
+		{[]string{"incident=true"}, "prod", "", true},
+		{[]string{"incident=true"}, "prod", "us-west-2", false},
+		{[]string{"incident=true"}, "test", "us-east-1", false},
+
+		// The alert has neither account nor region, so it affects all of them
+		{[]string{"severity=warning"}, "test", "us-west-2", true},
+	}
+
+	for _, tt := range tests {
+		a := testAlertmanager(t, ts.URL, tt.matchers...)
+
+		got, err := a.OutageIn(tt.account, tt.region)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("%q in account=%s region=%s: got %t, want %t", tt.matchers, tt.account, tt.region, got, tt.want)
+		}
+	}
+}
+
+// TestAlertmanagerFailures verifies that there is an outage when Alertmanager
+// can't be queried
+func TestAlertmanagerFailures(t *testing.T) {
+	unreachable := alertmanagerServer(t, testAlerts)
+	unreachable.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	invalid := alertmanagerServer(t, `{"error": "oops"}`)
+	defer invalid.Close()
+
+	for name, url := range map[string]string{
+		"unreachable": unreachable.URL,
+		"status 503":  failing.URL,
+		"invalid":     invalid.URL,
+	} {
+		a := testAlertmanager(t, url, "severity=critical")
+
+		got, err := a.OutageIn("prod", "us-east-1")
+		if err == nil {
+			t.Errorf("%s: got nil, want error", name)
+		}
+
+		if !got {
+			t.Errorf("%s: got no outage, want outage", name)
+		}
+	}
+}
+
+// TestParseMatchersQuotedCommas verifies that commas within quoted values
+// don't separate matchers
+func TestParseMatchersQuotedCommas(t *testing.T) {
+	m, err := parseMatchers(`instance=~"db-[0-9]{1,3}", team="payments,checkout",severity=critical`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(m), 3; got != want {
+		t.Fatalf("got %d matchers, want %d: %+v", got, want, m)
+	}
+
+	labels := map[string]string{"instance": "db-42", "team": "payments,checkout", "severity": "critical"}
+	for _, mt := range m {
+		if !mt.matches(labels) {
+			t.Errorf("%s%s%q: got no match of %v, want match", mt.name, mt.op, mt.value, labels)
+		}
+	}
+}
+
+func TestParseMatchersInvalid(t *testing.T) {
+	for _, s := range []string{"", "severity", "=critical", "severity~critical", "team=~(payments"} {
+		_, err := parseMatchers(s)
+		if err == nil {
+			t.Errorf("%q: got nil, want error", s)
+		}
+	}
+}
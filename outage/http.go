@@ -0,0 +1,162 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+)
+
+// httpOutage is an outage checker that queries a status endpoint over HTTP.
+//
+// With a JSON path, there is an outage if the field at that path of the
+// response is true, or is a string or number that isn't one of the OK values.
+// Without JSON path, there is an outage if the endpoint doesn't respond with
+// a 2xx status.
+type httpOutage struct {
+	url      string
+	path     []string
+	okValues []string
+	client   *http.Client
+}
+
+// newHTTP returns an http outage checker configured by cfg
+func newHTTP(cfg *config.Monkey) (httpOutage, error) {
+	if cfg.OutageURL() == "" {
+		return httpOutage{}, errors.Errorf("%s not specified", param.OutageURL)
+	}
+
+	okValues, err := cfg.OutageOKValues()
+	if err != nil {
+		return httpOutage{}, err
+	}
+
+	var path []string
+	if p := cfg.OutageJSONPath(); p != "" {
+		path = strings.Split(p, ".")
+	}
+
+	return httpOutage{
+		url:      cfg.OutageURL(),
+		path:     path,
+		okValues: okValues,
+		client:   &http.Client{Timeout: cfg.OutageTimeout()},
+	}, nil
+}
+
+// Outage implements elon.Outage.Outage. If the status endpoint can't be
+// reached, or its response can't be evaluated, it reports an outage along
+// with the error, so that nothing is terminated.
+func (h httpOutage) Outage() (bool, error) {
+	down, err := h.check()
+	if err != nil {
+		return true, err
+	}
+
+	return down, nil
+}
+
+// check queries the status endpoint and evaluates its response
+func (h httpOutage) check() (bool, error) {
+	resp, err := h.client.Get(h.url)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not reach status endpoint %s", h.url)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	ok := resp.StatusCode >= 200 && resp.StatusCode <= 299
+	if h.path == nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		if !ok {
+			log.Printf("outage: %s responded with status %d", h.url, resp.StatusCode)
+		}
+		return !ok, nil
+	}
+
+	if !ok {
+		return false, errors.Errorf("status endpoint %s responded with status %d", h.url, resp.StatusCode)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+
+	var doc interface{}
+	err = dec.Decode(&doc)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not decode response of status endpoint %s", h.url)
+	}
+
+	value, err := lookup(doc, h.path)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid response of status endpoint %s", h.url)
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string, json.Number:
+		s := fmt.Sprint(v)
+		for _, okValue := range h.okValues {
+			if s == okValue {
+				return false, nil
+			}
+		}
+		log.Printf("outage: %s of %s is %q", strings.Join(h.path, "."), h.url, s)
+		return true, nil
+	default:
+		return false, errors.Errorf("%s of %s is not a bool, string or number: %v", strings.Join(h.path, "."), h.url, value)
+	}
+}
+
+// lookup returns the value at path in doc, a decoded JSON document. The
+// elements of arrays are referred to by their index, e.g. "components.0".
+func lookup(doc interface{}, path []string) (interface{}, error) {
+	for i, key := range path {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			val, ok := v[key]
+			if !ok {
+				return nil, errors.Errorf("no %s", strings.Join(path[:i+1], "."))
+			}
+			doc = val
+		case []interface{}:
+			n, err := strconv.Atoi(key)
+			if err != nil || n < 0 || n >= len(v) {
+				return nil, errors.Errorf("no %s", strings.Join(path[:i+1], "."))
+			}
+			doc = v[n]
+		default:
+			name := "response"
+			if i > 0 {
+				name = strings.Join(path[:i], ".")
+			}
+			return nil, errors.Errorf("%s is not an object or array", name)
+		}
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outage
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statusServer returns a test server that responds with status and body
+func statusServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = fmt.Fprint(w, body)
+	}))
+}
+
+func TestHTTPOutageJSONPath(t *testing.T) {
+	tests := []struct {
+		body string
+		path string
+		want bool
+	}{
+		{`{"status": {"indicator": "none", "description": "All Systems Operational"}}`, "status.indicator", false},
+		{`{"status": {"indicator": "major", "description": "Partial System Outage"}}`, "status.indicator", true},
+		{`{"outage": false}`, "outage", false},
+		{`{"outage": true}`, "outage", true},
+		{`{"incidents": 0}`, "incidents", false},
+		{`{"incidents": 2}`, "incidents", true},
+		{`{"components": [{"status": "ok"}, {"status": "degraded"}]}`, "components.0.status", false},
+		{`{"components": [{"status": "ok"}, {"status": "degraded"}]}`, "components.1.status", true},
+	}
+
+	for _, tt := range tests {
+		ts := statusServer(http.StatusOK, tt.body)
+		h := httpOutage{url: ts.URL, path: strings.Split(tt.path, "."), okValues: []string{"none", "ok", "0"}, client: http.DefaultClient}
+
+		got, err := h.Outage()
+		ts.Close()
+		if err != nil {
+			t.Errorf("%s of %s: %v", tt.path, tt.body, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("%s of %s: got %t, want %t", tt.path, tt.body, got, tt.want)
+		}
+	}
+}
+
+// TestHTTPOutageStatus verifies that, without JSON path, only a 2xx status
+// means there is no outage
+func TestHTTPOutageStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNoContent, false},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusNotFound, true},
+	}
+
+	for _, tt := range tests {
+		ts := statusServer(tt.status, "")
+		h := httpOutage{url: ts.URL, client: http.DefaultClient}
+
+		got, err := h.Outage()
+		ts.Close()
+		if err != nil {
+			t.Errorf("status %d: %v", tt.status, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("status %d: got %t, want %t", tt.status, got, tt.want)
+		}
+	}
+}
+
+// TestHTTPOutageFailures verifies that there is an outage when the status
+// can't be determined
+func TestHTTPOutageFailures(t *testing.T) {
+	unreachable := statusServer(http.StatusOK, "")
+	unreachable.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	failing := statusServer(http.StatusInternalServerError, `{"status": {"indicator": "none"}}`)
+	defer failing.Close()
+
+	invalid := statusServer(http.StatusOK, `<html>`)
+	defer invalid.Close()
+
+	missing := statusServer(http.StatusOK, `{"status": {}}`)
+	defer missing.Close()
+
+	object := statusServer(http.StatusOK, `{"status": {"indicator": {}}}`)
+	defer object.Close()
+
+	client := &http.Client{Timeout: 10 * time.Millisecond}
+	for name, url := range map[string]string{
+		"unreachable":   unreachable.URL,
+		"timeout":       slow.URL,
+		"status 500":    failing.URL,
+		"invalid json":  invalid.URL,
+		"missing field": missing.URL,
+		"not a value":   object.URL,
+	} {
+		h := httpOutage{url: url, path: []string{"status", "indicator"}, okValues: []string{"none"}, client: client}
+
+		got, err := h.Outage()
+		if err == nil {
+			t.Errorf("%s: got nil, want error", name)
+		}
+
+		if !got {
+			t.Errorf("%s: got no outage, want outage", name)
+		}
+	}
+}
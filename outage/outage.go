@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package outage provides a default no-op outage implementation
+// Package outage provides the outage checkers, and a default no-op one
 package outage
 
 import (
@@ -34,11 +34,17 @@ func init() {
 	deps.GetOutage = GetOutage
 }
 
-// GetOutage returns a do-nothing outage checker
+// GetOutage returns the outage checker specified in the configuration, or a
+// do-nothing outage checker if none is
 func GetOutage(cfg *config.Monkey) (elon.Outage, error) {
-	checker := cfg.OutageChecker()
-	if checker != "" {
+	switch checker := cfg.OutageChecker(); checker {
+	case "":
+		return NullOutage{}, nil
+	case "http":
+		return newHTTP(cfg)
+	case "alertmanager":
+		return newAlertmanager(cfg)
+	default:
 		return nil, errors.Errorf("unknown outage provider: %s", checker)
 	}
-	return NullOutage{}, nil
 }
@@ -0,0 +1,458 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package postgres implements a PostgreSQL-backed store for schedules and
+// terminations
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/rubenv/sql-migrate"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/cal"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/migration"
+	"github.com/FakeTwitter/elon/schedstore"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// Postgres represents a PostgreSQL-backed store for schedules and terminations
+type Postgres struct {
+	db *sql.DB
+
+	// calendars determine the work days used for min time between fires
+	calendars cal.Regional
+}
+
+// TxSerializationFailure returns true if the error is because a serializable
+// transaction could not be committed due to a concurrent transaction.
+// This is the PostgreSQL equivalent of a MySQL transaction deadlock.
+func TxSerializationFailure(err error) bool {
+	switch err := errors.Cause(err).(type) {
+	case *pq.Error:
+		// serialization_failure and deadlock_detected
+		// See: https://www.postgresql.org/docs/current/errcodes-appendix.html
+		return err.Code == "40001" || err.Code == "40P01"
+	default:
+		return false
+	}
+}
+
+// ViolatesMinTime returns true if the error violates min time between
+// terminations
+func ViolatesMinTime(err error) bool {
+	_, ok := errors.Cause(err).(elon.ErrViolatesMinTime)
+	return ok
+}
+
+// NewFromConfig creates a new Postgres taking config parameters from cfg
+func NewFromConfig(cfg *config.Monkey) (Postgres, error) {
+
+	if cfg.DatabaseHost() == "" {
+		return Postgres{}, errors.Errorf("%s not specified", param.DatabaseHost)
+	}
+
+	encryptedPassword := cfg.DatabaseEncryptedPassword()
+
+	decryptor, err := deps.GetDecryptor(cfg)
+	if err != nil {
+		return Postgres{}, err
+	}
+
+	password, err := decryptor.Decrypt(encryptedPassword)
+	if err != nil {
+		return Postgres{}, err
+	}
+
+	calendars, err := cal.NewFromConfig(cfg)
+	if err != nil {
+		return Postgres{}, err
+	}
+
+	p, err := New(cfg.DatabaseHost(), cfg.DatabasePort(), cfg.DatabaseUser(), password, cfg.DatabaseName())
+	if err != nil {
+		return Postgres{}, err
+	}
+
+	p.calendars = calendars
+	return p, nil
+}
+
+// New creates a new Postgres that uses the default calendar of work days
+func New(host string, port int, user string, password string, dbname string) (Postgres, error) {
+	db, err := sql.Open("postgres", dsn(host, port, user, password, dbname))
+	if err != nil {
+		return Postgres{}, errors.Wrap(err, "sql.Open failed")
+	}
+
+	return Postgres{db: db}, nil
+}
+
+// Close closes the underlying sql.DB
+func (p Postgres) Close() error {
+	return p.db.Close()
+}
+
+// dsn returns a PostgreSQL connection URL (data source name)
+// See: https://godoc.org/github.com/lib/pq#hdr-Connection_String_Parameters
+func dsn(host string, port int, user string, password string, dbname string) string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(user, password),
+		Host:   fmt.Sprintf("%s:%d", host, port),
+		Path:   "/" + dbname,
+	}
+
+	q := url.Values{}
+	q.Set("timezone", "UTC") // TIMESTAMP values are interpreted as being in UTC
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+// begin starts a serializable transaction. We need serializable transactions
+// for atomic test & set behavior
+func (p Postgres) begin() (*sql.Tx, error) {
+	return p.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+}
+
+// utcDate takes a time.Time in a local time zone and returns a time.Time
+// that has the same year/month/day as date, but is in UTC, at 12 PM
+// We use this to work with DATE entries without having to worry about
+// the database changing the value due to time conversion
+func utcDate(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+// Retrieve  retrieves the schedule for the given date
+// The seed of the schedule is zero if none was recorded.
+func (p Postgres) Retrieve(date time.Time) (sched *schedule.Schedule, err error) {
+	var seed int64
+	err = p.db.QueryRow("SELECT seed FROM schedule_seeds WHERE date = $1::date", utcDate(date)).Scan(&seed)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "failed to retrieve schedule seed for %s", date)
+	}
+
+	rows, err := p.db.Query("SELECT time, app, account, region, stack, team FROM schedules WHERE date = $1::date", utcDate(date))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve schedule for %s", date)
+	}
+
+	sched = schedule.NewWithSeed(seed)
+
+	defer func() {
+		if cerr := rows.Close(); cerr != nil && err == nil {
+			err = errors.Wrap(cerr, "rows.Close() failed")
+		}
+	}()
+
+	for rows.Next() {
+		var tm time.Time
+		var app, account, region, stack, team string
+
+		err = rows.Scan(&tm, &app, &account, &region, &stack, &team)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+
+		sched.Add(tm.UTC(), grp.New(app, account, region, stack, team))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, errors.Wrap(err, "rows.Err() errored")
+	}
+
+	return sched, nil
+
+}
+
+// Publish publishes the schedule for the given date
+func (p Postgres) Publish(date time.Time, sched *schedule.Schedule) error {
+	return p.PublishWithDelay(date, sched, 0)
+}
+
+// PublishWithDelay publishes the schedule with a delay between checking the schedule
+// exists and writing it. The delay is used only for testing race conditions
+func (p Postgres) PublishWithDelay(date time.Time, sched *schedule.Schedule, delay time.Duration) (err error) {
+	// First, we check to see if there is a schedule present
+	tx, err := p.begin()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	// We must either commit or rollback at the end
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit()
+		case schedstore.ErrAlreadyExists:
+			// We want to return ErrAlreadyExists even if the transaction commit
+			// fails
+			_ = tx.Commit()
+		default:
+			_ = tx.Rollback()
+		}
+	}()
+
+	exists, err := schedExists(tx, date)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return schedstore.ErrAlreadyExists
+	}
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	query := "INSERT INTO schedules (date, time, app, account, region, stack, team) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return errors.Wrapf(err, "failed to prepare sql statement: %s", query)
+	}
+
+	for _, entry := range sched.Entries() {
+		var region, stack, team string
+		app := entry.Group.Team()
+		account := entry.Group.Account()
+		if val, ok := entry.Group.Region(); ok {
+			region = val
+		}
+		if val, ok := entry.Group.Stack(); ok {
+			stack = val
+		}
+		if val, ok := entry.Group.Team(); ok {
+			team = val
+		}
+
+		_, err = stmt.Exec(utcDate(date), entry.Time.In(time.UTC), app, account, region, stack, team)
+		if err != nil {
+			return errors.Wrapf(err, "failed to execute prepared query")
+		}
+	}
+
+	// An empty schedule has no rows, so it may be published again
+	_, err = tx.Exec("INSERT INTO schedule_seeds (date, seed) VALUES ($1::date, $2) ON CONFLICT (date) DO UPDATE SET seed = EXCLUDED.seed", utcDate(date), sched.Seed())
+	if err != nil {
+		return errors.Wrap(err, "failed to record schedule seed")
+	}
+
+	return nil
+}
+
+// schedExists returns true if a schedule has previously been
+// published for this date
+func schedExists(tx *sql.Tx, date time.Time) (bool, error) {
+	var count int
+	err := tx.QueryRow("SELECT COUNT(*) FROM schedules WHERE date = $1::date", utcDate(date)).Scan(&count)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check if schedule exists for %s", date)
+	}
+
+	return (count > 0), nil
+}
+
+// Check checks if a termination is permitted and, if so, records the
+// termination time on the team
+func (p Postgres) Check(term elon.Termination, appCfg elon.TeamConfig, endHour int, loc *time.Location) error {
+	return p.CheckWithDelay(term, appCfg, endHour, loc, 0)
+}
+
+// CheckWithDelay is the same as Check, but adds a delay between reading and
+// writing to the database (used for testing only)
+func (p Postgres) CheckWithDelay(term elon.Termination, appCfg elon.TeamConfig, endHour int, loc *time.Location, delay time.Duration) (err error) {
+	tx, err := p.begin()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit()
+		default:
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = respectsMinTimeBetweenFires(tx, p.calendars.ForRegion(term.employee.RegionName()), term.Time, term, appCfg, endHour, loc)
+	if err != nil {
+		return err
+	}
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	err = recordTermination(tx, term)
+	return err
+
+}
+
+// Complete records the outcome of a termination that was permitted by Check.
+// The termination is identified by its employee and its time, which the
+// database may have rounded to the second.
+func (p Postgres) Complete(term elon.Termination, outcome elon.Outcome, ref string, message string) error {
+	t := term.Time.In(time.UTC)
+	res, err := p.db.Exec("UPDATE terminations SET status = $1, task_ref = $2, error_message = $3 WHERE employee_id = $4 AND status = $5 AND fired_at BETWEEN $6 AND $7",
+		outcome, ref, truncate(message, maxErrorMessage), term.employee.ID(), elon.Pending, t.Add(-time.Second), t.Add(time.Second))
+	if err != nil {
+		return errors.Wrapf(err, "failed to record outcome of termination of %s", term.employee.ID())
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve number of updated terminations")
+	}
+
+	if n == 0 {
+		return errors.Errorf("no pending termination of %s at %s", term.employee.ID(), term.Time)
+	}
+
+	return nil
+}
+
+// maxErrorMessage is the size of the error_message column of terminations
+const maxErrorMessage = 2048
+
+// truncate returns the first n characters of s
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
+
+// respectsMinTimeBetweenFires checks if this termination will respect or
+// violate the min time between fires value. If this termination is too close
+// to the most recent one, this will return an error.
+// If this termination would violate the min time, returns an ErrViolatesMinTime
+func respectsMinTimeBetweenFires(tx *sql.Tx, c cal.Calendar, now time.Time, term elon.Termination, appCfg elon.TeamConfig, endHour int, loc *time.Location) (err error) {
+	app := term.employee.TeamName()
+	account := term.employee.AccountName()
+	threshold, err := cal.NoFiresSince(c, appCfg.MinTimeBetweenFiresInWorkDays, now, endHour, loc)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{app, account, threshold.In(time.UTC)}
+
+	// placeholder returns the positional parameter for the next argument
+	placeholder := func() string {
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	query := "SELECT employee_id, fired_at FROM terminations WHERE app = $1 AND account = $2 AND fired_at >= $3"
+
+	switch appCfg.Grouping {
+	case elon.Team:
+		// nothing to do
+	case elon.Stack:
+		args = append(args, term.employee.StackName())
+		query += " AND stack = " + placeholder()
+	case elon.Team:
+		args = append(args, term.employee.TeamName())
+		query += " AND team = " + placeholder()
+	default:
+		return errors.Errorf("unknown group: %v", appCfg.Grouping)
+	}
+
+	if appCfg.RegionsAreIndependent {
+		args = append(args, term.employee.RegionName())
+		query += " AND region = " + placeholder()
+	}
+
+	// For unleashed (real) terminations, we only care about previous
+	// terminations that were also unleashed. That's because a previous
+	// leashed termination wasn't a real one, so that wouldn't violate
+	// the min time between terminations
+	if !term.Leashed {
+		query += " AND leashed = FALSE"
+	}
+
+	// Failed terminations didn't fire anyone
+	query += " AND status <> 'failed'"
+
+	// We need at most one entry
+	query += " LIMIT 1"
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		cerr := rows.Close()
+		if err == nil && cerr != nil {
+			err = cerr
+		}
+	}()
+
+	if rows.Next() {
+		var employeeID string
+		var firedAt time.Time
+		err = rows.Scan(&employeeID, &firedAt)
+		if err != nil {
+			return errors.Wrap(err, "failed to scan row")
+		}
+		return elon.ErrViolatesMinTime{EmployeeId: employeeID, FiredAt: firedAt.UTC(), Loc: loc}
+	}
+
+	return rows.Err()
+}
+
+func recordTermination(tx *sql.Tx, term elon.Termination) error {
+	i := term.employee
+
+	_, err := tx.Exec("INSERT INTO terminations (app, account, stack, team, region, asg, employee_id, fired_at, leashed, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		i.TeamName(), i.AccountName(), i.StackName(), i.TeamName(), i.RegionName(), i.ASGName(), i.ID(), term.Time.In(time.UTC), term.Leashed, elon.Pending)
+
+	return err
+}
+
+var migrationSource = &migrate.AssetMigrationSource{
+	Asset:    migration.Asset,
+	AssetDir: migration.AssetDir,
+	Dir:      "migration/postgres",
+}
+
+var databaseDialect = "postgres"
+
+// Migrate upgrades a database to the latest database schema version.
+func Migrate(pg Postgres) error {
+	migrationCount, err := migrate.Exec(pg.db, databaseDialect, migrationSource, migrate.Up)
+	if err != nil {
+		return errors.Wrap(err, "database migration failed")
+	}
+	log.Println("Successfully applied database migrations. Number of migrations applied: ", migrationCount)
+
+	return nil
+}
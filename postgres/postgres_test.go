@@ -0,0 +1,183 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build docker
+
+// The tests in this package use docker to test against a postgres:9.6 database
+// By default, the tests are off unless you pass the "-tags docker" flag
+// when running the test.
+//
+// By default, TestMain starts up a new postgres Docker container. However, if
+// you already have a postgres docker container running, you can skip this by
+// also passing the "dockerup" flag: -tags "docker dockerup"
+
+package postgres_test
+
+import (
+	"bufio"
+	"database/sql"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon/postgres"
+)
+
+var (
+	dbName   string = "elon"
+	password string = "password"
+	port     int    = 5432
+)
+
+// inUse returns true if port accepts connections on localhsot
+func inUse(port int) bool {
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+
+	conn.Close()
+	return true
+}
+
+func TestMain(m *testing.M) {
+	var alwaysUp bool
+	flag.BoolVar(&alwaysUp, "dockerup", false, "if true, won't start docker")
+	flag.Parse()
+
+	var cmd *exec.Cmd
+	var err error
+
+	if !alwaysUp {
+		// Check to make sure the port isn't already in use
+		if inUse(port) {
+			panic(fmt.Sprintf("can't start postgres container: port %d currently in use", port))
+		}
+		cmd, err = startPostgresContainer()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	r := m.Run()
+
+	if !alwaysUp {
+		// Send a SIGTERM once we're done so postgres container shuts down
+		cmd.Process.Signal(syscall.SIGTERM)
+
+		// Wait for container to finish shutting down
+		cmd.Wait()
+	}
+
+	os.Exit(r)
+}
+
+// startPostgresContainer starts a PostgreSQL docker container
+// Returns the Cmd object associated with the process
+func startPostgresContainer() (*exec.Cmd, error) {
+	cmd := exec.Command("docker", "run", "-e", "POSTGRES_PASSWORD="+password, fmt.Sprintf("-p5432:%d", port), "postgres:9.6")
+	pipe, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan error)
+
+	readyString := "database system is ready to accept connections"
+
+	go func() {
+		reader := bufio.NewReader(pipe)
+
+		// The container restarts the server once after initialization, so
+		// we wait until we've seen the ready string twice
+		var s string
+		seen := 0
+
+		for seen < 2 {
+			s, err = reader.ReadString('\n')
+			if err != nil {
+				ch <- err
+				return
+			}
+			fmt.Print(s)
+			if strings.Contains(s, readyString) {
+				seen++
+			}
+		}
+
+		ch <- nil
+	}()
+
+	select {
+	case err := <-ch:
+		if err != nil {
+			return nil, err
+		}
+	case <-time.After(time.Second * 30):
+		// timeout.
+		return nil, errors.Errorf(`never saw "%s". (postgres container needs manual cleanup)`, readyString)
+	}
+
+	return cmd, nil
+}
+
+// initDB initializes the "elon" database with the elon schemas
+// It wipes out any existing database database with the same name
+func initDB() error {
+	db, err := sql.Open("postgres", fmt.Sprintf("postgres://postgres:%s@127.0.0.1:%d/?sslmode=disable", password, port))
+	if err != nil {
+		return errors.Wrap(err, "sql.Open failed")
+	}
+	defer db.Close()
+
+	_, err = db.Exec("DROP DATABASE IF EXISTS " + dbName)
+	if err != nil {
+		return errors.Wrap(err, "drop database failed")
+	}
+
+	_, err = db.Exec("CREATE DATABASE " + dbName)
+	if err != nil {
+		return errors.Wrap(err, "create database failed")
+	}
+
+	pg, err := NewPostgres()
+	if err != nil {
+		return errors.Wrap(err, "postgres.New failed")
+	}
+	defer pg.Close()
+
+	err = postgres.Migrate(pg)
+	if err != nil {
+		return errors.Wrap(err, "database migration failed")
+	}
+
+	return nil
+}
+
+func NewPostgres() (postgres.Postgres, error) {
+	return postgres.New("localhost", port, "postgres", password, dbName)
+}
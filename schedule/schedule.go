@@ -19,21 +19,70 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"math/rand"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/blackout"
+	"github.com/FakeTwitter/elon/cal"
 	"github.com/FakeTwitter/elon/config"
 	"github.com/FakeTwitter/elon/deploy"
 	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/metrics"
 )
 
+// ErrAppsFailed is returned by Populate when some apps couldn't be retrieved
+// from the deployment. The other apps are scheduled.
+type ErrAppsFailed struct {
+	// Failed maps the names of the apps that couldn't be retrieved to the
+	// reason
+	Failed map[string]error
+
+	// Succeeded is the number of apps that were retrieved
+	Succeeded int
+}
+
+func (e ErrAppsFailed) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failures := make([]string, len(names))
+	for i, name := range names {
+		failures[i] = fmt.Sprintf("%s: %v", name, e.Failed[name])
+	}
+
+	return fmt.Sprintf("could not retrieve %d of %d apps: %s", len(e.Failed), len(e.Failed)+e.Succeeded, strings.Join(failures, "; "))
+}
+
+// AllFailed returns true if no app could be retrieved, i.e., the schedule is
+// empty because of the failures
+func (e ErrAppsFailed) AllFailed() bool {
+	return e.Succeeded == 0
+}
+
 // Populate populates the termination schedule with the random
 // terminations for a list of apps. If the specified list of apps is empty,
 // then it will
 func (s *Schedule) Populate(d deploy.Deployment, getter elon.TeamConfigGetter, chaosConfig *config.Monkey, apps []string) error {
+	return s.PopulateAt(time.Now(), d, getter, chaosConfig, apps)
+}
+
+// PopulateAt is like Populate, but it schedules the terminations of the
+// termination windows that haven't ended yet at now, instead of the current
+// time. This is used to simulate schedules over many days.
+//
+// If the deployment is a deploy.FailureReporter, the apps that couldn't be
+// retrieved are skipped, and reported in an ErrAppsFailed once the other apps
+// are scheduled.
+func (s *Schedule) PopulateAt(now time.Time, d deploy.Deployment, getter elon.TeamConfigGetter, chaosConfig *config.Monkey, apps []string) error {
 	c := make(chan *deploy.Team)
 
 	// If the caller explicitly a set of apps, use those
@@ -46,7 +95,30 @@ func (s *Schedule) Populate(d deploy.Deployment, getter elon.TeamConfigGetter, c
 		}
 	}
 
-	go d.Teams(c, apps)
+	cals, err := cal.NewFromConfig(chaosConfig)
+	if err != nil {
+		return fmt.Errorf("could not load calendars: %v", err)
+	}
+
+	blackouts, err := blackout.FromConfig(chaosConfig)
+	if err != nil {
+		return fmt.Errorf("could not load blackouts: %v", err)
+	}
+
+	var mu sync.Mutex
+	failed := make(map[string]error)
+
+	if fr, ok := d.(deploy.FailureReporter); ok {
+		go fr.TeamsWithFailures(c, apps, func(appName string, err error) {
+			log.Printf("WARNING: could not retrieve app=%s: %v", appName, err)
+			mu.Lock()
+			defer mu.Unlock()
+			failed[appName] = err
+		})
+	} else {
+		go d.Teams(c, apps)
+	}
+
 	i := 0 // number of apps already processed
 	for team := range c {
 		if i >= chaosConfig.MaxTeams() {
@@ -54,6 +126,7 @@ func (s *Schedule) Populate(d deploy.Deployment, getter elon.TeamConfigGetter, c
 		}
 
 		i++
+		metrics.AppsScanned.Inc()
 
 		cfg, err := getter.Get(app.Name())
 
@@ -61,7 +134,23 @@ func (s *Schedule) Populate(d deploy.Deployment, getter elon.TeamConfigGetter, c
 			log.Printf("WARNING: Could not retrieve config for app=%s. %s", app.Name(), err)
 			continue
 		}
-		doScheduleTeam(s, app, *cfg, chaosConfig)
+		doScheduleTeam(s, now, app, *cfg, chaosConfig, cals, blackouts, teamRand(s.seed, app.Name()))
+	}
+
+	// Drain the apps past MaxTeams, so that the goroutines retrieving them
+	// don't block forever
+	for range c {
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(failed) > 0 {
+		e := ErrAppsFailed{Failed: make(map[string]error, len(failed)), Succeeded: i}
+		for name, err := range failed {
+			e.Failed[name] = err
+		}
+		return e
 	}
 
 	return nil
@@ -78,38 +167,91 @@ func (s *Schedule) Entries() []Entry {
 }
 
 // doScheduleTeam populates the termination schedule for one team
-func doScheduleTeam(schedule *Schedule, team *deploy.Team, cfg elon.TeamConfig, chaosConfig *config.Monkey) {
+// Terminations are scheduled during the next termination window of the team
+// that hasn't ended yet at now. Nothing is scheduled if that window falls on a day
+// the team doesn't allow, and groups are not scheduled if it falls on a day
+// that isn't a work day in the calendar of their region. Terminations that
+// would fall in a blackout window are dropped. All random decisions are taken
+// with r.
+func doScheduleTeam(schedule *Schedule, now time.Time, team *deploy.Team, cfg elon.TeamConfig, chaosConfig *config.Monkey, cals cal.Regional, blackouts blackout.Blackouts, r *rand.Rand) {
 
 	if !cfg.Enabled {
 		log.Printf("app=%s disabled\n", app.Name())
 		return
 	}
 
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	startHour := chaosConfig.StartHour()
-	endHour := chaosConfig.EndHour()
 	location, err := chaosConfig.Location()
 
 	if err != nil {
 		panic(fmt.Sprintf("Could not get Location for time zone calculation: %s", err.Error()))
 	}
 
+	startHour, endHour, location := cfg.Window(chaosConfig.StartHour(), chaosConfig.EndHour(), location)
+	if endHour <= startHour {
+		log.Printf("app=%s invalid termination window: start hour %d is not before end hour %d", app.Name(), startHour, endHour)
+		return
+	}
+
+	day := nextWindowDay(now, endHour, location)
+	if !cfg.AllowsDay(day.Weekday()) {
+		log.Printf("app=%s does not allow terminations on %s\n", app.Name(), day.Weekday())
+		return
+	}
+
 	groups := app.EligibleemployeeGroups(cfg)
+	metrics.GroupsEligible.Add(float64(len(groups)))
 
 	if len(groups) == 0 {
 		log.Printf("app=%s no eligible employee groups", app.Name())
 	}
 
 	for _, group := range groups {
+		region, _ := group.Region()
+		if !cals.ForRegion(region).IsWorkday(day) {
+			log.Printf("%s %s not a work day, not scheduling\n", grp.String(group), day.Format("2006-01-02"))
+			continue
+		}
+
 		fire := shouldFireemployee(cfg.MeanTimeBetweenFiresInWorkDays, r)
 		log.Printf("%s mtbk=%d fire=%t\n", grp.String(group), cfg.MeanTimeBetweenFiresInWorkDays, fire)
 		if fire {
-			time := chooseTerminationTime(time.Now(), startHour, endHour, location)
-			schedule.Add(time, group)
+			tm := chooseTerminationTime(day, startHour, endHour, location, r)
+			if w, ok := blackouts.Active(tm, group.Team(), group.Account(), region); ok {
+				log.Printf("%s not scheduling at %s: %s\n", grp.String(group), tm.Format(time.RFC3339), w)
+				continue
+			}
+			schedule.Add(tm, group)
 		}
 	}
 }
 
+// teamRand returns the source of randomness for scheduling the team named
+// name. It only depends on the seed of the schedule and on the name, so the
+// decisions for a team are the same regardless of the order in which the
+// teams are scheduled.
+func teamRand(seed int64, name string) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+// nextWindowDay returns midnight, in location, of the day of the next
+// termination window that hasn't ended yet at now. That is today's date in
+// location, unless it is already past endHour there, in which case it is
+// tomorrow's date.
+//
+// This lets teams in time zones far from Elon's pick a termination time
+// during their own business hours.
+func nextWindowDay(now time.Time, endHour int, location *time.Location) time.Time {
+	local := now.In(location)
+	year, month, day := local.Date()
+	if local.Hour() >= endHour {
+		day++
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
+
 // chooseTerminationTime Randomly selects a time to terminate an employee
 // on the same date as now, between startHour:00 and endHour:00 in the same
 // timezone as location
@@ -119,7 +261,7 @@ func doScheduleTeam(schedule *Schedule, team *deploy.Team, cfg elon.TeamConfig,
 // the future
 //
 // now is passed as an argument to simplify testing
-func chooseTerminationTime(now time.Time, startHour int, endHour int, location *time.Location) time.Time {
+func chooseTerminationTime(now time.Time, startHour int, endHour int, location *time.Location, r *rand.Rand) time.Time {
 	if endHour <= startHour {
 		panic(fmt.Sprintf("ChooseTermination called with startHour <= endHour, startHour: %d. endHour: %d", startHour, endHour))
 	}
@@ -128,7 +270,6 @@ func chooseTerminationTime(now time.Time, startHour int, endHour int, location *
 	// pick a random one in there, and then add it to the start time as an
 	// offset
 	minutesInTimeInterval := (endHour - startHour) * 60
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	sample := r.Intn(minutesInTimeInterval)
 
 	// Convert the sample to duration in minutes
@@ -209,8 +350,25 @@ func (e *Entry) Equal(o *Entry) bool {
 // It takes as arguments:
 //  - the path to the termination executable
 //  - the account that should execute the job
+//  - the seed for the random selection of the employee to terminate
+// The returned string is not terminated by a newline.
+func (e *Entry) Crontab(termPath, account string, seed int64) string {
+	return crontab(e.Time, account, terminateCommand(termPath, e.Group, seed))
+}
+
+// WarningCrontab returns a command that warns of the termination of the
+// Entry, lead before it, in crontab format.
+// It takes as arguments:
+//  - the path to the warning executable
+//  - the account that should execute the job
+//  - how long before the termination the warning is sent
 // The returned string is not terminated by a newline.
-func (e *Entry) Crontab(termPath, account string) string {
+func (e *Entry) WarningCrontab(warnPath, account string, lead time.Duration) string {
+	return crontab(e.Time.Add(-lead), account, groupCommand(warnPath, e.Group))
+}
+
+// crontab returns a line of crontab that runs cmd as account at time t
+func crontab(t time.Time, account, cmd string) string {
 	// From https://en.wikipedia.org/wiki/Cron
 	// # * * * * *  account command to execute
 	// # │ │ │ │ │
@@ -220,14 +378,22 @@ func (e *Entry) Crontab(termPath, account string) string {
 	// # │ │ └─────────────── day of month (1 - 31)
 	// # │ └──────────────────── hour (0 - 23)
 	// # └───────────────────────── min (0 - 59)
-	t := e.Time.UTC()
-	return fmt.Sprintf("%d %d %d %d %d %s %s", t.Minute(), t.Hour(), t.Day(), t.Month(), t.Weekday(), account, terminateCommand(termPath, e.Group))
+	t = t.UTC()
+	return fmt.Sprintf("%d %d %d %d %d %s %s", t.Minute(), t.Hour(), t.Day(), t.Month(), t.Weekday(), account, cmd)
 }
 
 // terminateCommand returns the string for terminating an employee
-// given the path to the elon termination executable and an employee to terminate
-func terminateCommand(termPath string, group grp.employeeGroup) string {
-	cmd := fmt.Sprintf("%s %s %s", termPath, group.Team(), group.Account())
+// given the path to the elon termination executable, an employee group to
+// terminate from and the seed for selecting the employee
+func terminateCommand(termPath string, group grp.employeeGroup, seed int64) string {
+	return fmt.Sprintf("%s --seed=%d", groupCommand(termPath, group), seed)
+}
+
+// groupCommand returns the string for running the executable at path with
+// the arguments that identify an employee group. The termination and the
+// warning commands identify the group the same way.
+func groupCommand(path string, group grp.employeeGroup) string {
+	cmd := fmt.Sprintf("%s %s %s", path, group.Team(), group.Account())
 	if team, ok := group.Team(); ok {
 		cmd = fmt.Sprintf("%s --team=%s", cmd, team)
 	}
@@ -251,19 +417,45 @@ func logRedirect(logPath string) string {
 }
 
 // Schedule is a collection of termination entries.
+//
+// The random decisions that produce a schedule, and the random selection of
+// the employees that are terminated, all derive from the seed of the
+// schedule. A schedule can be reproduced exactly by populating a schedule with
+// the same seed, as long as the apps and their configs haven't changed.
 type Schedule struct {
 	entries []Entry
+	seed    int64
 }
 
-// New returns a new Schedule
+// New returns a new Schedule, seeded with the current time
 func New() *Schedule {
+	return NewWithSeed(time.Now().UnixNano())
+}
+
+// NewWithSeed returns a new Schedule with the given seed
+func NewWithSeed(seed int64) *Schedule {
 	return &Schedule{
 		// We need a zero-element slice instead of a nil slice so that
 		// it will JSON-marshall into '[ ]' instead of 'null'
-		make([]Entry, 0),
+		entries: make([]Entry, 0),
+		seed:    seed,
 	}
 }
 
+// Seed returns the seed of the schedule
+func (s *Schedule) Seed() int64 {
+	return s.seed
+}
+
+// EntrySeed returns the seed for selecting the employee to terminate for
+// entry e. It only depends on the seed of the schedule and on the group and
+// time of e.
+func (s *Schedule) EntrySeed(e Entry) int64 {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s %d", grp.String(e.Group), e.Time.Unix())
+	return s.seed ^ int64(h.Sum64())
+}
+
 // ByTime implements sort.Interface for []Entry based on the time field
 type ByTime []Entry
 
@@ -276,13 +468,32 @@ func (t ByTime) Less(i, j int) bool { return t[i].Time.Before(t[j].Time) }
 //  - the path to the executable that terminates an employee
 //  - the account that should execute the job
 func (s Schedule) Crontab(exPath string, account string) []byte {
+	return s.crontab(func(entry Entry) string {
+		return entry.Crontab(exPath, account, s.EntrySeed(entry))
+	})
+}
+
+// WarningCrontab returns a schedule of commands in crontab format that warn
+// of each termination, lead before it
+// It takes as arguments:
+//  - the path to the executable that warns of a termination
+//  - the account that should execute the job
+//  - how long before each termination the warning is sent
+func (s Schedule) WarningCrontab(warnPath string, account string, lead time.Duration) []byte {
+	return s.crontab(func(entry Entry) string {
+		return entry.WarningCrontab(warnPath, account, lead)
+	})
+}
+
+// crontab returns the lines of crontab that line returns for each entry
+func (s Schedule) crontab(line func(Entry) string) []byte {
 	var result bytes.Buffer
 
 	// In-place sort the entries before generating the table
 	sort.Sort(ByTime(s.entries))
 
 	for _, entry := range s.entries {
-		_, err := result.WriteString(entry.Crontab(exPath, account))
+		_, err := result.WriteString(line(entry))
 		if err != nil {
 			panic(fmt.Sprintf("Could not generate string with crontab: %s", err.Error()))
 		}
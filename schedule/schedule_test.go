@@ -16,11 +16,20 @@ package schedule_test
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/FakeTwitter/elon"
 	"github.com/FakeTwitter/elon/config"
 	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/deploy"
+	"github.com/FakeTwitter/elon/grp"
 	"github.com/FakeTwitter/elon/mock"
 	"github.com/FakeTwitter/elon/schedule"
 )
@@ -37,6 +46,9 @@ func TestPopulate(t *testing.T) {
 	cfg := config.Defaults()
 	cfg.Set(param.ScheduleEnabled, true)
 
+	// Every day is a work day, so the test doesn't depend on today's date
+	cfg.Set(param.CalendarWorkdays, allWeek)
+
 	// Code under test
 	err := s.Populate(d, getter, cfg, nil)
 
@@ -56,6 +68,305 @@ func TestPopulate(t *testing.T) {
 
 }
 
+// failingDeployment is a deployment that fails to retrieve some apps
+type failingDeployment struct {
+	deploy.Deployment
+	failing map[string]bool
+
+	// done, if not nil, is closed once every app has been sent
+	done chan struct{}
+}
+
+func (d failingDeployment) TeamsWithFailures(c chan<- *deploy.Team, appNames []string, failed func(appName string, err error)) {
+	defer close(c)
+	if d.done != nil {
+		defer close(d.done)
+	}
+
+	for _, name := range appNames {
+		if d.failing[name] {
+			failed(name, errors.New("server error"))
+			continue
+		}
+
+		app, _ := d.GetTeam(name)
+		c <- app
+	}
+}
+
+// TestPopulatePartialFailure verifies that the apps that could be retrieved
+// are scheduled, and the others are reported
+func TestPopulatePartialFailure(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Set(param.ScheduleEnabled, true)
+	cfg.Set(param.CalendarWorkdays, allWeek)
+
+	d := failingDeployment{Deployment: mock.Dep(), failing: map[string]bool{"bar": true}}
+
+	s := schedule.New()
+	err := s.Populate(d, new(mockConfigGetter), cfg, []string{"foo", "bar", "baz", "quux"})
+
+	failure, ok := err.(schedule.ErrAppsFailed)
+	if !ok {
+		t.Fatalf("got err=%v, want schedule.ErrAppsFailed", err)
+	}
+
+	if _, ok := failure.Failed["bar"]; !ok || len(failure.Failed) != 1 {
+		t.Errorf("got failure.Failed=%v, want bar only", failure.Failed)
+	}
+
+	if failure.AllFailed() {
+		t.Error("got failure.AllFailed()=true, want false")
+	}
+
+	if got, want := len(s.Entries()), 3; got != want {
+		t.Errorf("got len(s.Entries())=%d, want %d", got, want)
+	}
+
+	// When every app fails, nothing is scheduled
+	d.failing = map[string]bool{"foo": true, "bar": true, "baz": true, "quux": true}
+
+	s = schedule.New()
+	err = s.Populate(d, new(mockConfigGetter), cfg, []string{"foo", "bar", "baz", "quux"})
+
+	failure, ok = err.(schedule.ErrAppsFailed)
+	if !ok {
+		t.Fatalf("got err=%v, want schedule.ErrAppsFailed", err)
+	}
+
+	if !failure.AllFailed() {
+		t.Error("got failure.AllFailed()=false, want true")
+	}
+}
+
+// TestPopulateMaxTeams verifies that the apps past MaxTeams aren't scheduled,
+// and that retrieving them doesn't block
+func TestPopulateMaxTeams(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Set(param.ScheduleEnabled, true)
+	cfg.Set(param.CalendarWorkdays, allWeek)
+	cfg.Set(param.MaxTeams, 1)
+
+	d := failingDeployment{Deployment: mock.Dep(), done: make(chan struct{})}
+
+	s := schedule.New()
+	err := s.Populate(d, new(mockConfigGetter), cfg, []string{"foo", "bar", "baz", "quux"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(s.Entries()), 1; got != want {
+		t.Errorf("got len(s.Entries())=%d, want %d", got, want)
+	}
+
+	select {
+	case <-d.done:
+	case <-time.After(time.Second):
+		t.Error("apps past MaxTeams are still being retrieved")
+	}
+}
+
+// TestPopulateSeed verifies that schedules with the same seed are the same
+func TestPopulateSeed(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Set(param.ScheduleEnabled, true)
+	cfg.Set(param.CalendarWorkdays, allWeek)
+
+	s1 := schedule.NewWithSeed(42)
+	err := s1.Populate(mock.Dep(), new(mockConfigGetter), cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s2 := schedule.NewWithSeed(42)
+	err = s2.Populate(mock.Dep(), new(mockConfigGetter), cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := s2.Seed(), s1.Seed(); got != want {
+		t.Errorf("got s2.Seed()=%d, want %d", got, want)
+	}
+
+	// The apps of the mock deployment are not always in the same order
+	sort.Sort(schedule.ByTime(s1.Entries()))
+	sort.Sort(schedule.ByTime(s2.Entries()))
+
+	if got, want := len(s2.Entries()), len(s1.Entries()); got != want {
+		t.Fatalf("got len(s2.Entries())=%d, want %d", got, want)
+	}
+
+	for i := range s1.Entries() {
+		e1, e2 := s1.Entries()[i], s2.Entries()[i]
+		if !e2.Equal(&e1) {
+			t.Errorf("got entry[%d]=%v, want %v", i, e2, e1)
+		}
+
+		if got, want := s2.EntrySeed(e2), s1.EntrySeed(e1); got != want {
+			t.Errorf("got EntrySeed(entry[%d])=%d, want %d", i, got, want)
+		}
+	}
+}
+
+// TestPopulateSkipsHolidays verifies that nothing is scheduled on a holiday
+func TestPopulateSkipsHolidays(t *testing.T) {
+	s := schedule.New()
+	d := mock.Dep()
+	getter := new(mockConfigGetter)
+
+	cfg := config.Defaults()
+	cfg.Set(param.ScheduleEnabled, true)
+	cfg.Set(param.CalendarWorkdays, allWeek)
+
+	loc, err := cfg.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Today is a holiday
+	f, err := ioutil.TempFile("", "holidays")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	holidays := f.Name() + ".yaml"
+	defer os.Remove(holidays)
+
+	contents := fmt.Sprintf("holidays:\n  - %s\n", time.Now().In(loc).Format("2006-01-02"))
+	err = ioutil.WriteFile(holidays, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Set(param.CalendarHolidays, []string{holidays})
+
+	err = s.Populate(d, getter, cfg, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if got, want := len(s.Entries()), 0; got != want {
+		t.Errorf("got len(entries)=%d, want %d", got, want)
+	}
+}
+
+// TestPopulateSkipsBlackouts verifies that nothing is scheduled during a
+// blackout window, unless the window doesn't apply to the group
+func TestPopulateSkipsBlackouts(t *testing.T) {
+	s := schedule.New()
+	d := mock.Dep()
+	getter := new(mockConfigGetter)
+
+	cfg := config.Defaults()
+	cfg.Set(param.ScheduleEnabled, true)
+	cfg.Set(param.CalendarWorkdays, allWeek)
+
+	f, err := ioutil.TempFile("", "blackouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	// The blackout covers the next termination window, but only in prod
+	now := time.Now().UTC()
+	contents := fmt.Sprintf("blackouts:\n  - start: %s\n    end: %s\n    accounts: [prod]\n",
+		now.Add(-24*time.Hour).Format(time.RFC3339), now.Add(48*time.Hour).Format(time.RFC3339))
+	err = ioutil.WriteFile(f.Name(), []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Set(param.BlackoutsPath, f.Name())
+
+	err = s.Populate(d, getter, cfg, nil)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if got, want := len(s.Entries()), 1; got != want {
+		t.Fatalf("got len(entries)=%d, want %d", got, want)
+	}
+
+	if got, want := s.Entries()[0].Group.Account(), "test"; got != want {
+		t.Errorf("got account=%s, want %s", got, want)
+	}
+}
+
+// TestPopulateTeamWindow verifies that terminations are scheduled during the
+// next termination window of the team, in its own time zone
+func TestPopulateTeamWindow(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startHour, endHour := 10, 16
+	appCfg := elon.NewTeamConfig(nil)
+	appCfg.MeanTimeBetweenFiresInWorkDays = 1
+	appCfg.StartHour = &startHour
+	appCfg.EndHour = &endHour
+	appCfg.Location = paris
+	getter := windowConfigGetter{&appCfg}
+
+	cfg := config.Defaults()
+	cfg.Set(param.CalendarWorkdays, allWeek)
+
+	s := schedule.New()
+	err = s.Populate(mock.Dep(), getter, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(s.Entries()), 4; got != want {
+		t.Fatalf("got len(entries)=%d, want %d", got, want)
+	}
+
+	now := time.Now()
+	for _, e := range s.Entries() {
+		local := e.Time.In(paris)
+		if local.Hour() < startHour || local.Hour() >= endHour {
+			t.Errorf("got termination at %s, want between %d:00 and %d:00", local, startHour, endHour)
+		}
+
+		// The window must not have ended, and must be the next one
+		end := time.Date(local.Year(), local.Month(), local.Day(), endHour, 0, 0, 0, paris)
+		if !end.After(now) || end.Sub(now) > 24*time.Hour {
+			t.Errorf("got termination at %s, not in the next window after %s", local, now.In(paris))
+		}
+	}
+}
+
+// TestWarningCrontab verifies that the warning of each termination runs lead
+// before it, and identifies the group like the termination does
+func TestWarningCrontab(t *testing.T) {
+	s := schedule.NewWithSeed(1)
+	s.Add(time.Date(2015, time.October, 1, 17, 5, 0, 0, time.UTC), grp.New("foo", "prod", "us-east-1", "", "foo-prod"))
+
+	got := string(s.WarningCrontab("/apps/elon/elon-warn.sh", "root", 30*time.Minute))
+	want := "35 16 1 10 4 root /apps/elon/elon-warn.sh foo prod --team=foo-prod --region=us-east-1\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = string(s.Crontab("/apps/elon/elon-terminate.sh", "root"))
+	want = fmt.Sprintf("5 17 1 10 4 root /apps/elon/elon-terminate.sh foo prod --team=foo-prod --region=us-east-1 --seed=%d\n", s.EntrySeed(s.Entries()[0]))
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// windowConfigGetter returns the same config for every team
+type windowConfigGetter struct {
+	cfg *elon.TeamConfig
+}
+
+func (g windowConfigGetter) Get(app string) (*elon.TeamConfig, error) {
+	return g.cfg, nil
+}
+
+var allWeek = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
 // mockConfigGetter implements elon.Getter
 // returns configs for apps
 type mockConfigGetter struct {
@@ -0,0 +1,142 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/cal"
+	"github.com/FakeTwitter/elon/grp"
+)
+
+// memChecker is an in-memory implementation of elon.Checker. It enforces the
+// min time between terminations the same way as the database backends.
+type memChecker struct {
+	calendars    cal.Regional
+	terminations []elon.Termination
+}
+
+func newMemChecker(calendars cal.Regional) *memChecker {
+	return &memChecker{calendars: calendars}
+}
+
+// Check implements elon.Checker.Check
+func (m *memChecker) Check(term elon.Termination, appCfg elon.TeamConfig, endHour int, loc *time.Location) error {
+	c := m.calendars.ForRegion(term.employee.RegionName())
+	threshold, err := cal.NoFiresSince(c, appCfg.MinTimeBetweenFiresInWorkDays, term.Time, endHour, loc)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range m.terminations {
+		if t.Time.Before(threshold) {
+			continue
+		}
+
+		same, err := sameGroup(t, term, appCfg)
+		if err != nil {
+			return err
+		}
+
+		if same {
+			return elon.ErrViolatesMinTime{EmployeeId: t.employee.ID(), FiredAt: t.Time.UTC(), Loc: loc}
+		}
+	}
+
+	m.terminations = append(m.terminations, term)
+	return nil
+}
+
+// Complete implements elon.Checker.Complete. Simulated terminations are
+// never executed, so they all count toward the min time between terminations
+func (m *memChecker) Complete(term elon.Termination, outcome elon.Outcome, ref string, message string) error {
+	return nil
+}
+
+// sameGroup returns true if the previous termination t counts against term
+// for the purposes of the min time between fires
+func sameGroup(t elon.Termination, term elon.Termination, appCfg elon.TeamConfig) (bool, error) {
+	i, j := t.employee, term.employee
+
+	if i.TeamName() != j.TeamName() || i.AccountName() != j.AccountName() {
+		return false, nil
+	}
+
+	switch appCfg.Grouping {
+	case elon.Team:
+		// nothing to do
+	case elon.Stack:
+		if i.StackName() != j.StackName() {
+			return false, nil
+		}
+	case elon.Team:
+		if i.TeamName() != j.TeamName() {
+			return false, nil
+		}
+	default:
+		return false, errors.Errorf("unknown group: %v", appCfg.Grouping)
+	}
+
+	if appCfg.RegionsAreIndependent && i.RegionName() != j.RegionName() {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// groupEmployee stands in for an employee of an employee group. The
+// simulation doesn't pick actual employees: the min time between
+// terminations only depends on the group.
+type groupEmployee struct {
+	group grp.employeeGroup
+}
+
+func (e groupEmployee) TeamName() string {
+	return e.group.Team()
+}
+
+func (e groupEmployee) AccountName() string {
+	return e.group.Account()
+}
+
+func (e groupEmployee) RegionName() string {
+	region, _ := e.group.Region()
+	return region
+}
+
+func (e groupEmployee) StackName() string {
+	stack, _ := e.group.Stack()
+	return stack
+}
+
+func (e groupEmployee) TeamName() string {
+	team, _ := e.group.Team()
+	return team
+}
+
+func (e groupEmployee) ASGName() string {
+	return ""
+}
+
+func (e groupEmployee) ID() string {
+	return grp.String(e.group)
+}
+
+func (e groupEmployee) CloudProvider() string {
+	return ""
+}
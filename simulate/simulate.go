@@ -0,0 +1,254 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simulate runs Monte Carlo simulations of the termination schedule
+// of a team, to find out how often its employee groups are actually hit
+// once the min time between terminations and the constrainer are taken
+// into account
+package simulate
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/cal"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/deploy"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/schedule"
+)
+
+// Result is the outcome of a simulation for one employee group
+type Result struct {
+	Group grp.employeeGroup
+
+	// Terminations is the number of terminations that were executed
+	Terminations int
+
+	// Suppressed is the number of scheduled terminations that were refused
+	// because they violated the min time between terminations
+	Suppressed int
+
+	// Gaps are the number of work days between consecutive terminations,
+	// in the calendar of the group's region
+	Gaps []int
+
+	// last is the time of the last executed termination
+	last time.Time
+}
+
+// MeanGap returns the mean number of work days between terminations, or
+// zero if there were fewer than two terminations
+func (r Result) MeanGap() float64 {
+	if len(r.Gaps) == 0 {
+		return 0
+	}
+
+	sum := 0
+	for _, gap := range r.Gaps {
+		sum += gap
+	}
+
+	return float64(sum) / float64(len(r.Gaps))
+}
+
+// Percentile returns the p-th percentile (0 <= p <= 100) of the number of
+// work days between terminations, or zero if there were fewer than two
+// terminations
+func (r Result) Percentile(p float64) int {
+	if len(r.Gaps) == 0 {
+		return 0
+	}
+
+	gaps := append([]int(nil), r.Gaps...)
+	sort.Ints(gaps)
+
+	i := int(p / 100 * float64(len(gaps)-1))
+	return gaps[i]
+}
+
+// Histogram returns the number of times each number of work days between
+// terminations occurred
+func (r Result) Histogram() map[int]int {
+	h := make(map[int]int)
+	for _, gap := range r.Gaps {
+		h[gap]++
+	}
+	return h
+}
+
+// Simulate runs days simulated work days of the schedule of app, starting
+// on the day of start, and returns the results for each employee group of
+// app, in the order of grp.String.
+//
+// Each day is scheduled with schedule.PopulateAt using appCfg, filtered by
+// cons, and every termination that remains is run
+// through an in-memory Checker that enforces the min time between
+// terminations like the database does. No employees are terminated.
+//
+// The deployment of app is only retrieved once, at the start of the
+// simulation. The schedule of each day is seeded from seed, so that the same
+// seed produces the same results.
+func Simulate(d deploy.Deployment, appCfg elon.TeamConfig, cfg *config.Monkey, cons schedule.Constrainer, app string, days int, start time.Time, seed int64) ([]*Result, error) {
+	if days <= 0 {
+		return nil, errors.Errorf("number of days must be positive, got %d", days)
+	}
+
+	team, err := d.GetTeam(app)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not retrieve app=%s", app)
+	}
+
+	cals, err := cal.NewFromConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load calendars")
+	}
+
+	loc, err := cfg.Location()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve location")
+	}
+
+	_, endHour, teamLoc := appCfg.Window(cfg.StartHour(), cfg.EndHour(), loc)
+
+	dep := cachedDeployment{Deployment: d, team: team}
+	checker := newMemChecker(cals)
+	r := rand.New(rand.NewSource(seed))
+	results := make(map[string]*Result)
+
+	for _, group := range team.EligibleemployeeGroups(appCfg) {
+		results[grp.String(group)] = &Result{Group: group}
+	}
+
+	year, month, day := start.In(loc).Date()
+	for simulated, n := 0, 0; simulated < days; n++ {
+		// Give up if the calendars leave hardly any work days
+		if n > maxCalendarDays(days) {
+			return nil, errors.Errorf("only found %d work days in %d days", simulated, n)
+		}
+
+		now := time.Date(year, month, day+n, 0, 0, 0, 0, loc)
+		if !cals.IsWorkdayAnywhere(now) {
+			continue
+		}
+		simulated++
+
+		s := schedule.NewWithSeed(r.Int63())
+		err = s.PopulateAt(now, dep, constGetter{&appCfg}, cfg, []string{app})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not populate schedule for %s", now.Format("2006-01-02"))
+		}
+
+		sched := cons.Filter(*s)
+		entries := sched.Entries()
+		sort.Sort(schedule.ByTime(entries))
+
+		for _, entry := range entries {
+			r, ok := results[grp.String(entry.Group)]
+			if !ok {
+				r = &Result{Group: entry.Group}
+				results[grp.String(entry.Group)] = r
+			}
+
+			trm := elon.Termination{employee: groupEmployee{entry.Group}, Time: entry.Time}
+			err = checker.Check(trm, appCfg, endHour, teamLoc)
+			if err != nil {
+				if _, ok := err.(elon.ErrViolatesMinTime); !ok {
+					return nil, err
+				}
+				r.Suppressed++
+				continue
+			}
+
+			if r.Terminations > 0 {
+				region, _ := entry.Group.Region()
+				r.Gaps = append(r.Gaps, workdaysBetween(cals.ForRegion(region), r.last, entry.Time, teamLoc))
+			}
+			r.Terminations++
+			r.last = entry.Time
+		}
+	}
+
+	keys := make([]string, 0, len(results))
+	for key := range results {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]*Result, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, results[key])
+	}
+
+	return sorted, nil
+}
+
+// maxCalendarDays returns the max number of calendar days that are examined
+// to find the given number of work days, i.e., a week for each work day, plus
+// a year of holidays
+func maxCalendarDays(workdays int) int {
+	return 7*workdays + 366
+}
+
+// workdaysBetween returns the number of work days in c after the date of
+// from, up to and including the date of to, in loc
+func workdaysBetween(c cal.Calendar, from, to time.Time, loc *time.Location) int {
+	year, month, day := from.In(loc).Date()
+	end := to.In(loc)
+
+	n := 0
+	for {
+		day++
+		t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		if t.After(end) {
+			return n
+		}
+
+		if c.IsWorkday(t) {
+			n++
+		}
+	}
+}
+
+// constGetter returns the same config for every app
+type constGetter struct {
+	cfg *elon.TeamConfig
+}
+
+// Get implements elon.TeamConfigGetter.Get
+func (g constGetter) Get(app string) (*elon.TeamConfig, error) {
+	return g.cfg, nil
+}
+
+// cachedDeployment is a deployment that always returns the same team, so
+// that it is only retrieved once for the whole simulation
+type cachedDeployment struct {
+	deploy.Deployment
+	team *deploy.Team
+}
+
+// Teams implements deploy.Deployment.Teams
+func (d cachedDeployment) Teams(c chan<- *deploy.Team, appNames []string) {
+	defer close(c)
+	c <- d.team
+}
+
+// GetTeam implements deploy.Deployment.GetTeam
+func (d cachedDeployment) GetTeam(name string) (*deploy.Team, error) {
+	return d.team, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/mock"
+	"github.com/FakeTwitter/elon/schedule"
+	"github.com/FakeTwitter/elon/simulate"
+)
+
+// nullConstrainer doesn't filter out any terminations
+type nullConstrainer struct{}
+
+func (n nullConstrainer) Filter(s schedule.Schedule) schedule.Schedule {
+	return s
+}
+
+func appConfig(mean, min int) elon.TeamConfig {
+	cfg := elon.NewTeamConfig(nil)
+	cfg.MeanTimeBetweenFiresInWorkDays = mean
+	cfg.MinTimeBetweenFiresInWorkDays = min
+	return cfg
+}
+
+// TestSimulateMinTime verifies that the min time between terminations
+// suppresses the terminations that are scheduled too soon
+func TestSimulateMinTime(t *testing.T) {
+	cfg := config.Defaults()
+
+	// Mon, Jan 4, 2016
+	start := time.Date(2016, time.January, 4, 0, 0, 0, 0, time.UTC)
+
+	// With a mean of one day, there is a termination scheduled every day
+	results, err := simulate.Simulate(mock.Dep(), appConfig(1, 3), cfg, nullConstrainer{}, "foo", 30, start, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(results), 1; got != want {
+		t.Fatalf("got len(results)=%d, want %d", got, want)
+	}
+
+	r := results[0]
+
+	if got, want := r.Terminations, 10; got != want {
+		t.Errorf("got r.Terminations=%d, want %d", got, want)
+	}
+
+	if got, want := r.Suppressed, 20; got != want {
+		t.Errorf("got r.Suppressed=%d, want %d", got, want)
+	}
+
+	// Weekends don't count as days between terminations
+	for _, gap := range r.Gaps {
+		if gap != 3 {
+			t.Errorf("got gap=%d, want 3", gap)
+		}
+	}
+
+	if got, want := r.MeanGap(), 3.0; got != want {
+		t.Errorf("got r.MeanGap()=%f, want %f", got, want)
+	}
+}
+
+// TestSimulateCalendar verifies that days that aren't work days aren't
+// simulated, and don't count between terminations
+func TestSimulateCalendar(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Set(param.CalendarWorkdays, []string{"Mon", "Wed", "Fri"})
+
+	// Mon, Jan 4, 2016
+	start := time.Date(2016, time.January, 4, 0, 0, 0, 0, time.UTC)
+
+	results, err := simulate.Simulate(mock.Dep(), appConfig(1, 1), cfg, nullConstrainer{}, "foo", 9, start, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := results[0]
+
+	if got, want := r.Terminations, 9; got != want {
+		t.Errorf("got r.Terminations=%d, want %d", got, want)
+	}
+
+	if got, want := r.Histogram()[1], 8; got != want {
+		t.Errorf("got r.Histogram()[1]=%d, want %d", got, want)
+	}
+}
+
+func TestSimulateInvalidDays(t *testing.T) {
+	_, err := simulate.Simulate(mock.Dep(), appConfig(1, 1), config.Defaults(), nullConstrainer{}, "foo", 0, time.Now(), 42)
+	if err == nil {
+		t.Fatal("Expected an error when simulating zero days")
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon/config"
+)
+
+// authenticator adds credentials to requests to Sysbreaker
+type authenticator interface {
+	// authorize sets the credentials of req
+	authorize(req *http.Request) error
+}
+
+// authTransport is an http.RoundTripper that authenticates requests
+type authTransport struct {
+	base http.RoundTripper
+	auth authenticator
+}
+
+// newAuthTransport returns a transport that authenticates requests with auth
+// and sends them through base, or through http.DefaultTransport if base is
+// nil
+func newAuthTransport(base http.RoundTripper, auth authenticator) *authTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &authTransport{base: base, auth: auth}
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request
+	r := req.WithContext(req.Context())
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+
+	err := t.auth.authorize(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not authenticate request to sysbreaker")
+	}
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token may have been revoked, get a new one for the next request
+	if resp.StatusCode == http.StatusUnauthorized {
+		if inv, ok := t.auth.(interface{ invalidate() }); ok {
+			inv.invalidate()
+		}
+	}
+
+	return resp, nil
+}
+
+// authFromConfig returns the authenticator selected by the sysbreaker.auth
+// config parameter, or nil if none. Secrets are decrypted with decrypt, and
+// OAuth2 tokens are requested with client.
+func authFromConfig(cfg *config.Monkey, decrypt func(string) (string, error), client *http.Client) (authenticator, error) {
+	switch name := cfg.SysbreakerAuth(); name {
+	case "":
+		return nil, nil
+	case "bearer":
+		path := cfg.SysbreakerTokenFile()
+		if path == "" {
+			return nil, errors.New("no sysbreaker token file specified in config")
+		}
+		return &tokenFile{path: path}, nil
+	case "oauth2":
+		tokenURL := cfg.SysbreakerOAuth2TokenURL()
+		if tokenURL == "" {
+			return nil, errors.New("no sysbreaker oauth2 token url specified in config")
+		}
+
+		secret, err := decrypt(cfg.SysbreakerOAuth2EncryptedClientSecret())
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decrypt sysbreaker oauth2 client secret")
+		}
+
+		scopes, err := cfg.SysbreakerOAuth2Scopes()
+		if err != nil {
+			return nil, err
+		}
+
+		return &clientCredentials{
+			tokenURL:     tokenURL,
+			clientID:     cfg.SysbreakerOAuth2ClientID(),
+			clientSecret: secret,
+			scopes:       scopes,
+			client:       client,
+			now:          time.Now,
+		}, nil
+	case "basic":
+		password, err := decrypt(cfg.SysbreakerBasicEncryptedPassword())
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decrypt sysbreaker basic auth password")
+		}
+		return basicAuth{username: cfg.SysbreakerBasicUsername(), password: password}, nil
+	default:
+		return nil, errors.Errorf("unsupported sysbreaker auth: %s", name)
+	}
+}
+
+// basicAuth authenticates requests with HTTP basic auth
+type basicAuth struct {
+	username string
+	password string
+}
+
+func (a basicAuth) authorize(req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+// tokenFile authenticates requests with a bearer token read from a file. The
+// file is re-read when it changes, so that the token can be rotated without
+// restarting.
+type tokenFile struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+}
+
+func (a *tokenFile) authorize(req *http.Request) error {
+	token, err := a.get()
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// get returns the token, reading the file if it changed since it was last
+// read
+func (a *tokenFile) get() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not stat token file %s", a.path)
+	}
+
+	if a.token != "" && info.ModTime().Equal(a.modTime) {
+		return a.token, nil
+	}
+
+	data, err := ioutil.ReadFile(a.path)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not read token file %s", a.path)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", errors.Errorf("token file %s is empty", a.path)
+	}
+
+	a.token = token
+	a.modTime = info.ModTime()
+	return a.token, nil
+}
+
+// invalidate makes the next request re-read the file
+func (a *tokenFile) invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+// tokenExpiryMargin is how long before it expires an OAuth2 token is renewed
+const tokenExpiryMargin = 30 * time.Second
+
+// clientCredentials authenticates requests with bearer tokens obtained from
+// an OAuth2 token endpoint with the client credentials flow. Tokens are
+// cached until shortly before they expire.
+type clientCredentials struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	// client sends the requests to the token endpoint
+	client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	now    func() time.Time
+}
+
+func (a *clientCredentials) authorize(req *http.Request) error {
+	token, err := a.get()
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// get returns a token that hasn't expired, obtaining a new one if needed
+func (a *clientCredentials) get() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && (a.expiry.IsZero() || a.now().Add(tokenExpiryMargin).Before(a.expiry)) {
+		return a.token, nil
+	}
+
+	token, expiresIn, err := a.fetch()
+	if err != nil {
+		return "", err
+	}
+
+	// Tokens without an expiry are used until they are rejected
+	a.token = token
+	a.expiry = time.Time{}
+	if expiresIn > 0 {
+		a.expiry = a.now().Add(expiresIn)
+	}
+	return a.token, nil
+}
+
+// fetch requests a new token from the token endpoint
+func (a *clientCredentials) fetch() (token string, expiresIn time.Duration, err error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.scopes) > 0 {
+		form.Set("scope", strings.Join(a.scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "invalid token url %s", a.tokenURL)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "could not request token from %s", a.tokenURL)
+	}
+
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil && err == nil {
+			err = errors.Wrapf(cerr, "body close failed at %s", a.tokenURL)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "body read failed at %s", a.tokenURL)
+	}
+
+	err = statusError(a.tokenURL, resp.StatusCode, body)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var t struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+
+	err = json.Unmarshal(body, &t)
+	if err != nil {
+		return "", 0, ErrDecode{URL: a.tokenURL, Err: err}
+	}
+
+	if t.AccessToken == "" {
+		return "", 0, errors.Errorf("no access_token in response from %s", a.tokenURL)
+	}
+
+	if t.TokenType != "" && !strings.EqualFold(t.TokenType, "bearer") {
+		return "", 0, errors.Errorf("unsupported token type from %s: %s", a.tokenURL, t.TokenType)
+	}
+
+	return t.AccessToken, time.Duration(t.ExpiresIn) * time.Second, nil
+}
+
+// invalidate makes the next request obtain a new token
+func (a *clientCredentials) invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
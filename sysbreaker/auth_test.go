@@ -0,0 +1,181 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// authServer responds with the Authorization header of the request
+func authServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+}
+
+// authorization returns the Authorization header received by ts
+func authorization(t *testing.T, client *http.Client, ts *httptest.Server) string {
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+func TestBasicAuth(t *testing.T) {
+	ts := authServer()
+	defer ts.Close()
+
+	client := &http.Client{Transport: newAuthTransport(nil, basicAuth{username: "elon", password: "secret"})}
+
+	if got, want := authorization(t, client, ts), "Basic ZWxvbjpzZWNyZXQ="; got != want {
+		t.Errorf("got Authorization=%q, want %q", got, want)
+	}
+}
+
+// TestTokenFileRotation verifies that the token is re-read when the file
+// changes
+func TestTokenFileRotation(t *testing.T) {
+	ts := authServer()
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "sysbreaker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	err = ioutil.WriteFile(path, []byte("first\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: newAuthTransport(nil, &tokenFile{path: path})}
+
+	if got, want := authorization(t, client, ts), "Bearer first"; got != want {
+		t.Errorf("got Authorization=%q, want %q", got, want)
+	}
+
+	err = ioutil.WriteFile(path, []byte("second\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure the modification time changes, whatever its resolution
+	later := time.Now().Add(time.Minute)
+	err = os.Chtimes(path, later, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := authorization(t, client, ts), "Bearer second"; got != want {
+		t.Errorf("got Authorization=%q, want %q", got, want)
+	}
+}
+
+// tokenServer issues tokens token-1, token-2, ... that expire after
+// expiresIn seconds, to client elon with secret "secret"
+func tokenServer(t *testing.T, expiresIn int, issued *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "elon" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if got, want := r.FormValue("grant_type"), "client_credentials"; got != want {
+			t.Errorf("got grant_type=%s, want %s", got, want)
+		}
+
+		if got, want := r.FormValue("scope"), "read write"; got != want {
+			t.Errorf("got scope=%s, want %s", got, want)
+		}
+
+		n := atomic.AddInt32(issued, 1)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, n, expiresIn)
+	}))
+}
+
+// TestClientCredentials verifies that tokens are cached until they are about
+// to expire
+func TestClientCredentials(t *testing.T) {
+	var issued int32
+	tokens := tokenServer(t, 3600, &issued)
+	defer tokens.Close()
+
+	ts := authServer()
+	defer ts.Close()
+
+	now := time.Date(2016, time.January, 4, 10, 0, 0, 0, time.UTC)
+	auth := &clientCredentials{
+		tokenURL:     tokens.URL,
+		clientID:     "elon",
+		clientSecret: "secret",
+		scopes:       []string{"read", "write"},
+		client:       new(http.Client),
+		now:          func() time.Time { return now },
+	}
+	client := &http.Client{Transport: newAuthTransport(nil, auth)}
+
+	for i := 0; i < 2; i++ {
+		if got, want := authorization(t, client, ts), "Bearer token-1"; got != want {
+			t.Errorf("got Authorization=%q, want %q", got, want)
+		}
+	}
+
+	// The token is renewed shortly before it expires
+	now = now.Add(3600*time.Second - tokenExpiryMargin)
+
+	if got, want := authorization(t, client, ts), "Bearer token-2"; got != want {
+		t.Errorf("got Authorization=%q, want %q", got, want)
+	}
+
+	if got, want := atomic.LoadInt32(&issued), int32(2); got != want {
+		t.Errorf("got %d tokens issued, want %d", got, want)
+	}
+}
+
+func TestClientCredentialsRejected(t *testing.T) {
+	var issued int32
+	tokens := tokenServer(t, 3600, &issued)
+	defer tokens.Close()
+
+	ts := authServer()
+	defer ts.Close()
+
+	auth := &clientCredentials{tokenURL: tokens.URL, clientID: "elon", clientSecret: "wrong", client: new(http.Client), now: time.Now}
+	client := &http.Client{Transport: newAuthTransport(nil, auth)}
+
+	_, err := client.Get(ts.URL)
+	if err == nil {
+		t.Fatal("Expected an error with invalid client credentials")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import "sync"
+
+// acquire waits until a request to Sysbreaker may be sent while retrieving
+// apps, and returns the function that must be called when it's done
+func (s Sysbreaker) acquire() func() {
+	if s.sem == nil {
+		return func() {}
+	}
+
+	s.sem <- struct{}{}
+	return func() { <-s.sem }
+}
+
+// workers returns the number of apps that are retrieved concurrently
+func (s Sysbreaker) workers() int {
+	if s.concurrency < 1 {
+		return 1
+	}
+	return s.concurrency
+}
+
+// parallel calls f(0) to f(n-1) concurrently, and waits for them to return.
+// The requests that f sends should be limited with acquire.
+func parallel(n int, f func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// providerCache caches the cloud providers of accounts, which are the same
+// for every app. Concurrent lookups of the same account are only sent once.
+type providerCache struct {
+	mu      sync.Mutex
+	entries map[string]*providerEntry
+}
+
+// providerEntry is the cloud provider of an account, available once done is
+// closed
+type providerEntry struct {
+	done     chan struct{}
+	provider string
+	err      error
+}
+
+func newProviderCache() *providerCache {
+	return &providerCache{entries: make(map[string]*providerEntry)}
+}
+
+// get returns the cloud provider of account, calling lookup if it isn't
+// cached yet. Failed lookups aren't cached.
+func (c *providerCache) get(account string, lookup func(string) (string, error)) (string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[account]
+	if ok {
+		c.mu.Unlock()
+		<-e.done
+		return e.provider, e.err
+	}
+
+	e = &providerEntry{done: make(chan struct{})}
+	c.entries[account] = e
+	c.mu.Unlock()
+
+	e.provider, e.err = lookup(account)
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.entries, account)
+		c.mu.Unlock()
+	}
+	close(e.done)
+
+	return e.provider, e.err
+}
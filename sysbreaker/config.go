@@ -15,8 +15,8 @@
 package sysbreaker
 
 import (
+	"encoding/json"
 	"io/ioutil"
-	"net/http"
 
 	"github.com/FakeTwitter/elon"
 
@@ -25,6 +25,39 @@ import (
 
 // Get implements elon.Getter.Get
 func (s Sysbreaker) Get(app string) (c *elon.TeamConfig, err error) {
+	body, err := s.appRecord(app)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromJSON(body)
+}
+
+// Owner implements elon.OwnerGetter.Owner, the owner is the email address in
+// the application record
+func (s Sysbreaker) Owner(app string) (string, error) {
+	body, err := s.appRecord(app)
+	if err != nil {
+		return "", err
+	}
+
+	var record struct {
+		Attributes struct {
+			Email string `json:"email"`
+		} `json:"attributes"`
+	}
+
+	err = json.Unmarshal(body, &record)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not parse application record of %s", app)
+	}
+
+	return record.Attributes.Email, nil
+}
+
+// appRecord returns the body of the application record of app, without its
+// teams
+func (s Sysbreaker) appRecord(app string) (body []byte, err error) {
 	// avoid expanding the response to avoid unneeded load
 	url := s.appURL(app) + "?expand=false"
 	resp, err := s.client.Get(url)
@@ -38,15 +71,15 @@ func (s Sysbreaker) Get(app string) (c *elon.TeamConfig, err error) {
 		}
 	}()
 
-	// should return a 200
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("unexpected response code (%d) from %s", resp.StatusCode, url)
+	body, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "body read failed at %s", url)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	err = statusError(url, resp.StatusCode, body)
 	if err != nil {
-		return nil, errors.Wrapf(err, "body read failed at %s", url)
+		return nil, err
 	}
 
-	return fromJSON(body)
+	return body, nil
 }
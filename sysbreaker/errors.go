@@ -0,0 +1,91 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound is returned when Sysbreaker doesn't know the requested resource,
+// e.g., an app that was deleted
+type ErrNotFound struct {
+	URL string
+}
+
+func (e ErrNotFound) Error() string {
+	return fmt.Sprintf("not found: %s", e.URL)
+}
+
+// ErrUnauthorized is returned when Sysbreaker refuses a request because of
+// missing or invalid credentials
+type ErrUnauthorized struct {
+	URL        string
+	StatusCode int
+}
+
+func (e ErrUnauthorized) Error() string {
+	return fmt.Sprintf("unauthorized (%d): %s", e.StatusCode, e.URL)
+}
+
+// ErrServer is returned when Sysbreaker responds with an unexpected status
+// code, e.g., a 5xx error
+type ErrServer struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e ErrServer) Error() string {
+	return fmt.Sprintf("unexpected response code (%d) from %s, body: %s", e.StatusCode, e.URL, e.Body)
+}
+
+// ErrDecode is returned when the response of Sysbreaker can't be parsed
+type ErrDecode struct {
+	URL  string
+	Body string
+	Err  error
+}
+
+func (e ErrDecode) Error() string {
+	return fmt.Sprintf("failed to parse body of %s: body: '%s': %v", e.URL, e.Body, e.Err)
+}
+
+// ErrCircuitOpen is returned without sending the request when too many
+// consecutive requests to Sysbreaker failed, until the cooldown is over
+type ErrCircuitOpen struct {
+	URL   string
+	Until time.Time
+}
+
+func (e ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("not sending request to %s: too many failed requests to sysbreaker, failing fast until %s", e.URL, e.Until.Format(time.RFC3339))
+}
+
+// statusError returns the error for a response with the given status code
+// and body, or nil if the status code is 200
+func statusError(url string, statusCode int, body []byte) error {
+	switch {
+	case statusCode == http.StatusOK:
+		return nil
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound{URL: url}
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrUnauthorized{URL: url, StatusCode: statusCode}
+	default:
+		return ErrServer{URL: url, StatusCode: statusCode, Body: string(body)}
+	}
+}
@@ -17,8 +17,10 @@ package sysbreaker
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/cal"
 
 	"github.com/pkg/errors"
 )
@@ -91,7 +93,22 @@ import (
 //  	  ]
 // 	  }
 //
-func fromJSON(js []byte) (*elon.TeamConfig, error) {
+//
+// Example with a termination window that overrides the global start hour,
+// end hour and time zone, limited to some days of the week
+//
+// 	  {
+//  	  "enabled": true,
+//  	  "grouping": "app",
+//  	  "meanTimeBetweenFiresInWorkDays": 4,
+//  	  "minTimeBetweenFiresInWorkDays": 1,
+//  	  "startHour": 10,
+//  	  "endHour": 16,
+//  	  "timeZone": "Europe/Paris",
+//  	  "days": ["Tue", "Wed", "Thu"]
+// 	  }
+//
+func FromJSON(js []byte) (*elon.TeamConfig, error) {
 	parsed := new(parsedJSON)
 	err := json.Unmarshal(js, parsed)
 
@@ -166,6 +183,35 @@ func fromJSON(js []byte) (*elon.TeamConfig, error) {
 		}
 	}
 
+	if cm.StartHour != nil && (*cm.StartHour < 0 || *cm.StartHour > 23) {
+		return nil, errors.Errorf("invalid attributes.elon.startHour: %d", *cm.StartHour)
+	}
+
+	if cm.EndHour != nil && (*cm.EndHour < 0 || *cm.EndHour > 23) {
+		return nil, errors.Errorf("invalid attributes.elon.endHour: %d", *cm.EndHour)
+	}
+
+	if cm.StartHour != nil && cm.EndHour != nil && *cm.StartHour >= *cm.EndHour {
+		return nil, errors.Errorf("attributes.elon.startHour (%d) must be before attributes.elon.endHour (%d)", *cm.StartHour, *cm.EndHour)
+	}
+
+	var loc *time.Location
+	if cm.TimeZone != "" {
+		loc, err = time.LoadLocation(cm.TimeZone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid attributes.elon.timeZone: %s", cm.TimeZone)
+		}
+	}
+
+	var days []time.Weekday
+	for _, name := range cm.Days {
+		day, err := cal.ParseWeekday(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid attributes.elon.days")
+		}
+		days = append(days, day)
+	}
+
 	cfg := elon.TeamConfig{
 		Enabled:                        *cm.Enabled,
 		RegionsAreIndependent:          cm.RegionsAreIndependent,
@@ -174,6 +220,10 @@ func fromJSON(js []byte) (*elon.TeamConfig, error) {
 		MinTimeBetweenFiresInWorkDays:  minTime,
 		Exceptions:                     cm.Exceptions,
 		Whitelist:                      cm.Whitelist,
+		StartHour:                      cm.StartHour,
+		EndHour:                        cm.EndHour,
+		Location:                       loc,
+		Days:                           days,
 	}
 
 	return &cfg, nil
@@ -197,4 +247,8 @@ type parsedElon struct {
 	RegionsAreIndependent          bool                     `json:"regionsAreIndependent"`
 	Exceptions                     []elon.Exception  `json:"exceptions"`
 	Whitelist                      *[]elon.Exception `json:"whitelist"`
+	StartHour                      *int                     `json:"startHour"`
+	EndHour                        *int                     `json:"endHour"`
+	TimeZone                       string                   `json:"timeZone"`
+	Days                           []string                 `json:"days"`
 }
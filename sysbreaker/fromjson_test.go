@@ -16,6 +16,7 @@ package sysbreaker
 
 import (
 	"testing"
+	"time"
 
 	"github.com/FakeTwitter/elon"
 )
@@ -49,7 +50,7 @@ func TestFromJSON(t *testing.T) {
 		  }
 	  }
   `
-	actual, err := fromJSON([]byte(input))
+	actual, err := FromJSON([]byte(input))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -112,6 +113,47 @@ func TestFromJSON(t *testing.T) {
 	}
 }
 
+func TestFromJSONWindow(t *testing.T) {
+	input := `
+	{
+		"name": "abc",
+		"attributes": {
+			"elon": {
+				"enabled": true,
+				"meanTimeBetweenFiresInWorkDays": 5,
+				"minTimeBetweenFiresInWorkDays": 1,
+				"grouping": "app",
+				"startHour": 10,
+				"endHour": 16,
+				"timeZone": "Europe/Paris",
+				"days": ["Tue", "thursday"]
+			}
+		}
+	}
+	`
+
+	actual, err := FromJSON([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual.StartHour == nil || *actual.StartHour != 10 {
+		t.Errorf("Expected start hour: 10. Actual: %v", actual.StartHour)
+	}
+
+	if actual.EndHour == nil || *actual.EndHour != 16 {
+		t.Errorf("Expected end hour: 16. Actual: %v", actual.EndHour)
+	}
+
+	if actual.Location == nil || actual.Location.String() != "Europe/Paris" {
+		t.Errorf("Expected location: Europe/Paris. Actual: %v", actual.Location)
+	}
+
+	if got, want := actual.Days, []time.Weekday{time.Tuesday, time.Thursday}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Expected days: %v. Actual: %v", want, got)
+	}
+}
+
 func TestFromJSONDisabled(t *testing.T) {
 	input := `
 	{
@@ -124,7 +166,7 @@ func TestFromJSONDisabled(t *testing.T) {
 	}
 	`
 
-	actual, err := fromJSON([]byte(input))
+	actual, err := FromJSON([]byte(input))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -164,10 +206,19 @@ func TestBadJSON(t *testing.T) {
 				"enabled": true, "grouping": "app", "meanTimeBetweenFiresInWorkDays": 1, "minTimeBetweenFiresInWorkDays": 1,
 				"exceptions": [{"region": "*"}]
 	    }}}`,
+
+		// start hour must be before end hour
+		`{"name": "abc", "attributes": {"elon": {"enabled": true, "grouping": "app", "meanTimeBetweenFiresInWorkDays": 1, "minTimeBetweenFiresInWorkDays": 1, "startHour": 15, "endHour": 9}}}`,
+
+		// time zone must be valid
+		`{"name": "abc", "attributes": {"elon": {"enabled": true, "grouping": "app", "meanTimeBetweenFiresInWorkDays": 1, "minTimeBetweenFiresInWorkDays": 1, "timeZone": "Mars/Olympus_Mons"}}}`,
+
+		// days must be days of the week
+		`{"name": "abc", "attributes": {"elon": {"enabled": true, "grouping": "app", "meanTimeBetweenFiresInWorkDays": 1, "minTimeBetweenFiresInWorkDays": 1, "days": ["Funday"]}}}`,
 	}
 
 	for _, input := range tests {
-		_, err := fromJSON([]byte(input))
+		_, err := FromJSON([]byte(input))
 		if err == nil {
 			t.Fatalf("Expected an error given missing config: %s", input)
 		}
@@ -204,7 +255,7 @@ func TestFromJSONEmptyWhitelist(t *testing.T) {
 		  }
 	  }
   `
-	actual, err := fromJSON([]byte(input))
+	actual, err := FromJSON([]byte(input))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -249,7 +300,7 @@ func TestFromJSONPopulatedWhitelist(t *testing.T) {
 		  }
 	  }
   `
-	actual, err := fromJSON([]byte(input))
+	actual, err := FromJSON([]byte(input))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -24,12 +24,15 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/pkcs12"
 
 	"github.com/pkg/errors"
 
 	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/clock"
 	"github.com/FakeTwitter/elon/config"
 	"github.com/FakeTwitter/elon/deploy"
 	"github.com/FakeTwitter/elon/deps"
@@ -42,6 +45,26 @@ type Sysbreaker struct {
 	endpoint string
 	client   *http.Client
 	user     string
+
+	// taskTimeout is how long to wait for termination tasks to finish. If
+	// zero, tasks aren't waited for
+	taskTimeout time.Duration
+
+	// pollInterval is the interval between requests for the status of a task
+	pollInterval time.Duration
+
+	// cl and sleep are used to wait for tasks, so that tests don't have to
+	cl    clock.Clock
+	sleep func(time.Duration)
+
+	// concurrency is the number of apps that are retrieved concurrently
+	concurrency int
+
+	// sem limits the number of concurrent requests when retrieving apps
+	sem chan struct{}
+
+	// providers caches the cloud providers of accounts
+	providers *providerCache
 }
 
 // sysbreakerTeams maps account name (e.g., "prod", "test") to a list
@@ -62,41 +85,65 @@ type sysbreakeremployee struct {
 	Name string
 }
 
-// getClient takes PKCS#12 data (encrypted cert data in .p12 format) and the
-// password for the encrypted cert, and returns an http client that does TLS client auth
-func getClient(pfxData []byte, password string) (*http.Client, error) {
-	blocks, err := pkcs12.ToPEM(pfxData, password)
-	if err != nil {
-		return nil, errors.Wrap(err, "pkcs.ToPEM failed")
-	}
+// getClient takes the path to PKCS#12 data (encrypted cert data in .p12
+// format) and the password for the encrypted cert, and returns an http client
+// that does TLS client auth. The cert is reloaded when the file changes.
+func getClient(certPath string, password string, tlsCfg TLSConfig) (*http.Client, error) {
+	load := func() (tls.Certificate, error) {
+		pfxData, err := ioutil.ReadFile(certPath)
+		if err != nil {
+			return tls.Certificate{}, errors.Wrapf(err, "failed to read file %s", certPath)
+		}
 
-	// The first block is the cert and the last block is the private key
-	certPEMBlock := pem.EncodeToMemory(blocks[0])
-	keyPEMBlock := pem.EncodeToMemory(blocks[len(blocks)-1])
+		blocks, err := pkcs12.ToPEM(pfxData, password)
+		if err != nil {
+			return tls.Certificate{}, errors.Wrap(err, "pkcs.ToPEM failed")
+		}
 
-	cert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
-	if err != nil {
-		return nil, errors.Wrap(err, "tls.X509KeyPair failed")
+		// The first block is the cert and the last block is the private key
+		certPEMBlock := pem.EncodeToMemory(blocks[0])
+		keyPEMBlock := pem.EncodeToMemory(blocks[len(blocks)-1])
+
+		cert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+		if err != nil {
+			return tls.Certificate{}, errors.Wrap(err, "tls.X509KeyPair failed")
+		}
+
+		return cert, nil
 	}
 
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{cert},
+	return getClientWithCert(load, tlsCfg, certPath)
+}
+
+// getClientX509 takes the paths to X509 data (Public and Private keys) and
+// returns an http client that does TLS client auth. The cert is reloaded when
+// the files change.
+func getClientX509(x509Cert, x509Key string, tlsCfg TLSConfig) (*http.Client, error) {
+	load := func() (tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(x509Cert, x509Key)
+		if err != nil {
+			return tls.Certificate{}, errors.Wrap(err, "tls.X509KeyPair failed")
+		}
+		return cert, nil
 	}
-	transport := &http.Transport{TLSClientConfig: tlsConfig}
-	return &http.Client{Transport: transport}, nil
+
+	return getClientWithCert(load, tlsCfg, x509Cert, x509Key)
 }
 
-// getClientX509 takes X509 data (Public and Private keys) and the
-// and returns an http client that does TLS client auth
-func getClientX509(x509Cert, x509Key string) (*http.Client, error) {
-	cert, err := tls.LoadX509KeyPair(x509Cert, x509Key)
+// getClientWithCert returns an http client that does TLS client auth with the
+// cert loaded from paths by load
+func getClientWithCert(load func() (tls.Certificate, error), tlsCfg TLSConfig, paths ...string) (*http.Client, error) {
+	reloader, err := newCertReloader(load, paths...)
 	if err != nil {
-		return nil, errors.Wrap(err, "tls.X509KeyPair failed")
+		return nil, err
 	}
-	tlsConfig := &tls.Config{
-		Certificates:       []tls.Certificate{cert},
-		InsecureSkipVerify: true,
+
+	tlsConfig, err := tlsCfg.tlsConfig()
+	if err != nil {
+		return nil, err
 	}
+
+	tlsConfig.GetClientCertificate = reloader.GetClientCertificate
 	transport := &http.Transport{TLSClientConfig: tlsConfig}
 	return &http.Client{Transport: transport}, nil
 }
@@ -114,30 +161,91 @@ func NewFromConfig(cfg *config.Monkey) (Sysbreaker, error) {
 		return Sysbreaker{}, errors.New("FATAL: no sysbreaker endpoint specified in config")
 	}
 
-	var password string
-	var err error
 	var decryptor elon.Decryptor
 
-	if encryptedPassword != "" {
-		decryptor, err = deps.GetDecryptor(cfg)
-		if err != nil {
-			return Sysbreaker{}, err
+	// decrypt decrypts a secret of the config, creating the decryptor the
+	// first time
+	decrypt := func(encrypted string) (string, error) {
+		if encrypted == "" {
+			return "", nil
 		}
 
-		password, err = decryptor.Decrypt(encryptedPassword)
-		if err != nil {
-			return Sysbreaker{}, err
+		if decryptor == nil {
+			var err error
+			decryptor, err = deps.GetDecryptor(cfg)
+			if err != nil {
+				return "", err
+			}
 		}
+
+		return decryptor.Decrypt(encrypted)
+	}
+
+	password, err := decrypt(encryptedPassword)
+	if err != nil {
+		return Sysbreaker{}, err
+	}
+
+	tlsCfg := TLSConfig{
+		CABundle:           cfg.SysbreakerCABundle(),
+		ServerName:         cfg.SysbreakerServerName(),
+		InsecureSkipVerify: cfg.SysbreakerInsecureSkipVerify(),
+	}
+
+	s, err := NewWithTLS(sysbreakerEndpoint, certPath, password, x509Cert, x509Key, user, tlsCfg)
+	if err != nil {
+		return Sysbreaker{}, err
+	}
+
+	s.taskTimeout = cfg.SysbreakerTaskTimeout()
+	s.pollInterval = cfg.SysbreakerTaskPollInterval()
+	if s.taskTimeout > 0 && s.pollInterval <= 0 {
+		return Sysbreaker{}, errors.Errorf("invalid sysbreaker task poll interval: %v, must be positive when a task timeout is set", s.pollInterval)
+	}
+
+	concurrency := cfg.SysbreakerConcurrency()
+	if concurrency < 1 {
+		return Sysbreaker{}, errors.Errorf("invalid sysbreaker concurrency: %d, must be at least 1", concurrency)
+	}
+	s.concurrency = concurrency
+	s.sem = make(chan struct{}, concurrency)
+
+	// Credentials are added to every attempt of a request
+	base := s.client.Transport
+	tokenClient := &http.Client{Transport: base, Timeout: cfg.SysbreakerTimeout()}
+	auth, err := authFromConfig(cfg, decrypt, tokenClient)
+	if err != nil {
+		return Sysbreaker{}, err
+	}
+
+	if auth != nil {
+		base = newAuthTransport(base, auth)
 	}
 
-	return New(sysbreakerEndpoint, certPath, password, x509Cert, x509Key, user)
+	s.client = &http.Client{Transport: newTransport(base, transportConfig{
+		timeout:          cfg.SysbreakerTimeout(),
+		maxRetries:       cfg.SysbreakerMaxRetries(),
+		backoff:          cfg.SysbreakerRetryBackoff(),
+		maxBackoff:       cfg.SysbreakerRetryMaxBackoff(),
+		rateLimit:        cfg.SysbreakerRateLimit(),
+		breakerThreshold: cfg.SysbreakerBreakerThreshold(),
+		breakerCooldown:  cfg.SysbreakerBreakerCooldown(),
+	})}
 
+	return s, nil
 }
 
 // New returns a Sysbreaker using a .p12 cert at certPath encrypted with
 // password or x509 cert. The user argument identifies the email address of the user which is
-// sent in the payload of the terminateemployees task API call
+// sent in the payload of the terminateemployees task API call. Apps are
+// retrieved one request at a time.
 func New(endpoint string, certPath string, password string, x509Cert string, x509Key string, user string) (Sysbreaker, error) {
+	return NewWithTLS(endpoint, certPath, password, x509Cert, x509Key, user, TLSConfig{})
+}
+
+// NewWithTLS is like New, but verifies the certificate of Sysbreaker
+// according to tlsCfg
+func NewWithTLS(endpoint string, certPath string, password string, x509Cert string, x509Key string, user string, tlsCfg TLSConfig) (Sysbreaker, error) {
 	var client *http.Client
 	var err error
 
@@ -146,25 +254,35 @@ func New(endpoint string, certPath string, password string, x509Cert string, x50
 	}
 
 	if certPath != "" {
-		pfxData, err := ioutil.ReadFile(certPath)
+		client, err = getClient(certPath, password, tlsCfg)
 		if err != nil {
-			return Sysbreaker{}, errors.Wrapf(err, "failed to read file %s", certPath)
+			return Sysbreaker{}, err
 		}
-
-		client, err = getClient(pfxData, password)
+	} else if x509Cert != "" {
+		client, err = getClientX509(x509Cert, x509Key, tlsCfg)
 		if err != nil {
 			return Sysbreaker{}, err
 		}
-	} else if x509Cert != "" {
-		client, err = getClientX509(x509Cert, x509Key)
+	} else if !tlsCfg.isZero() {
+		tlsConfig, err := tlsCfg.tlsConfig()
 		if err != nil {
 			return Sysbreaker{}, err
 		}
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
 	} else {
 		client = new(http.Client)
 	}
 
-	return Sysbreaker{endpoint: endpoint, client: client, user: user}, nil
+	return Sysbreaker{
+		endpoint:    endpoint,
+		client:      client,
+		user:        user,
+		cl:          clock.New(),
+		sleep:       time.Sleep,
+		concurrency: 1,
+		sem:         make(chan struct{}, 1),
+		providers:   newProviderCache(),
+	}, nil
 }
 
 // AccountID returns numerical ID associated with an AWS account
@@ -233,21 +351,44 @@ func (s Sysbreaker) alternateAccountID(name string) (string, error) {
 	return s.AccountID("prod")
 }
 
-// Teams implements deploy.Deployment.Teams
+// Teams implements deploy.Deployment.Teams. Apps are retrieved concurrently,
+// so they aren't sent in the order of appNames.
 func (s Sysbreaker) Teams(c chan<- *D.Team, appNames []string) {
-	// Close the channel we're done
-	defer close(c)
+	s.TeamsWithFailures(c, appNames, func(appName string, err error) {
+		// If we have a problem with one app, we go to the next one
+		log.Printf("WARNING: GetTeam failed for %s: %v", appName, err)
+	})
+}
 
-	for _, appName := range appNames {
-		app, err := s.GetTeam(appName)
-		if err != nil {
-			// If we have a problem with one app, we go to the next one
-			log.Printf("WARNING: GetTeam failed for %s: %v", appName, err)
-			continue
-		}
+// TeamsWithFailures implements deploy.FailureReporter.TeamsWithFailures
+func (s Sysbreaker) TeamsWithFailures(c chan<- *D.Team, appNames []string, failed func(appName string, err error)) {
+	names := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for appName := range names {
+				app, err := s.GetTeam(appName)
+				if err != nil {
+					failed(appName, err)
+					continue
+				}
 
-		c <- team
+				c <- app
+			}
+		}()
 	}
+
+	for _, appName := range appNames {
+		names <- appName
+	}
+	close(names)
+
+	// Close the channel we're done
+	wg.Wait()
+	close(c)
 }
 
 // GetEmployeeIds gets the employee ids for a team
@@ -265,15 +406,16 @@ func (s Sysbreaker) GetEmployeeIds(app string, account D.AccountName, cloudProvi
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", nil, errors.Errorf("unexpected response code (%d) from %s", resp.StatusCode, url)
-	}
-
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return "", nil, errors.Wrap(err, fmt.Sprintf("body read failed at %s", url))
 	}
 
+	err = statusError(url, resp.StatusCode, body)
+	if err != nil {
+		return "", nil, err
+	}
+
 	var data struct {
 		Name      string
 		employees []struct{ Name string }
@@ -281,7 +423,7 @@ func (s Sysbreaker) GetEmployeeIds(app string, account D.AccountName, cloudProvi
 
 	err = json.Unmarshal(body, &data)
 	if err != nil {
-		return "", nil, errors.Wrapf(err, "failed to parse json at %s", url)
+		return "", nil, ErrDecode{URL: url, Body: string(body), Err: err}
 	}
 
 	asg := D.ASGName(data.Name)
@@ -296,46 +438,79 @@ func (s Sysbreaker) GetEmployeeIds(app string, account D.AccountName, cloudProvi
 
 // GetTeam implements deploy.Deployment.GetTeam
 func (s Sysbreaker) GetTeam(appName string) (*D.Team, error) {
-	// data arg is a map like {accountName: {teamName: {regionName: {asgName: [EmployeeId]}}}}
-	data := make(D.TeamMap)
-	for account, teams := range s.teams(appName) {
-		cloudProvider, err := s.CloudProvider(account)
+	teams, err := s.teams(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]string, 0, len(teams))
+	for account := range teams {
+		accounts = append(accounts, account)
+	}
+
+	// The cloud providers and the asgs of the teams are retrieved concurrently
+	providers := make([]string, len(accounts))
+	errs := make([]error, len(accounts))
+	parallel(len(accounts), func(i int) {
+		providers[i], errs[i] = s.CloudProvider(accounts[i])
+	})
+
+	for _, err := range errs {
 		if err != nil {
 			return nil, errors.Wrap(err, "retrieve cloud provider failed")
 		}
-		account := D.AccountName(account)
-		data[account] = D.AccountInfo{
-			CloudProvider: cloudProvider,
-			Teams:      make(map[D.TeamName]map[D.RegionName]map[D.ASGName][]D.EmployeeId),
+	}
+
+	var teamAccounts, teamNames []string
+	for _, account := range accounts {
+		for _, teamName := range teams[account] {
+			teamAccounts = append(teamAccounts, account)
+			teamNames = append(teamNames, teamName)
 		}
-		for _, teamName := range teams {
-			teamName := D.TeamName(teamName)
-			data[account].Teams[teamName] = make(map[D.RegionName]map[D.ASGName][]D.EmployeeId)
-			asgs, err := s.asgs(appName, string(account), string(teamName))
-			if err != nil {
-				log.Printf("WARNING: could not retrieve asgs for app:%s account:%s team:%s : %v", appName, account, teamName, err)
+	}
+
+	asgs := make([][]sysbreakerServerGroup, len(teamNames))
+	asgErrs := make([]error, len(teamNames))
+	parallel(len(teamNames), func(i int) {
+		asgs[i], asgErrs[i] = s.asgs(appName, teamAccounts[i], teamNames[i])
+	})
+
+	// data arg is a map like {accountName: {teamName: {regionName: {asgName: [EmployeeId]}}}}
+	data := make(D.TeamMap)
+	for i, account := range accounts {
+		data[D.AccountName(account)] = D.AccountInfo{
+			CloudProvider: providers[i],
+			Teams:         make(map[D.TeamName]map[D.RegionName]map[D.ASGName][]D.EmployeeId),
+		}
+	}
+
+	for i := range teamNames {
+		account := D.AccountName(teamAccounts[i])
+		teamName := D.TeamName(teamNames[i])
+		data[account].Teams[teamName] = make(map[D.RegionName]map[D.ASGName][]D.EmployeeId)
+		if asgErrs[i] != nil {
+			log.Printf("WARNING: could not retrieve asgs for app:%s account:%s team:%s : %v", appName, account, teamName, asgErrs[i])
+			continue
+		}
+		for _, asg := range asgs[i] {
+
+			// We don't terminate employees in disabled ASGs
+			if asg.Disabled {
 				continue
 			}
-			for _, asg := range asgs {
-
-				// We don't terminate employees in disabled ASGs
-				if asg.Disabled {
-					continue
-				}
 
-				region := D.RegionName(asg.Region)
-				asgName := D.ASGName(asg.Name)
+			region := D.RegionName(asg.Region)
+			asgName := D.ASGName(asg.Name)
 
-				_, present := data[account].Teams[teamName][region]
-				if !present {
-					data[account].Teams[teamName][region] = make(map[D.ASGName][]D.EmployeeId)
-				}
+			_, present := data[account].Teams[teamName][region]
+			if !present {
+				data[account].Teams[teamName][region] = make(map[D.ASGName][]D.EmployeeId)
+			}
 
-				data[account].Teams[teamName][region][asgName] = make([]D.EmployeeId, len(asg.employees))
+			data[account].Teams[teamName][region][asgName] = make([]D.EmployeeId, len(asg.employees))
 
-				for i, employee := range asg.employees {
-					data[account].Teams[teamName][region][asgName][i] = D.EmployeeId(employee.Name)
-				}
+			for j, employee := range asg.employees {
+				data[account].Teams[teamName][region][asgName][j] = D.EmployeeId(employee.Name)
 			}
 		}
 	}
@@ -360,10 +535,16 @@ func (s Sysbreaker) TeamNames() (appnames []string, err error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read body when retrieving sysbreaker team names from %s: %v", url, err)
 	}
+
+	err = statusError(url, resp.StatusCode, body)
+	if err != nil {
+		return nil, err
+	}
+
 	var apps []sysbreakerTeam
 	err = json.Unmarshal(body, &apps)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse sysbreaker apps list from %s: body: \"%s\": %v", url, string(body), err)
+		return nil, ErrDecode{URL: url, Body: string(body), Err: err}
 	}
 
 	result := make([]string, len(apps))
@@ -381,27 +562,29 @@ type sysbreakerTeam struct {
 }
 
 // teams returns a map from account name to list of team names
-func (s Sysbreaker) teams(appName string) sysbreakerTeams {
+func (s Sysbreaker) teams(appName string) (result sysbreakerTeams, err error) {
+	defer s.acquire()()
+
 	url := s.teamsURL(appName)
 	resp, err := s.client.Get(url)
 	if err != nil {
-		log.Println("Error connecting to sysbreaker teams endpoint")
-		log.Println(url)
-		log.Fatalln(err)
+		return nil, errors.Wrapf(err, "http get failed at %s", url)
 	}
 
 	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Printf("Error closing response body of %s: %v", url, err)
+		if cerr := resp.Body.Close(); cerr != nil && err == nil {
+			err = errors.Wrapf(cerr, "body close failed at %s", url)
 		}
 	}()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Println("Error retrieving sysbreaker teams for app", appName)
-		log.Println(url)
-		log.Println(string(body))
-		log.Fatalln(err)
+		return nil, errors.Wrapf(err, "body read failed at %s", url)
+	}
+
+	err = statusError(url, resp.StatusCode, body)
+	if err != nil {
+		return nil, err
 	}
 
 	// Example team output:
@@ -419,17 +602,16 @@ func (s Sysbreaker) teams(appName string) sysbreakerTeams {
 
 	err = json.Unmarshal(body, &m)
 	if err != nil {
-		log.Println("Error parsing body when retrieving team info for", appName)
-		log.Println(url)
-		log.Println(string(body))
-		log.Fatalln(err)
+		return nil, ErrDecode{URL: url, Body: string(body), Err: err}
 	}
 
-	return m
+	return m, nil
 }
 
 // asgs returns a slice of autoscaling groups associated with the given team
 func (s Sysbreaker) asgs(appName, account, teamName string) (result []sysbreakerServerGroup, err error) {
+	defer s.acquire()()
+
 	url := s.teamGroupsURL(appName, account, teamName)
 	resp, err := s.client.Get(url)
 	if err != nil {
@@ -447,6 +629,11 @@ func (s Sysbreaker) asgs(appName, account, teamName string) (result []sysbreaker
 		return nil, fmt.Errorf("failed to read body of teams url (%s): body: '%s': %v", url, string(body), err)
 	}
 
+	err = statusError(url, resp.StatusCode, body)
+	if err != nil {
+		return nil, err
+	}
+
 	// Example:
 	/*
 		[
@@ -473,14 +660,26 @@ func (s Sysbreaker) asgs(appName, account, teamName string) (result []sysbreaker
 	var asgs []sysbreakerServerGroup
 	err = json.Unmarshal(body, &asgs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse body of sysbreaker asgs url (%s): body: '%s'. %v", url, string(body), err)
+		return nil, ErrDecode{URL: url, Body: string(body), Err: err}
 	}
 
 	return asgs, nil
 }
 
-// CloudProvider returns the cloud provider for a given account name
+// CloudProvider returns the cloud provider for a given account name. Cloud
+// providers are cached, since they are the same for all apps.
 func (s Sysbreaker) CloudProvider(name string) (provider string, err error) {
+	if s.providers == nil {
+		return s.cloudProvider(name)
+	}
+
+	return s.providers.get(name, s.cloudProvider)
+}
+
+// cloudProvider retrieves the cloud provider for a given account name
+func (s Sysbreaker) cloudProvider(name string) (string, error) {
+	defer s.acquire()()
+
 	account, err := s.account(name)
 	if err != nil {
 		return "", err
@@ -562,15 +761,16 @@ func (s Sysbreaker) GetTeamNames(app string, account D.AccountName) (teams []D.T
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("unexpected response code (%d) from %s", resp.StatusCode, url)
-	}
-
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("body read failed at %s", url))
 	}
 
+	err = statusError(url, resp.StatusCode, body)
+	if err != nil {
+		return nil, err
+	}
+
 	var pcl struct {
 		Teams map[D.AccountName][]struct {
 			Name D.TeamName
@@ -606,15 +806,16 @@ func (s Sysbreaker) GetRegionNames(app string, account D.AccountName, team D.Tea
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("unexpected response code (%d) from %s", resp.StatusCode, url)
-	}
-
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("body read failed at %s", url))
 	}
 
+	err = statusError(url, resp.StatusCode, body)
+	if err != nil {
+		return nil, err
+	}
+
 	var cl struct {
 		ServerGroups []struct{ Region D.RegionName }
 	}
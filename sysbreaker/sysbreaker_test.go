@@ -0,0 +1,232 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	D "github.com/FakeTwitter/elon/deploy"
+)
+
+// appsServer serves apps app0 to app(n-1), each with a team in the prod and
+// test accounts. It records the number of requests for the accounts, and the
+// max number of requests that were served concurrently.
+type appsServer struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	inFlight       int
+	maxInFlight    int
+	accountQueries int
+}
+
+func newAppsServer() *appsServer {
+	a := &appsServer{}
+	a.Server = httptest.NewServer(http.HandlerFunc(a.serve))
+	return a
+}
+
+func (a *appsServer) serve(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	a.inFlight++
+	if a.inFlight > a.maxInFlight {
+		a.maxInFlight = a.inFlight
+	}
+	if r.URL.Path == "/credentials/" {
+		a.accountQueries++
+	}
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.inFlight--
+		a.mu.Unlock()
+	}()
+
+	// Give the other requests a chance to overlap
+	time.Sleep(10 * time.Millisecond)
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/credentials/":
+		fmt.Fprint(w, `[{"name": "prod", "cloudProvider": "aws"}, {"name": "test", "cloudProvider": "aws"}]`)
+	case len(parts) == 3 && parts[2] == "teams":
+		app := parts[1]
+		fmt.Fprintf(w, `{"prod": ["%s-prod"], "test": ["%s-beta"]}`, app, app)
+	case len(parts) == 6 && parts[5] == "teamGroups":
+		fmt.Fprintf(w, `[{"name": "%s-v001", "region": "us-east-1", "disabled": false}]`, parts[4])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// TestTeamsConcurrent verifies that apps are retrieved concurrently, within
+// the configured limit, and that the accounts are only looked up once
+func TestTeamsConcurrent(t *testing.T) {
+	ts := newAppsServer()
+	defer ts.Close()
+
+	s, err := New(ts.URL, "", "", "", "", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.concurrency = 4
+	s.sem = make(chan struct{}, s.concurrency)
+
+	var appNames []string
+	for i := 0; i < 20; i++ {
+		appNames = append(appNames, fmt.Sprintf("app%d", i))
+	}
+
+	c := make(chan *D.Team)
+	go s.Teams(c, appNames)
+
+	var got []string
+	for app := range c {
+		got = append(got, app.Name())
+
+		if n := len(app.Accounts()); n != 2 {
+			t.Errorf("got len(%s.Accounts())=%d, want 2", app.Name(), n)
+		}
+	}
+
+	sort.Strings(got)
+	want := append([]string(nil), appNames...)
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got apps=%v, want %v", got, want)
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.maxInFlight > s.concurrency {
+		t.Errorf("got %d concurrent requests, want at most %d", ts.maxInFlight, s.concurrency)
+	}
+
+	if ts.maxInFlight < 2 {
+		t.Errorf("got %d concurrent requests, want apps to be retrieved concurrently", ts.maxInFlight)
+	}
+
+	// Once for each account
+	if got, want := ts.accountQueries, 2; got != want {
+		t.Errorf("got %d account queries, want %d", got, want)
+	}
+}
+
+// TestGetTeamErrors verifies that failures to retrieve an app are reported
+// with typed errors instead of exiting
+func TestGetTeamErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		check  func(err error) bool
+	}{
+		{http.StatusNotFound, `{}`, func(err error) bool { _, ok := err.(ErrNotFound); return ok }},
+		{http.StatusUnauthorized, `{}`, func(err error) bool { _, ok := err.(ErrUnauthorized); return ok }},
+		{http.StatusForbidden, `{}`, func(err error) bool { _, ok := err.(ErrUnauthorized); return ok }},
+		{http.StatusInternalServerError, `oops`, func(err error) bool { _, ok := err.(ErrServer); return ok }},
+		{http.StatusOK, `not json`, func(err error) bool { _, ok := err.(ErrDecode); return ok }},
+	}
+
+	for _, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, tt.body)
+		}))
+
+		s, err := New(ts.URL, "", "", "", "", "user@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = s.GetTeam("foo")
+		if err == nil || !tt.check(err) {
+			t.Errorf("status=%d body=%s: got err=%#v", tt.status, tt.body, err)
+		}
+
+		ts.Close()
+	}
+}
+
+// TestNotFound verifies that the app and team lookups report resources
+// that Sysbreaker doesn't know with ErrNotFound
+func TestNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	s, err := New(ts.URL, "", "", "", "", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Get("foo")
+	if _, ok := err.(ErrNotFound); !ok {
+		t.Errorf("Get: got err=%#v, want ErrNotFound", err)
+	}
+
+	_, err = s.GetTeamNames("foo", "prod")
+	if _, ok := err.(ErrNotFound); !ok {
+		t.Errorf("GetTeamNames: got err=%#v, want ErrNotFound", err)
+	}
+
+	_, err = s.GetRegionNames("foo", "prod", "foo-prod")
+	if _, ok := err.(ErrNotFound); !ok {
+		t.Errorf("GetRegionNames: got err=%#v, want ErrNotFound", err)
+	}
+}
+
+// TestTeamsWithFailures verifies that the apps that can't be retrieved are
+// reported, and the others are sent
+func TestTeamsWithFailures(t *testing.T) {
+	ts := newAppsServer()
+	defer ts.Close()
+
+	s, err := New(ts.URL, "", "", "", "", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	failed := make(map[string]error)
+
+	c := make(chan *D.Team)
+	go s.TeamsWithFailures(c, []string{"app0", "missing/app", "app1"}, func(appName string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed[appName] = err
+	})
+
+	var got []string
+	for app := range c {
+		got = append(got, app.Name())
+	}
+	sort.Strings(got)
+
+	if strings.Join(got, ",") != "app0,app1" {
+		t.Errorf("got apps=%v, want [app0 app1]", got)
+	}
+
+	if _, ok := failed["missing/app"].(ErrNotFound); !ok || len(failed) != 1 {
+		t.Errorf("got failed=%v, want missing/app not found", failed)
+	}
+}
@@ -69,19 +69,46 @@ func (t fakeTerminator) Execute(trm elon.Termination) error {
 }
 
 // Execute implements term.Terminator.Execute
-func (s Sysbreaker) Execute(trm elon.Termination) (err error) {
+func (s Sysbreaker) Execute(trm elon.Termination) error {
+	_, err := s.ExecuteTask(trm)
+	return err
+}
+
+// ExecuteTask implements elon.TaskTerminator.ExecuteTask. The reference of
+// the task is the one returned by Sysbreaker, e.g. "/tasks/01BZ8R2Y4WG0DR6YJ1DYSPB6H7"
+//
+// If a task timeout is configured, ExecuteTask waits for the task to finish,
+// and returns an error if the task failed, or elon.ErrTaskTimeout if it
+// didn't finish in time.
+func (s Sysbreaker) ExecuteTask(trm elon.Termination) (string, error) {
+	ref, err := s.submitTask(trm)
+	if err != nil || s.taskTimeout == 0 {
+		return ref, err
+	}
+
+	if ref == "" {
+		log.Printf("WARNING: no task reference returned for termination of %s, not waiting for it", trm.employee.ID())
+		return "", nil
+	}
+
+	return ref, s.waitForTask(ref)
+}
+
+// submitTask posts the task that terminates the employee of trm, and returns
+// its reference
+func (s Sysbreaker) submitTask(trm elon.Termination) (ref string, err error) {
 	ins := trm.employee
 	url := s.tasksURL(ins.TeamName())
 
 	otherID, err := s.OtherID(ins)
 	if err != nil {
-		return errors.Wrap(err, "retrieve other id failed")
+		return "", errors.Wrap(err, "retrieve other id failed")
 	}
 
 	payload := fireJSONPayload(ins, otherID, s.user)
 	resp, err := s.client.Post(url, "application/json", bytes.NewReader(payload))
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("POST to %s failed, (body '%s')", url, string(payload)))
+		return "", errors.Wrap(err, fmt.Sprintf("POST to %s failed, (body '%s')", url, string(payload)))
 	}
 
 	defer func() {
@@ -90,16 +117,111 @@ func (s Sysbreaker) Execute(trm elon.Termination) (err error) {
 		}
 	}()
 
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read response body")
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("Unexpected response: %d", resp.StatusCode)
-		contents, err := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("unexpected response code: %d, body: %s", resp.StatusCode, string(contents))
+	}
+
+	// Example of response body:
+	// {"ref": "/tasks/01BZ8R2Y4WG0DR6YJ1DYSPB6H7"}
+	var task struct {
+		Ref string `json:"ref"`
+	}
+
+	// The termination was accepted even if the task can't be identified,
+	// so this isn't an error
+	err = json.Unmarshal(contents, &task)
+	if err != nil {
+		log.Printf("WARNING: could not read task reference from body '%s': %v", contents, err)
+		return "", nil
+	}
+
+	return task.Ref, nil
+}
+
+// Statuses of Sysbreaker tasks. The other statuses, e.g., "RUNNING", are
+// those of tasks that haven't finished yet
+const (
+	taskSucceeded      = "SUCCEEDED"
+	taskTerminal       = "TERMINAL"
+	taskCanceled       = "CANCELED"
+	taskStopped        = "STOPPED"
+	taskSkipped        = "SKIPPED"
+	taskFailedContinue = "FAILED_CONTINUE"
+)
+
+// waitForTask polls the status of the task at ref until it finishes or the
+// task timeout passes. Returns an error if the task didn't succeed.
+//
+// Failures to retrieve the status are retried until the timeout passes,
+// since the task may be running regardless
+func (s Sysbreaker) waitForTask(ref string) error {
+	deadline := s.cl.Now().Add(s.taskTimeout)
+	var status string
+
+	for {
+		st, err := s.taskStatus(ref)
 		if err != nil {
-			return errors.Wrap(err, "failed to read response body")
+			log.Printf("WARNING: could not retrieve status of task %s: %v", ref, err)
+		} else {
+			status = st
+		}
+
+		switch status {
+		case taskSucceeded:
+			return nil
+		case taskTerminal, taskCanceled, taskStopped, taskSkipped, taskFailedContinue:
+			return errors.Errorf("task %s finished with status %s", ref, status)
+		}
+
+		if !s.cl.Now().Add(s.pollInterval).Before(deadline) {
+			return elon.ErrTaskTimeout{Ref: ref, Status: status, Timeout: s.taskTimeout}
 		}
-		return fmt.Errorf("unexpected response code: %d, body: %s", resp.StatusCode, string(contents))
+
+		s.sleep(s.pollInterval)
 	}
+}
 
-	return nil
+// taskStatus returns the status of the task at ref
+func (s Sysbreaker) taskStatus(ref string) (status string, err error) {
+	url := s.taskURL(ref)
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return "", errors.Wrapf(err, "get failed on %s", url)
+	}
+
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil && err == nil {
+			err = errors.Wrapf(cerr, "failed to close response body from %s", url)
+		}
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "body read failed at %s", url)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status code: %d. body: %s", resp.StatusCode, body)
+	}
+
+	// Example of response body:
+	// {"id": "01BZ8R2Y4WG0DR6YJ1DYSPB6H7", "status": "RUNNING", ...}
+	var task struct {
+		Status string `json:"status"`
+	}
+
+	err = json.Unmarshal(body, &task)
+	if err != nil {
+		return "", errors.Wrapf(err, "json unmarshal failed, body: %s", body)
+	}
+
+	return task.Status, nil
 }
 
 // fireJsonPayload generates the JSON request body for terminating an employee
@@ -16,8 +16,15 @@ package sysbreaker
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
 	"github.com/FakeTwitter/elon/mock"
 )
 
@@ -168,3 +175,155 @@ func TestFireJSONPayloadWithOtherID(t *testing.T) {
 		t.Errorf("got: %s, want: %s", got, want)
 	}
 }
+
+// taskServer returns a test server that accepts terminations of employee
+// i-703a0439 of app foo, and responds to requests for the status of the
+// task with the statuses, the last one being repeated
+func taskServer(t *testing.T, statuses ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/employees/test/us-west-2/i-703a0439":
+			fmt.Fprint(w, `{"health": []}`)
+		case "/applications/foo/tasks":
+			if r.Method != "POST" {
+				t.Errorf("got method=%s, want POST", r.Method)
+			}
+			fmt.Fprint(w, `{"ref": "/tasks/01BZ8R2Y4WG0DR6YJ1DYSPB6H7"}`)
+		case "/tasks/01BZ8R2Y4WG0DR6YJ1DYSPB6H7":
+			fmt.Fprintf(w, `{"id": "01BZ8R2Y4WG0DR6YJ1DYSPB6H7", "status": "%s"}`, statuses[0])
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// testTermination returns the termination of employee i-703a0439 of app foo
+func testTermination() elon.Termination {
+	ins := mock.employee{
+		Team:        "foo",
+		Account:    "test",
+		Region:     "us-west-2",
+		ASG:        "foo-beta-v052",
+		EmployeeId: "i-703a0439",
+	}
+
+	return elon.Termination{employee: ins, Time: time.Now()}
+}
+
+// TestExecuteTaskRef verifies that the reference of the task that terminates
+// the employee is returned
+func TestExecuteTaskRef(t *testing.T) {
+	ts := taskServer(t, "RUNNING")
+	defer ts.Close()
+
+	s, err := New(ts.URL, "", "", "", "", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a task timeout, the task isn't waited for
+	ref, err := s.ExecuteTask(testTermination())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ref, "/tasks/01BZ8R2Y4WG0DR6YJ1DYSPB6H7"; got != want {
+		t.Errorf("got ref=%s, want %s", got, want)
+	}
+}
+
+// TestExecuteTaskWaits verifies that the final status of the task is the
+// result of the termination
+func TestExecuteTaskWaits(t *testing.T) {
+	tests := []struct {
+		statuses []string
+		ok       bool
+	}{
+		{[]string{"NOT_STARTED", "RUNNING", "SUCCEEDED"}, true},
+		{[]string{"RUNNING", "TERMINAL"}, false},
+		{[]string{"CANCELED"}, false},
+	}
+
+	for _, tt := range tests {
+		ts := taskServer(t, tt.statuses...)
+
+		s, err := New(ts.URL, "", "", "", "", "user@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.taskTimeout = time.Minute
+		s.pollInterval = time.Millisecond
+
+		_, err = s.ExecuteTask(testTermination())
+		ts.Close()
+
+		if got, want := err == nil, tt.ok; got != want {
+			t.Errorf("statuses=%v: got err=%v", tt.statuses, err)
+		}
+	}
+}
+
+// TestExecuteTaskTimeout verifies that a task that doesn't finish in time
+// results in an ErrTaskTimeout
+func TestExecuteTaskTimeout(t *testing.T) {
+	ts := taskServer(t, "RUNNING")
+	defer ts.Close()
+
+	s, err := New(ts.URL, "", "", "", "", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := &mock.Clock{Time: time.Date(2017, time.October, 2, 11, 0, 0, 0, time.UTC)}
+	var polls int
+	s.cl = cl
+	s.sleep = func(d time.Duration) {
+		polls++
+		cl.Time = cl.Time.Add(d)
+	}
+	s.taskTimeout = 5 * time.Minute
+	s.pollInterval = 5 * time.Second
+
+	_, err = s.ExecuteTask(testTermination())
+
+	e, ok := err.(elon.ErrTaskTimeout)
+	if !ok {
+		t.Fatalf("Expected elon.ErrTaskTimeout, got %v", err)
+	}
+
+	if got, want := e.Status, "RUNNING"; got != want {
+		t.Errorf("got e.Status=%s, want %s", got, want)
+	}
+
+	if got, want := polls, 59; got != want {
+		t.Errorf("got %d polls, want %d", got, want)
+	}
+}
+
+// TestInvalidTaskPollInterval verifies that a task timeout requires a
+// positive poll interval
+func TestInvalidTaskPollInterval(t *testing.T) {
+	tests := []struct {
+		timeout, interval int
+		ok                bool
+	}{
+		{300, 5, true},
+		{300, 0, false},
+		{300, -1, false},
+		{0, 0, true},
+	}
+
+	for _, tt := range tests {
+		cfg := config.Defaults()
+		cfg.Set(param.SysbreakerEndpoint, "http://localhost:8084")
+		cfg.Set(param.SysbreakerTaskTimeout, tt.timeout)
+		cfg.Set(param.SysbreakerTaskPollInterval, tt.interval)
+
+		_, err := NewFromConfig(cfg)
+		if got, want := err == nil, tt.ok; got != want {
+			t.Errorf("timeout=%d interval=%d: got err=%v", tt.timeout, tt.interval, err)
+		}
+	}
+}
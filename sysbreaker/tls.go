@@ -0,0 +1,156 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TLSConfig configures how the certificate of Sysbreaker is verified
+type TLSConfig struct {
+	// CABundle is the path to a PEM file with the certificate authorities
+	// that are trusted to sign the certificate of Sysbreaker. If blank, the
+	// system's certificate authorities are trusted
+	CABundle string
+
+	// ServerName is the name that the certificate of Sysbreaker is verified
+	// against. If blank, the host of the endpoint is used
+	ServerName string
+
+	// InsecureSkipVerify disables the verification of the certificate of
+	// Sysbreaker. This should only be used for testing
+	InsecureSkipVerify bool
+}
+
+// isZero returns true if c doesn't change the default verification
+func (c TLSConfig) isZero() bool {
+	return c == TLSConfig{}
+}
+
+// tlsConfig returns the tls.Config that verifies the certificate of
+// Sysbreaker according to c
+func (c TLSConfig) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CABundle != "" {
+		pem, err := ioutil.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read CA bundle %s", c.CABundle)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in CA bundle %s", c.CABundle)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.InsecureSkipVerify {
+		log.Println("WARNING: the certificate of sysbreaker is not verified")
+	}
+
+	return cfg, nil
+}
+
+// certReloader provides the client certificate, and reloads it when its
+// files change, so that rotated certificates are used without restarting
+type certReloader struct {
+	// paths are the files the certificate is loaded from
+	paths []string
+
+	// load loads the certificate from the files
+	load func() (tls.Certificate, error)
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modTimes []time.Time
+}
+
+// newCertReloader returns a certReloader that loads the certificate from
+// paths with load. The certificate is loaded right away, to report errors
+// early.
+func newCertReloader(load func() (tls.Certificate, error), paths ...string) (*certReloader, error) {
+	r := &certReloader{paths: paths, load: load}
+
+	_, err := r.get()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.get()
+}
+
+// get returns the certificate, reloading it if its files changed. If the new
+// certificate can't be loaded, e.g., because only one of the files was
+// replaced yet, the previous one is used.
+func (r *certReloader) get() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes := make([]time.Time, len(r.paths))
+	changed := r.cert == nil
+	for i, path := range r.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			if r.cert != nil {
+				log.Printf("WARNING: could not stat %s, using previous client certificate: %v", path, err)
+				return r.cert, nil
+			}
+			return nil, errors.Wrapf(err, "could not stat %s", path)
+		}
+
+		modTimes[i] = info.ModTime()
+		if r.cert != nil && !modTimes[i].Equal(r.modTimes[i]) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return r.cert, nil
+	}
+
+	cert, err := r.load()
+	if err != nil {
+		if r.cert != nil {
+			log.Printf("WARNING: could not reload client certificate, using previous one: %v", err)
+			return r.cert, nil
+		}
+		return nil, err
+	}
+
+	if r.cert != nil {
+		log.Printf("reloaded client certificate from %v", r.paths)
+	}
+
+	r.cert = &cert
+	r.modTimes = modTimes
+	return r.cert, nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tempDir returns a temporary directory, and a function that removes it
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "sysbreaker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestTLSConfig(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	bundle := filepath.Join(dir, "ca.pem")
+	err := ioutil.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cfg TLSConfig
+		ok  bool
+	}{
+		{TLSConfig{}, false},
+		{TLSConfig{CABundle: bundle}, true},
+		{TLSConfig{CABundle: bundle, ServerName: "example.com"}, true},
+		{TLSConfig{CABundle: bundle, ServerName: "sysbreaker.example.net"}, false},
+		{TLSConfig{InsecureSkipVerify: true}, true},
+	}
+
+	for _, tt := range tests {
+		tlsConfig, err := tt.cfg.tlsConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := client.Get(ts.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		if got, want := err == nil, tt.ok; got != want {
+			t.Errorf("%+v: got ok=%t, want %t (err=%v)", tt.cfg, got, want, err)
+		}
+	}
+
+	_, err = TLSConfig{CABundle: filepath.Join(dir, "missing.pem")}.tlsConfig()
+	if err == nil {
+		t.Error("Expected an error for a missing CA bundle")
+	}
+}
+
+// writeKeyPair writes a self-signed certificate with the given serial
+// number, and its key, to certPath and keyPath
+func writeKeyPair(t *testing.T, certPath, keyPath string, serial int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "elon"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure the modification times change, whatever their resolution
+	mtime := time.Now().Add(time.Duration(serial) * time.Minute)
+	for _, path := range []string{certPath, keyPath} {
+		err = os.Chtimes(path, mtime, mtime)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// serial returns the serial number of cert
+func serial(t *testing.T, cert *tls.Certificate) int64 {
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.SerialNumber.Int64()
+}
+
+// TestCertReload verifies that rotated client certificates are used
+func TestCertReload(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	writeKeyPair(t, certPath, keyPath, 1)
+
+	load := func() (tls.Certificate, error) { return tls.LoadX509KeyPair(certPath, keyPath) }
+	r, err := newCertReloader(load, certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := r.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := serial(t, cert), int64(1); got != want {
+		t.Errorf("got serial=%d, want %d", got, want)
+	}
+
+	writeKeyPair(t, certPath, keyPath, 2)
+
+	cert, err = r.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := serial(t, cert), int64(2); got != want {
+		t.Errorf("got serial=%d, want %d", got, want)
+	}
+
+	// A broken certificate doesn't replace the previous one
+	err = ioutil.WriteFile(keyPath, []byte("not a key"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err = r.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := serial(t, cert), int64(2); got != want {
+		t.Errorf("got serial=%d, want %d", got, want)
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/FakeTwitter/elon/metrics"
+)
+
+// transportConfig configures the resilience of the requests to Sysbreaker
+type transportConfig struct {
+	// timeout is the timeout of each attempt of a request, including
+	// reading the response. If zero, requests don't time out
+	timeout time.Duration
+
+	// maxRetries is the max number of retries of a failed request
+	maxRetries int
+
+	// backoff is the delay before the first retry, doubled for each retry
+	backoff time.Duration
+
+	// maxBackoff is the max delay between retries
+	maxBackoff time.Duration
+
+	// rateLimit is the max number of requests per second. If zero, requests
+	// aren't limited
+	rateLimit float64
+
+	// breakerThreshold is the number of consecutive failed requests after
+	// which requests fail fast. If zero, requests never fail fast
+	breakerThreshold int
+
+	// breakerCooldown is how long requests fail fast before a request is
+	// tried again
+	breakerCooldown time.Duration
+}
+
+// transport is an http.RoundTripper that adds timeouts, retries with
+// exponential backoff, a rate limit and a circuit breaker to requests
+type transport struct {
+	base    http.RoundTripper
+	cfg     transportConfig
+	limiter *rateLimiter
+	breaker *circuitBreaker
+}
+
+// newTransport returns a transport that sends requests through base, or
+// through http.DefaultTransport if base is nil
+func newTransport(base http.RoundTripper, cfg transportConfig) *transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{
+		base:    base,
+		cfg:     cfg,
+		limiter: newRateLimiter(cfg.rateLimit),
+		breaker: &circuitBreaker{threshold: cfg.breakerThreshold, cooldown: cfg.breakerCooldown, now: time.Now},
+	}
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if until, ok := t.breaker.allow(); !ok {
+		return nil, ErrCircuitOpen{URL: req.URL.String(), Until: until}
+	}
+
+	resp, err := t.roundTrip(req)
+	t.breaker.record(err == nil && !failedStatus(resp.StatusCode))
+
+	return resp, err
+}
+
+// roundTrip sends req, and retries it while it fails and may be retried
+func (t *transport) roundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.WithContext(req.Context())
+			r.Body = body
+		}
+
+		err := t.limiter.wait(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.attempt(r)
+		if attempt >= t.cfg.maxRetries || !retryable(req, resp, err) {
+			return resp, err
+		}
+
+		delay := t.delay(attempt, resp)
+		if resp != nil {
+			// Drain the body so that the connection is reused
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// attempt sends req once, with the configured timeout, and records how long
+// it took
+func (t *transport) attempt(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.send(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.SysbreakerRequestDuration.WithLabelValues(req.Method, code).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
+
+// send sends req once, with the configured timeout
+func (t *transport) send(req *http.Request) (*http.Response, error) {
+	if t.cfg.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.cfg.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The timeout also applies to reading the body
+	resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// delay returns how long to wait before retrying a request that failed for
+// the attempt-th time (starting at zero) with resp, which may be nil
+func (t *transport) delay(attempt int, resp *http.Response) time.Duration {
+	// Sysbreaker knows best when it can accept requests again
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return t.capped(time.Duration(seconds) * time.Second)
+		}
+	}
+
+	d := t.capped(t.cfg.backoff << uint(attempt))
+
+	// Jitter spreads out the retries of concurrent requests
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// capped returns d, or the max backoff if d is larger
+func (t *transport) capped(d time.Duration) time.Duration {
+	if t.cfg.maxBackoff > 0 && (d > t.cfg.maxBackoff || d < 0) {
+		return t.cfg.maxBackoff
+	}
+	return d
+}
+
+// retryable returns true if a request that returned resp and err may be
+// retried. Idempotent requests are retried on errors and 5xx responses, and
+// all requests are retried when Sysbreaker asks for fewer requests (429),
+// since it didn't process them.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	replayable := req.Body == nil || req.GetBody != nil
+
+	switch {
+	case err != nil:
+		return idempotent
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return idempotent || replayable
+	case resp.StatusCode >= 500:
+		return idempotent
+	default:
+		return false
+	}
+}
+
+// failedStatus returns true if a response with statusCode counts as a
+// failure of Sysbreaker for the circuit breaker
+func failedStatus(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusTooManyRequests
+}
+
+// cancelBody cancels the context of a request when its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// rateLimiter spaces out requests evenly
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns a rateLimiter that allows perSecond requests per
+// second, or nil if perSecond isn't positive
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request may be sent, or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// circuitBreaker makes requests fail fast after a number of consecutive
+// failures. After the cooldown, one request is let through: if it succeeds,
+// requests are sent again, otherwise they fail fast for another cooldown.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	now       func() time.Time
+}
+
+// allow returns true if a request may be sent, or false and the time until
+// which requests fail fast
+func (b *circuitBreaker) allow() (time.Time, bool) {
+	if b.threshold <= 0 {
+		return time.Time{}, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return time.Time{}, true
+	}
+
+	now := b.now()
+	if now.Before(b.openUntil) {
+		return b.openUntil, false
+	}
+
+	// Let this request through, and fail fast while it's in flight
+	b.openUntil = now.Add(b.cooldown)
+	return time.Time{}, true
+}
+
+// record records whether a request succeeded
+func (b *circuitBreaker) record(ok bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ok {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
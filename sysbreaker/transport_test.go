@@ -0,0 +1,214 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreaker
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status, and then
+// succeeds. It counts the requests.
+func flakyServer(failures int32, status int, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(requests, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func testClient(cfg transportConfig) *http.Client {
+	return &http.Client{Transport: newTransport(nil, cfg)}
+}
+
+func TestRetries(t *testing.T) {
+	var requests int32
+	ts := flakyServer(2, http.StatusBadGateway, &requests)
+	defer ts.Close()
+
+	client := testClient(transportConfig{maxRetries: 3, backoff: time.Millisecond})
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("got resp.StatusCode=%d, want %d", got, want)
+	}
+
+	if got, want := atomic.LoadInt32(&requests), int32(3); got != want {
+		t.Errorf("got %d requests, want %d", got, want)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	var requests int32
+	ts := flakyServer(10, http.StatusInternalServerError, &requests)
+	defer ts.Close()
+
+	client := testClient(transportConfig{maxRetries: 2, backoff: time.Millisecond})
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusInternalServerError; got != want {
+		t.Errorf("got resp.StatusCode=%d, want %d", got, want)
+	}
+
+	if got, want := atomic.LoadInt32(&requests), int32(3); got != want {
+		t.Errorf("got %d requests, want %d", got, want)
+	}
+}
+
+// TestNoRetryPost verifies that requests that aren't idempotent are only
+// retried when Sysbreaker rejects them with 429
+func TestNoRetryPost(t *testing.T) {
+	var requests int32
+	ts := flakyServer(1, http.StatusInternalServerError, &requests)
+	defer ts.Close()
+
+	client := testClient(transportConfig{maxRetries: 3, backoff: time.Millisecond})
+
+	resp, err := client.Post(ts.URL, "application/json", bytes.NewReader([]byte(`{}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got, want := atomic.LoadInt32(&requests), int32(1); got != want {
+		t.Errorf("got %d requests, want %d", got, want)
+	}
+
+	requests = 0
+	ts429 := flakyServer(1, http.StatusTooManyRequests, &requests)
+	defer ts429.Close()
+
+	resp, err = client.Post(ts429.URL, "application/json", bytes.NewReader([]byte(`{}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("got resp.StatusCode=%d, want %d", got, want)
+	}
+
+	if got, want := atomic.LoadInt32(&requests), int32(2); got != want {
+		t.Errorf("got %d requests, want %d", got, want)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	client := testClient(transportConfig{timeout: 10 * time.Millisecond})
+
+	start := time.Now()
+	_, err := client.Get(ts.URL)
+	if err == nil {
+		t.Fatal("Expected a timeout")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("request took %s, want it to time out", elapsed)
+	}
+}
+
+// TestCircuitBreaker verifies that requests fail fast after consecutive
+// failures, until a request succeeds after the cooldown
+func TestCircuitBreaker(t *testing.T) {
+	var requests int32
+	ts := flakyServer(2, http.StatusServiceUnavailable, &requests)
+	defer ts.Close()
+
+	now := time.Date(2016, time.January, 4, 10, 0, 0, 0, time.UTC)
+	tr := newTransport(nil, transportConfig{breakerThreshold: 2, breakerCooldown: time.Minute})
+	tr.breaker.now = func() time.Time { return now }
+	client := &http.Client{Transport: tr}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// The circuit is open: the request isn't sent
+	_, err := client.Get(ts.URL)
+	if err == nil {
+		t.Fatal("Expected the request to fail fast")
+	}
+
+	if got, want := atomic.LoadInt32(&requests), int32(2); got != want {
+		t.Errorf("got %d requests, want %d", got, want)
+	}
+
+	// After the cooldown, requests are sent again
+	now = now.Add(time.Minute)
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("got resp.StatusCode=%d, want %d", got, want)
+	}
+
+	if _, ok := tr.breaker.allow(); !ok {
+		t.Error("Expected the circuit to be closed after a successful request")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var requests int32
+	ts := flakyServer(0, http.StatusOK, &requests)
+	defer ts.Close()
+
+	// One request every 20ms
+	client := testClient(transportConfig{rateLimit: 50})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("4 requests took %s, want at least 60ms", elapsed)
+	}
+}
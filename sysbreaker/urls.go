@@ -55,6 +55,12 @@ func (s Sysbreaker) accountsURL(expanded bool) string {
 	return fmt.Sprintf("%s/credentials/"+qs, s.endpoint)
 }
 
+// taskURL returns the Sysbreaker endpoint for retrieving a task from its
+// reference, e.g. "/tasks/01BZ8R2Y4WG0DR6YJ1DYSPB6H7"
+func (s Sysbreaker) taskURL(ref string) string {
+	return s.endpoint + ref
+}
+
 // employeeURL returns the sysbreaker URL for an employee
 func (s Sysbreaker) employeeURL(account string, region string, id string) string {
 	return fmt.Sprintf("%s/employees/%s/%s/%s", s.endpoint, account, region, id)
@@ -0,0 +1,549 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sysbreakertest provides a fake Sysbreaker server for tests.
+//
+// The fake serves the parts of the Sysbreaker API that Elon uses, from a
+// fleet that is defined programmatically. Terminations are recorded, and the
+// terminated employees are removed from the fleet, so that a whole
+// schedule-then-terminate flow can be exercised:
+//
+//	s := sysbreakertest.NewServer()
+//	defer s.Close()
+//
+//	s.AddAccount("prod", "aws", "123456789012")
+//	s.AddASG("foo", "prod", "foo-prod", "us-east-1", "foo-prod-v001", "i-1", "i-2")
+//	s.SetConfig("foo", `{"enabled": true, "meanTimeBetweenFiresInWorkDays": 2, "minTimeBetweenFiresInWorkDays": 1}`)
+//
+//	sb, err := sysbreaker.New(s.URL, "", "", "", "", "elon@example.com")
+//	...
+//	terminated := s.Terminated()
+package sysbreakertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Task statuses that can be set with SetTaskStatus
+const (
+	TaskRunning   = "RUNNING"
+	TaskSucceeded = "SUCCEEDED"
+	TaskTerminal  = "TERMINAL"
+)
+
+// Termination is a termination requested from the fake
+type Termination struct {
+	App           string
+	Account       string
+	Region        string
+	ASG           string
+	EmployeeID    string
+	CloudProvider string
+	User          string
+}
+
+// Server is a fake Sysbreaker server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	apps       map[string]*app
+	accounts   map[string]account
+	failures   []*failure
+	terminated []Termination
+	taskStatus string
+	tasks      int
+}
+
+// app is an app of the fleet
+type app struct {
+	config json.RawMessage
+	owner  string
+
+	// teams maps account names to team names to ASGs
+	teams map[string]map[string][]*asg
+}
+
+// asg is an autoscaling group of a team
+type asg struct {
+	name      string
+	region    string
+	disabled  bool
+	employees []string
+}
+
+// account is a Sysbreaker account
+type account struct {
+	cloudProvider string
+	id            string
+}
+
+// failure is an injected failure of the requests whose path starts with
+// prefix
+type failure struct {
+	prefix string
+	status int
+
+	// remaining is the number of requests that still fail, or negative if
+	// they always fail
+	remaining int
+}
+
+// NewServer starts and returns a fake Sysbreaker server with an empty
+// fleet. The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		apps:       make(map[string]*app),
+		accounts:   make(map[string]account),
+		taskStatus: TaskSucceeded,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// AddAccount adds an account with the given cloud provider (e.g., "aws") and
+// account id
+func (s *Server) AddAccount(name, cloudProvider, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accounts[name] = account{cloudProvider: cloudProvider, id: id}
+}
+
+// AddASG adds an ASG with employees to a team of an app, in a region. The
+// app and the team are created if needed, and so is the account, with the
+// "aws" cloud provider. ASGs are sorted by name, the last one being the
+// active one.
+func (s *Server) AddASG(appName, accountName, team, region, asgName string, employeeIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[accountName]; !ok {
+		s.accounts[accountName] = account{cloudProvider: "aws"}
+	}
+
+	a := s.app(appName)
+	if a.teams[accountName] == nil {
+		a.teams[accountName] = make(map[string][]*asg)
+	}
+
+	asgs := append(a.teams[accountName][team], &asg{name: asgName, region: region, employees: employeeIDs})
+	sort.Slice(asgs, func(i, j int) bool { return asgs[i].name < asgs[j].name })
+	a.teams[accountName][team] = asgs
+}
+
+// DisableASG disables an ASG. Elon doesn't terminate employees of disabled
+// ASGs.
+func (s *Server) DisableASG(appName, accountName, team, asgName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, g := range s.app(appName).teams[accountName][team] {
+		if g.name == asgName {
+			g.disabled = true
+		}
+	}
+}
+
+// SetConfig sets the Elon config of an app, i.e., its "elon" attribute, in
+// the format parsed by sysbreaker.FromJSON. The app is created if needed.
+func (s *Server) SetConfig(appName string, config string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.app(appName).config = json.RawMessage(config)
+}
+
+// SetOwner sets the email address of the owner of an app. The app is created
+// if needed.
+func (s *Server) SetOwner(appName string, email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.app(appName).owner = email
+}
+
+// InjectFailure makes the next times requests whose path starts with prefix
+// (e.g., "/applications/foo") fail with status. If times isn't positive,
+// they always fail.
+func (s *Server) InjectFailure(prefix string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if times <= 0 {
+		times = -1
+	}
+	s.failures = append(s.failures, &failure{prefix: prefix, status: status, remaining: times})
+}
+
+// ClearFailures removes the injected failures
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = nil
+}
+
+// SetTaskStatus sets the status that is reported for termination tasks, e.g.
+// TaskRunning to simulate tasks that don't finish. Tasks succeed by default.
+func (s *Server) SetTaskStatus(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.taskStatus = status
+}
+
+// Terminated returns the terminations that were requested, in order
+func (s *Server) Terminated() []Termination {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Termination(nil), s.terminated...)
+}
+
+// app returns the app named name, creating it if needed. s.mu must be held.
+func (s *Server) app(name string) *app {
+	a, ok := s.apps[name]
+	if !ok {
+		a = &app{teams: make(map[string]map[string][]*asg)}
+		s.apps[name] = a
+	}
+	return a
+}
+
+// serve handles a request to the fake
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, f := range s.failures {
+		if f.remaining != 0 && strings.HasPrefix(r.URL.Path, f.prefix) {
+			if f.remaining > 0 {
+				f.remaining--
+			}
+			http.Error(w, `{"error": "injected failure"}`, f.status)
+			return
+		}
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "applications" && parts[2] == "tasks":
+		s.submitTask(w, r)
+	case r.Method != http.MethodGet:
+		http.Error(w, `{"error": "method not allowed"}`, http.StatusMethodNotAllowed)
+	case len(parts) == 1 && parts[0] == "applications":
+		s.serveApps(w)
+	case len(parts) == 2 && parts[0] == "applications":
+		s.serveApp(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "applications" && parts[2] == "teams":
+		s.serveTeams(w, r, parts[1])
+	case len(parts) == 5 && parts[0] == "applications" && parts[2] == "teams":
+		s.serveTeam(w, r, parts[1], parts[3], parts[4])
+	case len(parts) == 6 && parts[0] == "applications" && parts[5] == "teamGroups":
+		s.serveASGs(w, r, parts[1], parts[3], parts[4])
+	case len(parts) == 10 && parts[0] == "applications" && parts[7] == "teamGroups" && parts[8] == "target" && parts[9] == "CURRENT":
+		s.serveActiveASG(w, r, parts[1], parts[3], parts[4], parts[6])
+	case len(parts) == 1 && parts[0] == "credentials":
+		s.serveAccounts(w)
+	case len(parts) == 2 && parts[0] == "credentials":
+		s.serveAccount(w, r, parts[1])
+	case len(parts) == 4 && parts[0] == "employees":
+		s.serveEmployee(w, r, parts[1], parts[2], parts[3])
+	case len(parts) == 2 && parts[0] == "tasks":
+		s.serveTask(w, parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// writeJSON writes v as the JSON body of the response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) serveApps(w http.ResponseWriter) {
+	names := make([]string, 0, len(s.apps))
+	for name := range s.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	apps := make([]map[string]string, len(names))
+	for i, name := range names {
+		apps[i] = map[string]string{"name": name}
+	}
+
+	writeJSON(w, apps)
+}
+
+func (s *Server) serveApp(w http.ResponseWriter, r *http.Request, appName string) {
+	a, ok := s.apps[appName]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	attributes := make(map[string]interface{})
+	if a.config != nil {
+		attributes["elon"] = a.config
+	}
+	if a.owner != "" {
+		attributes["email"] = a.owner
+	}
+
+	teams := make(map[string][]map[string]string)
+	for accountName, accountTeams := range a.teams {
+		for _, team := range sortedKeys(accountTeams) {
+			teams[accountName] = append(teams[accountName], map[string]string{"name": team})
+		}
+	}
+
+	writeJSON(w, map[string]interface{}{"name": appName, "attributes": attributes, "teams": teams})
+}
+
+func (s *Server) serveTeams(w http.ResponseWriter, r *http.Request, appName string) {
+	a, ok := s.apps[appName]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	teams := make(map[string][]string)
+	for accountName, accountTeams := range a.teams {
+		teams[accountName] = sortedKeys(accountTeams)
+	}
+
+	writeJSON(w, teams)
+}
+
+func (s *Server) serveTeam(w http.ResponseWriter, r *http.Request, appName, accountName, team string) {
+	asgs, ok := s.asgs(appName, accountName, team)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	groups := make([]map[string]string, len(asgs))
+	for i, g := range asgs {
+		groups[i] = map[string]string{"name": g.name, "region": g.region}
+	}
+
+	writeJSON(w, map[string]interface{}{"name": team, "serverGroups": groups})
+}
+
+func (s *Server) serveASGs(w http.ResponseWriter, r *http.Request, appName, accountName, team string) {
+	asgs, ok := s.asgs(appName, accountName, team)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	groups := make([]map[string]interface{}, len(asgs))
+	for i, g := range asgs {
+		groups[i] = asgJSON(g)
+	}
+
+	writeJSON(w, groups)
+}
+
+func (s *Server) serveActiveASG(w http.ResponseWriter, r *http.Request, appName, accountName, team, region string) {
+	asgs, _ := s.asgs(appName, accountName, team)
+
+	// The active ASG is the most recent enabled one in the region
+	var active *asg
+	for _, g := range asgs {
+		if g.region == region && !g.disabled {
+			active = g
+		}
+	}
+
+	if active == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	writeJSON(w, asgJSON(active))
+}
+
+// asgJSON returns the representation of an ASG in the Sysbreaker API
+func asgJSON(g *asg) map[string]interface{} {
+	employees := make([]map[string]string, len(g.employees))
+	for i, id := range g.employees {
+		employees[i] = map[string]string{"name": id}
+	}
+
+	return map[string]interface{}{"name": g.name, "region": g.region, "disabled": g.disabled, "employees": employees}
+}
+
+func (s *Server) serveAccounts(w http.ResponseWriter) {
+	accounts := make([]map[string]string, 0, len(s.accounts))
+	for _, name := range sortedKeys(s.accounts) {
+		accounts = append(accounts, map[string]string{"name": name, "cloudProvider": s.accounts[name].cloudProvider})
+	}
+
+	writeJSON(w, accounts)
+}
+
+func (s *Server) serveAccount(w http.ResponseWriter, r *http.Request, name string) {
+	a, ok := s.accounts[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, map[string]string{"error": fmt.Sprintf("account %s not found", name)})
+		return
+	}
+
+	writeJSON(w, map[string]string{"name": name, "accountId": a.id, "cloudProvider": a.cloudProvider})
+}
+
+func (s *Server) serveEmployee(w http.ResponseWriter, r *http.Request, accountName, region, id string) {
+	if _, ok := s.employee(accountName, region, id); !ok {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, map[string]string{"error": fmt.Sprintf("employee %s not found", id)})
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{"name": id, "health": []interface{}{}})
+}
+
+// submitTask terminates the employees of the jobs of a task
+func (s *Server) submitTask(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Application string `json:"application"`
+		Job         []struct {
+			User          string   `json:"user"`
+			Type          string   `json:"type"`
+			Credentials   string   `json:"credentials"`
+			Region        string   `json:"region"`
+			ASG           string   `json:"teamGroupName"`
+			EmployeeIds   []string `json:"EmployeeIds"`
+			CloudProvider string   `json:"cloudProvider"`
+		} `json:"job"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": %q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	for _, job := range payload.Job {
+		if job.Type != "terminateemployees" {
+			http.Error(w, fmt.Sprintf(`{"error": "unsupported job type %s"}`, job.Type), http.StatusBadRequest)
+			return
+		}
+
+		for _, id := range job.EmployeeIds {
+			g, ok := s.employee(job.Credentials, job.Region, id)
+			if !ok {
+				http.Error(w, fmt.Sprintf(`{"error": "employee %s not found"}`, id), http.StatusBadRequest)
+				return
+			}
+
+			g.employees = remove(g.employees, id)
+			s.terminated = append(s.terminated, Termination{
+				App:           payload.Application,
+				Account:       job.Credentials,
+				Region:        job.Region,
+				ASG:           job.ASG,
+				EmployeeID:    id,
+				CloudProvider: job.CloudProvider,
+				User:          job.User,
+			})
+		}
+	}
+
+	s.tasks++
+	writeJSON(w, map[string]string{"ref": fmt.Sprintf("/tasks/%d", s.tasks)})
+}
+
+func (s *Server) serveTask(w http.ResponseWriter, id string) {
+	writeJSON(w, map[string]string{"id": id, "status": s.taskStatus})
+}
+
+// asgs returns the ASGs of a team
+func (s *Server) asgs(appName, accountName, team string) ([]*asg, bool) {
+	a, ok := s.apps[appName]
+	if !ok {
+		return nil, false
+	}
+
+	asgs, ok := a.teams[accountName][team]
+	return asgs, ok
+}
+
+// employee returns the ASG of an employee
+func (s *Server) employee(accountName, region, id string) (*asg, bool) {
+	for _, a := range s.apps {
+		for _, g := range allASGs(a.teams[accountName]) {
+			if g.region != region {
+				continue
+			}
+			for _, e := range g.employees {
+				if e == id {
+					return g, true
+				}
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// allASGs returns the ASGs of all teams
+func allASGs(teams map[string][]*asg) []*asg {
+	var result []*asg
+	for _, asgs := range teams {
+		result = append(result, asgs...)
+	}
+	return result
+}
+
+// remove returns ids without id
+func remove(ids []string, id string) []string {
+	result := make([]string, 0, len(ids))
+	for _, i := range ids {
+		if i != id {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// sortedKeys returns the keys of a map with string keys, sorted
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string][]*asg:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]account:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
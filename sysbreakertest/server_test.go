@@ -0,0 +1,277 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysbreakertest_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/clock"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	D "github.com/FakeTwitter/elon/deploy"
+	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/mock"
+	"github.com/FakeTwitter/elon/schedule"
+	"github.com/FakeTwitter/elon/sysbreaker"
+	"github.com/FakeTwitter/elon/sysbreakertest"
+	"github.com/FakeTwitter/elon/term"
+)
+
+// testServer returns a fake with app foo, which has a team in two regions of
+// the prod account, and app bar, which has no config
+func testServer() *sysbreakertest.Server {
+	s := sysbreakertest.NewServer()
+	s.AddAccount("prod", "aws", "123456789012")
+	s.AddASG("foo", "prod", "foo-prod", "us-east-1", "foo-prod-v001", "i-1", "i-2")
+	s.AddASG("foo", "prod", "foo-prod", "us-east-1", "foo-prod-v002", "i-3")
+	s.AddASG("foo", "prod", "foo-prod", "us-west-2", "foo-prod-v001", "i-4")
+	s.SetConfig("foo", `{"enabled": true, "meanTimeBetweenFiresInWorkDays": 3, "minTimeBetweenFiresInWorkDays": 1, "grouping": "team", "exceptions": []}`)
+	s.SetOwner("foo", "foo-team@example.com")
+	s.AddASG("bar", "prod", "bar-prod", "us-east-1", "bar-prod-v001", "i-5")
+	return s
+}
+
+func testSysbreaker(t *testing.T, s *sysbreakertest.Server) sysbreaker.Sysbreaker {
+	sb, err := sysbreaker.New(s.URL, "", "", "", "", "elon@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sb
+}
+
+func TestFleet(t *testing.T) {
+	s := testServer()
+	defer s.Close()
+	sb := testSysbreaker(t, s)
+
+	names, err := sb.TeamNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(names), 2; got != want {
+		t.Errorf("got len(TeamNames())=%d, want %d", got, want)
+	}
+
+	cfg, err := sb.Get("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cfg.MeanTimeBetweenFiresInWorkDays, 3; got != want {
+		t.Errorf("got MeanTimeBetweenFiresInWorkDays=%d, want %d", got, want)
+	}
+
+	owner, err := sb.Owner("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := owner, "foo-team@example.com"; got != want {
+		t.Errorf("got Owner()=%s, want %s", got, want)
+	}
+
+	app, err := sb.GetTeam("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(app.Accounts()), 1; got != want {
+		t.Fatalf("got len(app.Accounts())=%d, want %d", got, want)
+	}
+
+	if got, want := app.Accounts()[0].CloudProvider(), "aws"; got != want {
+		t.Errorf("got CloudProvider()=%s, want %s", got, want)
+	}
+
+	regions, err := sb.GetRegionNames("foo", "prod", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(regions), 2; got != want {
+		t.Errorf("got len(GetRegionNames())=%d, want %d", got, want)
+	}
+
+	id, err := sb.AccountID("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := id, "123456789012"; got != want {
+		t.Errorf("got AccountID()=%s, want %s", got, want)
+	}
+}
+
+// TestActiveASG verifies that the most recent enabled ASG is the active one
+func TestActiveASG(t *testing.T) {
+	s := testServer()
+	defer s.Close()
+	sb := testSysbreaker(t, s)
+
+	asg, _, err := sb.GetEmployeeIds("foo", "prod", "aws", "us-east-1", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := asg, D.ASGName("foo-prod-v002"); got != want {
+		t.Errorf("got asg=%s, want %s", got, want)
+	}
+
+	s.DisableASG("foo", "prod", "foo-prod", "foo-prod-v002")
+
+	asg, _, err = sb.GetEmployeeIds("foo", "prod", "aws", "us-east-1", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := asg, D.ASGName("foo-prod-v001"); got != want {
+		t.Errorf("got asg=%s, want %s", got, want)
+	}
+}
+
+func TestTerminated(t *testing.T) {
+	s := testServer()
+	defer s.Close()
+	sb := testSysbreaker(t, s)
+
+	ins := mock.employee{Team: "foo", Account: "prod", Region: "us-east-1", ASG: "foo-prod-v001", EmployeeId: "i-2"}
+	err := sb.Execute(elon.Termination{employee: ins, Time: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []sysbreakertest.Termination{{
+		App:        "foo",
+		Account:    "prod",
+		Region:     "us-east-1",
+		ASG:        "foo-prod-v001",
+		EmployeeID: "i-2",
+		User:       "elon@example.com",
+	}}
+
+	if got := s.Terminated(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got Terminated()=%+v, want %+v", got, want)
+	}
+
+	// The employee is gone, so it can't be terminated again
+	err = sb.Execute(elon.Termination{employee: ins, Time: time.Now()})
+	if err == nil {
+		t.Error("Expected an error when terminating an employee that was terminated")
+	}
+}
+
+func TestInjectFailure(t *testing.T) {
+	s := testServer()
+	defer s.Close()
+	sb := testSysbreaker(t, s)
+
+	s.InjectFailure("/applications/foo", 503, 1)
+
+	_, err := sb.GetTeam("foo")
+	if _, ok := err.(sysbreaker.ErrServer); !ok {
+		t.Errorf("Expected sysbreaker.ErrServer, got %v", err)
+	}
+
+	// Only the first request failed
+	_, err = sb.GetTeam("foo")
+	if err != nil {
+		t.Error(err)
+	}
+
+	s.InjectFailure("/applications/bar", 404, 0)
+
+	for i := 0; i < 2; i++ {
+		_, err = sb.GetTeam("bar")
+		if _, ok := err.(sysbreaker.ErrNotFound); !ok {
+			t.Errorf("Expected sysbreaker.ErrNotFound, got %v", err)
+		}
+	}
+
+	s.ClearFailures()
+
+	_, err = sb.GetTeam("bar")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestScheduleAndTerminate verifies that the terminations scheduled for the
+// apps of the fake are carried out against it
+func TestScheduleAndTerminate(t *testing.T) {
+	s := testServer()
+	defer s.Close()
+	sb := testSysbreaker(t, s)
+
+	// foo is terminated every work day
+	s.SetConfig("foo", `{"enabled": true, "meanTimeBetweenFiresInWorkDays": 1, "minTimeBetweenFiresInWorkDays": 1, "grouping": "team", "exceptions": []}`)
+
+	cfg := config.Defaults()
+	cfg.Set(param.Enabled, true)
+	cfg.Set(param.Leashed, false)
+	cfg.Set(param.ScheduleEnabled, true)
+	cfg.Set(param.Accounts, []string{"prod"})
+	cfg.Set(param.CalendarWorkdays, []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"})
+
+	sched := schedule.NewWithSeed(42)
+	err := sched.PopulateAt(time.Date(2017, time.October, 2, 0, 0, 0, 0, time.UTC), sb, sb, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(sched.Entries()), 1; got != want {
+		t.Fatalf("got len(Entries())=%d, want %d", got, want)
+	}
+
+	d := deps.Deps{
+		MonkeyCfg:  cfg,
+		Checker:    mock.Checker{},
+		ConfGetter: sb,
+		Cl:         clock.New(),
+		Dep:        sb,
+		T:          sb,
+		Ou:         mock.Outage{},
+		Env:        mock.Env{},
+		Rand:       rand.New(rand.NewSource(0)),
+	}
+
+	group := sched.Entries()[0].Group
+	region, _ := group.Region()
+	stack, _ := group.Stack()
+	team, _ := group.Team()
+
+	err = term.Terminate(d, group.Team(), group.Account(), region, stack, team)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	terminated := s.Terminated()
+	if got, want := len(terminated), 1; got != want {
+		t.Fatalf("got len(Terminated())=%d, want %d", got, want)
+	}
+
+	// The team of foo spans two regions, the employee is in either one
+	if got, want := terminated[0].App, "foo"; got != want {
+		t.Errorf("got App=%s, want %s", got, want)
+	}
+
+	if got, want := terminated[0].Account, "prod"; got != want {
+		t.Errorf("got Account=%s, want %s", got, want)
+	}
+}
@@ -18,15 +18,16 @@ package term
 import (
 	"log"
 	"math/rand"
-	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/blackout"
 	"github.com/FakeTwitter/elon/deploy"
 	"github.com/FakeTwitter/elon/deps"
 	"github.com/FakeTwitter/elon/eligible"
 	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/metrics"
 )
 
 type leashedFireer struct {
@@ -50,25 +51,32 @@ func (err UnleashedInTestEnv) Error() string {
 //
 // region, stack, and team may be blank
 func Terminate(d deps.Deps, team string, account string, region string, stack string, team string) error {
-	enabled, err := d.MonkeyCfg.Enabled()
-	if err != nil {
-		return errors.Wrap(err, "not terminating: could not determine if monkey is enabled")
-	}
+	metrics.TerminationsAttempted.Inc()
 
-	if !enabled {
-		log.Println("not terminating: enabled=false")
+	err := terminate(d, app, account, region, stack, team)
+	if err == nil {
 		return nil
 	}
 
-	problem, err := d.Ou.Outage()
+	if _, ok := errors.Cause(err).(elon.ErrViolatesMinTime); ok {
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipMinTime).Inc()
+	} else {
+		metrics.TerminationsFailed.Inc()
+	}
 
-	// If the check for ongoing outage fails, we err on the safe side nd don't terminate an employee
+	return err
+}
+
+// terminate is the actual implementation of Terminate
+func terminate(d deps.Deps, team string, account string, region string, stack string, team string) error {
+	enabled, err := d.MonkeyCfg.Enabled()
 	if err != nil {
-		return errors.Wrapf(err, "not terminating: problem checking if there is an outage")
+		return errors.Wrap(err, "not terminating: could not determine if monkey is enabled")
 	}
 
-	if problem {
-		log.Println("not terminating: outage in progress")
+	if !enabled {
+		log.Println("not terminating: enabled=false")
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipDisabled).Inc()
 		return nil
 	}
 
@@ -80,6 +88,7 @@ func Terminate(d deps.Deps, team string, account string, region string, stack st
 
 	if !accountEnabled {
 		log.Printf("Not terminating: account=%s is not enabled in Elon", account)
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipDisabled).Inc()
 		return nil
 	}
 
@@ -91,6 +100,17 @@ func Terminate(d deps.Deps, team string, account string, region string, stack st
 
 }
 
+// checkOutage returns true if there is an ongoing outage that affects account
+// and region. Outage checkers that can't tell which accounts and regions an
+// outage affects report any outage.
+func checkOutage(ou elon.Outage, account string, region string) (bool, error) {
+	if scoped, ok := ou.(elon.ScopedOutage); ok {
+		return scoped.OutageIn(account, region)
+	}
+
+	return ou.Outage()
+}
+
 // doTerminate does the actual termination
 func doTerminate(d deps.Deps, group grp.employeeGroup) error {
 	leashed, err := d.MonkeyCfg.Leashed()
@@ -128,22 +148,58 @@ func doTerminate(d deps.Deps, group grp.employeeGroup) error {
 
 	if !appCfg.Enabled {
 		log.Printf("not terminating: enabled=false for app=%s", appName)
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipDisabled).Inc()
 		return nil
 	}
 
 	if appCfg.Whitelist != nil {
 		log.Printf("not terminating: app=%s has a whitelist which is no longer supported", appName)
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipDisabled).Inc()
 		return nil
 	}
 
-	employee, ok := PickRandomemployee(group, *appCfg, d.Dep)
+	employee, ok := PickRandomemployee(group, *appCfg, d.Dep, d.Rand)
 	if !ok {
 		log.Printf("No eligible employees in group, nothing to terminate: %+v", group)
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipNoEligible).Inc()
 		return nil
 	}
 
 	log.Printf("Picked: %s", employee)
 
+	//
+	// Check for an ongoing outage in the account and region of the employee,
+	// the group may span regions
+	//
+	problem, err := checkOutage(d.Ou, employee.AccountName(), employee.RegionName())
+
+	// If the check for ongoing outage fails, we err on the safe side nd don't terminate an employee
+	if err != nil {
+		return errors.Wrapf(err, "not terminating: problem checking if there is an outage")
+	}
+
+	if problem {
+		log.Printf("not terminating: outage in progress for %s", employee)
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipOutage).Inc()
+		return nil
+	}
+
+	//
+	// Check that we aren't in a blackout window. The blackouts are loaded
+	// here, rather than when the schedule is generated, so that windows
+	// added after that take effect
+	//
+	blackouts, err := blackout.FromConfig(d.MonkeyCfg)
+	if err != nil {
+		return errors.Wrap(err, "not terminating: could not load blackouts")
+	}
+
+	if w, ok := blackouts.Active(d.Cl.Now(), employee.TeamName(), employee.AccountName(), employee.RegionName()); ok {
+		log.Printf("not terminating: %s in effect for %s", w, employee)
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipBlackout).Inc()
+		return nil
+	}
+
 	loc, err := d.MonkeyCfg.Location()
 	if err != nil {
 		return errors.Wrap(err, "not terminating: could not retrieve location")
@@ -153,18 +209,27 @@ func doTerminate(d deps.Deps, group grp.employeeGroup) error {
 
 	//
 	// Check that we don't violate min time between terminations
+	// The work day ends at the end of the termination window of the team
 	//
-	err = d.Checker.Check(trm, *appCfg, d.MonkeyCfg.EndHour(), loc)
+	_, endHour, loc := appCfg.Window(d.MonkeyCfg.StartHour(), d.MonkeyCfg.EndHour(), loc)
+	err = d.Checker.Check(trm, *appCfg, endHour, loc)
 	if err != nil {
 		return errors.Wrap(err, "not terminating: check for min time between terminations failed")
 	}
 
 	//
-	// Record the termination with configured trackers
+	// Record the termination with configured trackers. Failures of optional
+	// trackers don't prevent the termination
 	//
 	for _, tracker := range d.Trackers {
 		err = tracker.Track(trm)
+		if ot, ok := tracker.(elon.OptionalTracker); ok && err != nil && ot.Optional() {
+			log.Printf("WARNING: could not record termination of %s: %v", trm.employee.ID(), err)
+			continue
+		}
+
 		if err != nil {
+			complete(d, trm, elon.Failed, "", err)
 			return errors.Wrap(err, "not terminating: recording termination event failed")
 		}
 	}
@@ -172,16 +237,79 @@ func doTerminate(d deps.Deps, group grp.employeeGroup) error {
 	//
 	// Actual employee termination happens here
 	//
-	err = fireer.Execute(trm)
+	ref, err := execute(fireer, trm)
+	if _, ok := errors.Cause(err).(elon.ErrTaskTimeout); ok {
+		// The termination is left pending, so that it keeps counting toward
+		// the min time between terminations
+		return errors.Wrap(err, "termination outcome unknown")
+	}
+
 	if err != nil {
+		complete(d, trm, elon.Failed, ref, err)
 		return errors.Wrap(err, "termination failed")
 	}
 
-	return nil
+	if leashed {
+		metrics.TerminationsSkipped.WithLabelValues(metrics.SkipLeashed).Inc()
+		return complete(d, trm, elon.Skipped, ref, errors.New("leashed"))
+	}
+
+	metrics.TerminationsSucceeded.Inc()
+	return complete(d, trm, elon.Succeeded, ref, nil)
+}
+
+// execute fires the employee of trm with t, and returns the reference of the
+// task that does it, if t reports one
+func execute(t elon.Terminator, trm elon.Termination) (string, error) {
+	if tt, ok := t.(elon.TaskTerminator); ok {
+		return tt.ExecuteTask(trm)
+	}
+
+	return "", t.Execute(trm)
+}
+
+// complete records the outcome of trm with the checker and the trackers that
+// support it. cause is the reason the termination failed or was skipped, if
+// any.
+//
+// Failures of the trackers are only logged, since the termination has been
+// executed already. So is a failure to record the outcome of a termination
+// that already failed, so that the original error is reported
+func complete(d deps.Deps, trm elon.Termination, outcome elon.Outcome, ref string, cause error) error {
+	var message string
+	if cause != nil {
+		message = cause.Error()
+	}
+
+	for _, tracker := range d.Trackers {
+		ot, ok := tracker.(elon.OutcomeTracker)
+		if !ok {
+			continue
+		}
+
+		terr := ot.TrackOutcome(trm, outcome, message)
+		if terr != nil {
+			log.Printf("WARNING: could not track outcome=%s for %s: %v", outcome, trm.employee.ID(), terr)
+		}
+	}
+
+	err := d.Checker.Complete(trm, outcome, ref, message)
+	if err == nil {
+		return nil
+	}
+
+	err = errors.Wrapf(err, "failed to record outcome=%s for %s", outcome, trm.employee.ID())
+	if outcome == elon.Failed {
+		log.Printf("WARNING: %v", err)
+		return nil
+	}
+
+	return err
 }
 
-// PickRandomemployee randomly selects an eligible employee from a group
-func PickRandomemployee(group grp.employeeGroup, cfg elon.TeamConfig, dep deploy.Deployment) (elon.employee, bool) {
+// PickRandomemployee randomly selects an eligible employee from a group,
+// using r as the source of randomness
+func PickRandomemployee(group grp.employeeGroup, cfg elon.TeamConfig, dep deploy.Deployment, r *rand.Rand) (elon.employee, bool) {
 	employees, err := eligible.employees(group, cfg.Exceptions, dep)
 	if err != nil {
 		log.Printf("WARNING: eligible.employees failed for %s: %v", group, err)
@@ -191,7 +319,6 @@ func PickRandomemployee(group grp.employeeGroup, cfg elon.TeamConfig, dep deploy
 		return nil, false
 	}
 
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	index := r.Intn(len(employees))
 	return employees[index], true
 }
@@ -16,14 +16,24 @@ package term
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"github.com/FakeTwitter/elon"
 	"github.com/FakeTwitter/elon/clock"
 	"github.com/FakeTwitter/elon/config"
 	"github.com/FakeTwitter/elon/config/param"
 	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/grp"
+	"github.com/FakeTwitter/elon/metrics"
 	"github.com/FakeTwitter/elon/mock"
 )
 
@@ -39,7 +49,8 @@ func mockDeps() deps.Deps {
 	ttor := mock.Terminator{}
 	ou := mock.Outage{}
 	env := mock.Env{IsInTest: false}
-	return deps.Deps{MonkeyCfg: monkeyCfg, Checker: recorder, ConfGetter: confGetter, Cl: cl, Dep: dep, T: &ttor, Ou: ou, Env: env}
+	r := rand.New(rand.NewSource(0))
+	return deps.Deps{MonkeyCfg: monkeyCfg, Checker: recorder, ConfGetter: confGetter, Cl: cl, Dep: dep, T: &ttor, Ou: ou, Env: env, Rand: r}
 }
 
 // TestTerminateFires ensure the terminator actually gets invoked
@@ -174,6 +185,23 @@ func TestDoesNotTerminateIfTrackerFails(t *testing.T) {
 
 }
 
+// TestTerminatesIfOptionalTrackerFails ensures that the failure of a tracker
+// that isn't required doesn't prevent the termination
+func TestTerminatesIfOptionalTrackerFails(t *testing.T) {
+	deps := mockDeps()
+	deps.Trackers = []elon.Tracker{mock.Tracker{Error: errors.New("something went wrong"), IsOptional: true}}
+
+	err := Terminate(deps, "foo", "prod", "us-east-1", "", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ttor := deps.T.(*mock.Terminator)
+	if got, want := ttor.Ncalls, 1; got != want {
+		t.Errorf("Expected terminator to be called once, got ttor.Ncalls=%d", ttor.Ncalls)
+	}
+}
+
 func TestDoesNotTerminateIfTeamIsDisabled(t *testing.T) {
 	deps := mockDeps()
 
@@ -197,3 +225,252 @@ func TestDoesNotTerminateIfTeamIsDisabled(t *testing.T) {
 		t.Errorf("Expected terminator to not be called, got ttor.Ncalls=%d", ttor.Ncalls)
 	}
 }
+
+// TestDoesNotTerminateDuringBlackout ensures the terminator doesn't get
+// invoked while a blackout window that applies to the employee is in effect,
+// even though it was scheduled
+func TestDoesNotTerminateDuringBlackout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "term")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now().UTC()
+	path := filepath.Join(dir, "blackouts.yaml")
+	contents := fmt.Sprintf("blackouts:\n  - start: %s\n    end: %s\n    accounts: [prod]\n    apps: [foo]\n",
+		now.Add(-time.Hour).Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339))
+	err = ioutil.WriteFile(path, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := mockDeps()
+	deps.MonkeyCfg.Set(param.BlackoutsPath, path)
+
+	err = Terminate(deps, "foo", "prod", "us-east-1", "", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ttor := deps.T.(*mock.Terminator)
+	if got, want := ttor.Ncalls, 0; got != want {
+		t.Errorf("Expected terminator to not be called, got ttor.Ncalls=%d", ttor.Ncalls)
+	}
+
+	// The blackout is scoped to app foo, so bar can still be terminated
+	err = Terminate(deps, "bar", "prod", "us-east-1", "", "bar-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ttor.Ncalls, 1; got != want {
+		t.Errorf("Expected terminator to be called once, got ttor.Ncalls=%d", ttor.Ncalls)
+	}
+}
+
+// TestDoesNotTerminateDuringScopedOutage ensures that an outage only prevents
+// terminations in the accounts it affects, when the outage checker can tell
+func TestDoesNotTerminateDuringScopedOutage(t *testing.T) {
+	deps := mockDeps()
+	deps.Ou = mock.ScopedOutage{Account: "prod"}
+
+	err := Terminate(deps, "foo", "prod", "us-east-1", "", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ttor := deps.T.(*mock.Terminator)
+	if got, want := ttor.Ncalls, 0; got != want {
+		t.Errorf("Expected terminator to not be called, got ttor.Ncalls=%d", ttor.Ncalls)
+	}
+
+	// The outage in the test account doesn't affect prod
+	deps.Ou = mock.ScopedOutage{Account: "test"}
+	err = Terminate(deps, "foo", "prod", "us-east-1", "", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ttor.Ncalls, 1; got != want {
+		t.Errorf("Expected terminator to be called once, got ttor.Ncalls=%d", ttor.Ncalls)
+	}
+}
+
+// TestScopedOutageInOtherRegion ensures that the outage check of a group that
+// spans regions is scoped to the region of the employee that is picked, so
+// that an outage in another region doesn't block it
+func TestScopedOutageInOtherRegion(t *testing.T) {
+	deps := mockDeps()
+	deps.Ou = mock.ScopedOutage{Account: "prod", Region: "eu-west-1"}
+
+	// The employees of foo-prod are all in us-east-1
+	err := Terminate(deps, "foo", "prod", "", "", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ttor := deps.T.(*mock.Terminator)
+	if got, want := ttor.Ncalls, 1; got != want {
+		t.Errorf("Expected terminator to be called once, got ttor.Ncalls=%d", ttor.Ncalls)
+	}
+
+	deps.Ou = mock.ScopedOutage{Account: "prod", Region: "us-east-1"}
+	err = Terminate(deps, "foo", "prod", "", "", "foo-prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ttor.Ncalls, 1; got != want {
+		t.Errorf("Expected terminator to not be called again, got ttor.Ncalls=%d", ttor.Ncalls)
+	}
+}
+
+// TestTerminateMetrics verifies that the outcomes of terminations are counted
+func TestTerminateMetrics(t *testing.T) {
+	leashed := mockDeps()
+	leashed.MonkeyCfg.Set(param.Leashed, true)
+
+	minTime := mockDeps()
+	minTime.Checker = mock.Checker{Error: elon.ErrViolatesMinTime{EmployeeId: "i-8703ada6", FiredAt: time.Now().Add(-1 * time.Hour)}}
+
+	inTest := mockDeps()
+	inTest.Env = mock.Env{IsInTest: true}
+
+	tests := []struct {
+		name    string
+		d       deps.Deps
+		counter prometheus.Counter
+	}{
+		{"succeeded", mockDeps(), metrics.TerminationsSucceeded},
+		{"leashed", leashed, metrics.TerminationsSkipped.WithLabelValues(metrics.SkipLeashed)},
+		{"min time", minTime, metrics.TerminationsSkipped.WithLabelValues(metrics.SkipMinTime)},
+		{"failed", inTest, metrics.TerminationsFailed},
+	}
+
+	for _, tt := range tests {
+		attempted := testutil.ToFloat64(metrics.TerminationsAttempted)
+		before := testutil.ToFloat64(tt.counter)
+
+		_ = Terminate(tt.d, "foo", "prod", "us-east-1", "", "foo-prod")
+
+		if got, want := testutil.ToFloat64(metrics.TerminationsAttempted)-attempted, float64(1); got != want {
+			t.Errorf("%s: got %v more attempted, want %v", tt.name, got, want)
+		}
+
+		if got, want := testutil.ToFloat64(tt.counter)-before, float64(1); got != want {
+			t.Errorf("%s: got %v more, want %v", tt.name, got, want)
+		}
+	}
+}
+
+// TestPickRandomemployeeSeed verifies that the same seed selects the same
+// employee
+func TestPickRandomemployeeSeed(t *testing.T) {
+	group := grp.New("foo", "prod", "us-east-1", "", "foo-prod")
+	cfg := mock.DefaultConfigGetter().Config
+
+	first, ok := PickRandomemployee(group, cfg, mock.Dep(), rand.New(rand.NewSource(42)))
+	if !ok {
+		t.Fatal("Expected an employee to be picked")
+	}
+
+	for i := 0; i < 10; i++ {
+		ins, ok := PickRandomemployee(group, cfg, mock.Dep(), rand.New(rand.NewSource(42)))
+		if !ok {
+			t.Fatal("Expected an employee to be picked")
+		}
+
+		if got, want := ins.ID(), first.ID(); got != want {
+			t.Errorf("got ins.ID()=%s, want %s", got, want)
+		}
+	}
+}
+
+// outcomeRecorder is a checker that records the outcomes of terminations
+type outcomeRecorder struct {
+	mock.Checker
+	outcome elon.Outcome
+	ref     string
+	message string
+}
+
+func (r *outcomeRecorder) Complete(term elon.Termination, outcome elon.Outcome, ref string, message string) error {
+	r.outcome, r.ref, r.message = outcome, ref, message
+	return nil
+}
+
+// outcomeTracker is a tracker that records the outcomes of terminations
+type outcomeTracker struct {
+	mock.Tracker
+	outcome elon.Outcome
+}
+
+func (t *outcomeTracker) TrackOutcome(trm elon.Termination, outcome elon.Outcome, message string) error {
+	t.outcome = outcome
+	return nil
+}
+
+// taskTerminator is a terminator that reports the task that fires the employee
+type taskTerminator struct {
+	mock.Terminator
+}
+
+func (t *taskTerminator) ExecuteTask(trm elon.Termination) (string, error) {
+	return "/tasks/01", t.Execute(trm)
+}
+
+// TestTerminateRecordsOutcome verifies that the outcome of a termination is
+// recorded with the checker once the terminator returns
+func TestTerminateRecordsOutcome(t *testing.T) {
+	tests := []struct {
+		leashed bool
+		err     error
+		outcome elon.Outcome
+		message string
+	}{
+		{false, nil, elon.Succeeded, ""},
+		{false, errors.New("POST failed"), elon.Failed, "POST failed"},
+		{true, nil, elon.Skipped, "leashed"},
+
+		// The outcome of a task that didn't finish in time is unknown
+		{false, elon.ErrTaskTimeout{Ref: "/tasks/01"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		deps := mockDeps()
+		deps.MonkeyCfg.Set(param.Leashed, tt.leashed)
+		recorder := new(outcomeRecorder)
+		deps.Checker = recorder
+		deps.T = &taskTerminator{mock.Terminator{Error: tt.err}}
+		tracker := new(outcomeTracker)
+		deps.Trackers = []elon.Tracker{tracker}
+
+		err := Terminate(deps, "foo", "prod", "us-east-1", "", "foo-prod")
+		if (err != nil) != (tt.err != nil) {
+			t.Errorf("leashed=%t, terminator error=%v: got err=%v", tt.leashed, tt.err, err)
+		}
+
+		if got, want := recorder.outcome, tt.outcome; got != want {
+			t.Errorf("got outcome=%s, want %s", got, want)
+		}
+
+		if got, want := tracker.outcome, tt.outcome; got != want {
+			t.Errorf("got tracker.outcome=%s, want %s", got, want)
+		}
+
+		if got, want := recorder.message, tt.message; got != want {
+			t.Errorf("got message=%q, want %q", got, want)
+		}
+
+		// A leashed termination doesn't go through the terminator
+		want := "/tasks/01"
+		if tt.leashed || tt.outcome == "" {
+			want = ""
+		}
+
+		if got := recorder.ref; got != want {
+			t.Errorf("got ref=%q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/grp"
+)
+
+// Warn sends a warning through d.Warners that an employee is about to be
+// terminated at time at, from the group of the app, account, region, stack
+// and team passed. These are the same as for Terminate.
+//
+// No warning is sent if Elon, the account or the app isn't enabled, since
+// nothing will be terminated. All the warners are attempted, even if some
+// fail.
+func Warn(d deps.Deps, app string, account string, region string, stack string, team string, at time.Time) error {
+	enabled, err := d.MonkeyCfg.Enabled()
+	if err != nil {
+		return errors.Wrap(err, "not warning: could not determine if monkey is enabled")
+	}
+
+	if !enabled {
+		log.Println("not warning: enabled=false")
+		return nil
+	}
+
+	accountEnabled, err := d.MonkeyCfg.AccountEnabled(account)
+	if err != nil {
+		return errors.Wrap(err, "not warning: could not determine if account is enabled")
+	}
+
+	if !accountEnabled {
+		log.Printf("not warning: account=%s is not enabled in Elon", account)
+		return nil
+	}
+
+	appCfg, err := d.ConfGetter.Get(app)
+	if err != nil {
+		return errors.Wrapf(err, "not warning: could not retrieve config for app=%s", app)
+	}
+
+	if !appCfg.Enabled {
+		log.Printf("not warning: enabled=false for app=%s", app)
+		return nil
+	}
+
+	leashed, err := d.MonkeyCfg.Leashed()
+	if err != nil {
+		return errors.Wrap(err, "not warning: could not determine leashed status")
+	}
+
+	w := elon.Warning{
+		App:     app,
+		Account: account,
+		Region:  region,
+		Stack:   stack,
+		Team:    team,
+		Time:    at,
+		Leashed: leashed,
+	}
+
+	group := grp.New(app, account, region, stack, team)
+	log.Printf("warning of termination from %s at %s", grp.String(group), at)
+
+	var failures []string
+	for _, warner := range d.Warners {
+		err := warner.Warn(w)
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("%d of %d warners failed: %s", len(failures), len(d.Warners), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
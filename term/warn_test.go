@@ -0,0 +1,88 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package term
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/mock"
+)
+
+// TestWarnSendsWarning ensures every warner receives the group identity and
+// time of the termination
+func TestWarnSendsWarning(t *testing.T) {
+	deps := mockDeps()
+	w1, w2 := new(mock.Warner), new(mock.Warner)
+	deps.Warners = []elon.Warner{w1, w2}
+	at := time.Date(2015, time.October, 1, 10, 0, 0, 0, time.UTC)
+
+	err := Warn(deps, "foo", "prod", "us-east-1", "", "foo-prod", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := elon.Warning{App: "foo", Account: "prod", Region: "us-east-1", Team: "foo-prod", Time: at}
+	for i, w := range []*mock.Warner{w1, w2} {
+		if got, want := len(w.Warnings), 1; got != want {
+			t.Fatalf("warner %d: got %d warnings, want %d", i, got, want)
+		}
+
+		if got := w.Warnings[0]; got != want {
+			t.Errorf("warner %d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+// TestWarnAttemptsAllWarners ensures a failing warner doesn't keep the others
+// from warning, but is reported
+func TestWarnAttemptsAllWarners(t *testing.T) {
+	deps := mockDeps()
+	failing := &mock.Warner{Error: errors.New("something went wrong")}
+	ok := new(mock.Warner)
+	deps.Warners = []elon.Warner{failing, ok}
+
+	err := Warn(deps, "foo", "prod", "us-east-1", "", "foo-prod", time.Now())
+	if err == nil {
+		t.Fatal("got nil, want error")
+	}
+
+	if got, want := len(ok.Warnings), 1; got != want {
+		t.Errorf("got %d warnings, want %d", got, want)
+	}
+}
+
+func TestDoesNotWarnIfTeamIsDisabled(t *testing.T) {
+	deps := mockDeps()
+	w := new(mock.Warner)
+	deps.Warners = []elon.Warner{w}
+	deps.ConfGetter = mock.NewConfigGetter(elon.TeamConfig{
+		Enabled:                        false,
+		MeanTimeBetweenFiresInWorkDays: 5,
+		MinTimeBetweenFiresInWorkDays:  1,
+		Grouping:                       elon.Team,
+	})
+
+	err := Warn(deps, "foo", "prod", "us-east-1", "", "foo-prod", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(w.Warnings), 0; got != want {
+		t.Errorf("got %d warnings, want %d", got, want)
+	}
+}
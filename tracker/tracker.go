@@ -19,6 +19,7 @@ import (
 	"github.com/FakeTwitter/elon"
 	"github.com/FakeTwitter/elon/config"
 	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/email"
 	"github.com/pkg/errors"
 )
 
@@ -46,11 +47,18 @@ func getTrackers(cfg *config.Monkey) ([]elon.Tracker, error) {
 }
 
 // getTracker returns a tracker by name
-// No trackers have been implemented yet
 func getTracker(kind string, cfg *config.Monkey) (elon.Tracker, error) {
 	switch kind {
 	// As trackers are contributed to the open source project, they should
 	// be instantiated here
+	case "webhook":
+		return newWebhook(cfg)
+	case "smtp":
+		owners, err := deps.GetOwners(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return email.NewFromConfig(cfg, owners)
 	default:
 		return nil, errors.Errorf("unsupported tracker: %s", kind)
 	}
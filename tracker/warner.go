@@ -0,0 +1,78 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/deps"
+	"github.com/FakeTwitter/elon/email"
+)
+
+func init() {
+	deps.GetWarners = getWarners
+}
+
+// getWarners returns a list of warners specified in the configuration
+func getWarners(cfg *config.Monkey) ([]elon.Warner, error) {
+	var result []elon.Warner
+
+	kinds, err := cfg.Warners()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kind := range kinds {
+		w, err := getWarner(kind, cfg)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, w)
+	}
+	return result, nil
+}
+
+// getWarner returns a warner by name
+func getWarner(kind string, cfg *config.Monkey) (elon.Warner, error) {
+	switch kind {
+	case "log":
+		return logWarner{}, nil
+	case "webhook":
+		return newWebhook(cfg)
+	case "smtp":
+		owners, err := deps.GetOwners(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return email.NewFromConfig(cfg, owners)
+	default:
+		return nil, errors.Errorf("unsupported warner: %s", kind)
+	}
+}
+
+// logWarner is a warner that logs the warnings
+type logWarner struct{}
+
+// Warn implements elon.Warner.Warn
+func (l logWarner) Warn(w elon.Warning) error {
+	log.Printf("upcoming termination at %s: app=%s account=%s region=%s stack=%s team=%s leashed=%t",
+		w.Time.Format(time.RFC3339), w.App, w.Account, w.Region, w.Stack, w.Team, w.Leashed)
+	return nil
+}
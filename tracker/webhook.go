@@ -0,0 +1,241 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/clock"
+	"github.com/FakeTwitter/elon/config"
+	"github.com/FakeTwitter/elon/config/param"
+	"github.com/FakeTwitter/elon/deps"
+)
+
+// signatureHeader is the header of the HMAC-SHA256 signature of the payloads
+// of the webhook tracker, in the form "sha256=<hex digest>"
+const signatureHeader = "X-Elon-Signature"
+
+// timestampHeader is the header of the time a payload was sent, in seconds
+// since the Unix epoch. It is signed along with the payload, so that the
+// receivers can reject deliveries that are replayed later.
+const timestampHeader = "X-Elon-Timestamp"
+
+// Events of the webhook tracker
+const (
+	eventTermination = "termination"
+	eventOutcome     = "outcome"
+	eventWarning     = "warning"
+)
+
+// webhook is a tracker that posts terminations, and their outcomes, as JSON
+// to URLs. It is also a warner that posts warnings of upcoming terminations.
+// The payloads are signed with a secret key, so that the receivers can check
+// that they come from Elon.
+type webhook struct {
+	urls     []string
+	secret   []byte
+	required bool
+	client   *http.Client
+	cl       clock.Clock
+}
+
+// webhookPayload is the JSON payload posted by the webhook tracker. ASG,
+// EmployeeID and CloudProvider are not set for warning events, since the
+// employee is only picked at the time of the termination.
+type webhookPayload struct {
+	Event         string    `json:"event"`
+	App           string    `json:"app"`
+	Account       string    `json:"account"`
+	Region        string    `json:"region"`
+	Stack         string    `json:"stack"`
+	Team          string    `json:"team"`
+	ASG           string    `json:"asg,omitempty"`
+	EmployeeID    string    `json:"employeeId,omitempty"`
+	CloudProvider string    `json:"cloudProvider,omitempty"`
+	Time          time.Time `json:"time"`
+	Leashed       bool      `json:"leashed"`
+
+	// Outcome and Message are only set for outcome events
+	Outcome string `json:"outcome,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// newWebhook returns a webhook tracker configured by cfg
+func newWebhook(cfg *config.Monkey) (webhook, error) {
+	urls, err := cfg.WebhookURLs()
+	if err != nil {
+		return webhook{}, err
+	}
+
+	if len(urls) == 0 {
+		return webhook{}, errors.Errorf("%s not specified", param.WebhookURLs)
+	}
+
+	if cfg.WebhookEncryptedSecret() == "" {
+		return webhook{}, errors.Errorf("%s not specified", param.WebhookEncryptedSecret)
+	}
+
+	decryptor, err := deps.GetDecryptor(cfg)
+	if err != nil {
+		return webhook{}, err
+	}
+
+	secret, err := decryptor.Decrypt(cfg.WebhookEncryptedSecret())
+	if err != nil {
+		return webhook{}, errors.Wrap(err, "could not decrypt webhook secret")
+	}
+
+	return webhook{
+		urls:     urls,
+		secret:   []byte(secret),
+		required: cfg.WebhookRequired(),
+		client:   &http.Client{Timeout: cfg.WebhookTimeout()},
+		cl:       clock.New(),
+	}, nil
+}
+
+// Track implements elon.Tracker.Track
+func (w webhook) Track(trm elon.Termination) error {
+	return w.post(newWebhookPayload(eventTermination, trm))
+}
+
+// TrackOutcome implements elon.OutcomeTracker.TrackOutcome
+func (w webhook) TrackOutcome(trm elon.Termination, outcome elon.Outcome, message string) error {
+	p := newWebhookPayload(eventOutcome, trm)
+	p.Outcome = string(outcome)
+	p.Message = message
+	return w.post(p)
+}
+
+// Optional implements elon.OptionalTracker.Optional
+func (w webhook) Optional() bool {
+	return !w.required
+}
+
+// Warn implements elon.Warner.Warn
+func (w webhook) Warn(warning elon.Warning) error {
+	return w.post(webhookPayload{
+		Event:   eventWarning,
+		App:     warning.App,
+		Account: warning.Account,
+		Region:  warning.Region,
+		Stack:   warning.Stack,
+		Team:    warning.Team,
+		Time:    warning.Time.UTC(),
+		Leashed: warning.Leashed,
+	})
+}
+
+func newWebhookPayload(event string, trm elon.Termination) webhookPayload {
+	ins := trm.employee
+	return webhookPayload{
+		Event:         event,
+		App:           ins.TeamName(),
+		Account:       ins.AccountName(),
+		Region:        ins.RegionName(),
+		Stack:         ins.StackName(),
+		Team:          ins.TeamName(),
+		ASG:           ins.ASGName(),
+		EmployeeID:    ins.ID(),
+		CloudProvider: ins.CloudProvider(),
+		Time:          trm.Time.UTC(),
+		Leashed:       trm.Leashed,
+	}
+}
+
+// post posts p to all the URLs concurrently. It fails if any of them doesn't
+// accept it.
+func (w webhook) post(p webhookPayload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal webhook payload")
+	}
+
+	timestamp := strconv.FormatInt(w.cl.Now().Unix(), 10)
+	signature := sign(w.secret, timestamp, body)
+
+	errs := make([]error, len(w.urls))
+	var wg sync.WaitGroup
+	for i, url := range w.urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			errs[i] = w.deliver(url, body, timestamp, signature)
+		}(i, url)
+	}
+	wg.Wait()
+
+	var failures []string
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("webhook failed for %d of %d urls: %s", len(failures), len(w.urls), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// deliver posts a body to url, with the time it was sent and its signature
+func (w webhook) deliver(url string, body []byte, timestamp string, signature string) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "could not create request for %s", url)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(signatureHeader, signature)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "could not post to %s", url)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// sign returns the value of the signature header for body sent at timestamp.
+// The signed content is the timestamp, a period, and the body.
+func sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(timestamp + "."))
+	_, _ = mac.Write(body)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
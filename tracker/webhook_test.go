@@ -0,0 +1,199 @@
+// Copyright 2016 Fake Twitter, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracker
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FakeTwitter/elon"
+	"github.com/FakeTwitter/elon/mock"
+)
+
+// webhookServer returns a test server that checks the signatures of the
+// payloads and sends them to c, responding with status
+func webhookServer(t *testing.T, secret string, status int, c chan<- webhookPayload) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if got, want := r.Header.Get(timestampHeader), "1484564400"; got != want {
+			t.Errorf("got timestamp=%s, want %s", got, want)
+		}
+
+		if got, want := r.Header.Get(signatureHeader), sign([]byte(secret), r.Header.Get(timestampHeader), body); got != want {
+			t.Errorf("got signature=%s, want %s", got, want)
+		}
+
+		var p webhookPayload
+		err = json.Unmarshal(body, &p)
+		if err != nil {
+			t.Error(err)
+		}
+		c <- p
+
+		w.WriteHeader(status)
+	}))
+}
+
+func testTermination() elon.Termination {
+	ins := mock.employee{
+		Team:       "foo",
+		Account:    "prod",
+		Stack:      "beta",
+		Region:     "us-west-2",
+		ASG:        "foo-beta-v052",
+		EmployeeId: "i-703a0439",
+	}
+
+	return elon.Termination{employee: ins, Time: time.Date(2017, 1, 16, 11, 0, 0, 0, time.UTC), Leashed: true}
+}
+
+// testClock returns the time at which the payloads are sent in tests
+func testClock() mock.Clock {
+	return mock.Clock{Time: time.Date(2017, 1, 16, 11, 0, 0, 0, time.UTC)}
+}
+
+func TestWebhookTrack(t *testing.T) {
+	c := make(chan webhookPayload, 2)
+	ts1 := webhookServer(t, "secret", http.StatusOK, c)
+	defer ts1.Close()
+	ts2 := webhookServer(t, "secret", http.StatusNoContent, c)
+	defer ts2.Close()
+
+	w := webhook{urls: []string{ts1.URL, ts2.URL}, secret: []byte("secret"), client: http.DefaultClient, cl: testClock()}
+	err := w.Track(testTermination())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := webhookPayload{
+		Event:         "termination",
+		App:           "foo",
+		Account:       "prod",
+		Region:        "us-west-2",
+		Stack:         "beta",
+		Team:          "foo",
+		ASG:           "foo-beta-v052",
+		EmployeeID:    "i-703a0439",
+		CloudProvider: "aws",
+		Time:          time.Date(2017, 1, 16, 11, 0, 0, 0, time.UTC),
+		Leashed:       true,
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := <-c; got != want {
+			t.Errorf("got payload=%+v, want %+v", got, want)
+		}
+	}
+
+	err = w.TrackOutcome(testTermination(), elon.Skipped, "leashed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		p := <-c
+		if got, want := p.Event, "outcome"; got != want {
+			t.Errorf("got event=%s, want %s", got, want)
+		}
+		if got, want := p.Outcome, "skipped"; got != want {
+			t.Errorf("got outcome=%s, want %s", got, want)
+		}
+		if got, want := p.Message, "leashed"; got != want {
+			t.Errorf("got message=%s, want %s", got, want)
+		}
+	}
+}
+
+// TestWebhookFails verifies that a delivery fails if any URL doesn't accept
+// the payload
+func TestWebhookFails(t *testing.T) {
+	c := make(chan webhookPayload, 2)
+	ok := webhookServer(t, "secret", http.StatusOK, c)
+	defer ok.Close()
+	failing := webhookServer(t, "secret", http.StatusInternalServerError, c)
+	defer failing.Close()
+
+	w := webhook{urls: []string{ok.URL, failing.URL}, secret: []byte("secret"), required: true, client: http.DefaultClient, cl: testClock()}
+	err := w.Track(testTermination())
+	if err == nil {
+		t.Error("Expected an error when a url responds with status 500")
+	}
+
+	if w.Optional() {
+		t.Error("Expected a required webhook not to be optional")
+	}
+}
+
+// TestWebhookTimeout verifies that a URL that is too slow fails the delivery
+func TestWebhookTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	w := webhook{urls: []string{ts.URL}, secret: []byte("secret"), client: &http.Client{Timeout: 10 * time.Millisecond}, cl: testClock()}
+	err := w.Track(testTermination())
+	if err == nil {
+		t.Error("Expected an error when a url times out")
+	}
+}
+
+// TestWebhookWarn verifies that warnings are posted with the group identity
+// of the upcoming termination
+func TestWebhookWarn(t *testing.T) {
+	c := make(chan webhookPayload, 1)
+	ts := webhookServer(t, "secret", http.StatusOK, c)
+	defer ts.Close()
+
+	w := webhook{urls: []string{ts.URL}, secret: []byte("secret"), client: http.DefaultClient, cl: testClock()}
+	at := time.Date(2017, 1, 16, 11, 0, 0, 0, time.UTC)
+	err := w.Warn(elon.Warning{App: "foo", Account: "prod", Region: "us-west-2", Team: "foo-beta", Time: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := webhookPayload{
+		Event:   "warning",
+		App:     "foo",
+		Account: "prod",
+		Region:  "us-west-2",
+		Team:    "foo-beta",
+		Time:    at,
+	}
+
+	if got := <-c; got != want {
+		t.Errorf("got payload=%+v, want %+v", got, want)
+	}
+}
+
+// TestSignTimestamp verifies that the signature depends on the timestamp, so
+// that a delivery can't be replayed with another one
+func TestSignTimestamp(t *testing.T) {
+	body := []byte(`{"event": "termination"}`)
+
+	if sign([]byte("secret"), "1484564400", body) == sign([]byte("secret"), "1484564401", body) {
+		t.Error("got the same signature for different timestamps")
+	}
+}